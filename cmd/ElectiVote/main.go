@@ -3,10 +3,13 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/AndreanDjabbar/ElectiVote/config"
 	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/handlers"
 	"github.com/AndreanDjabbar/ElectiVote/internal/routes"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
 	"github.com/gin-contrib/sessions"
 	"github.com/joho/godotenv" // Pastikan package ini terinstal
 )
@@ -25,9 +28,19 @@ func main() {
 	logger := config.SetUpLogger()
 	logger.Info("Start setting up server")
 
-	router := config.SetUpRouter()
+	// An invalid storage configuration stops the server here.
+	utils.GetBlobStore()
+	router := config.SetUpRouter(utils.TemplateFuncs())
 	router.Use(sessions.Sessions("mainSession", config.SetUpSessionStore()))
 	routes.SetUpRoutes(router)
+	routes.SetUpAPIRoutes(router)
+	go handlers.RunAccountDeletionWorker(time.Hour)
+	utils.StartEmailWorkers(2)
+	utils.StartWebhookWorkers(2)
+	go utils.BackfillImageVariants()
+	go utils.RefreshImageVariants(time.Minute)
+	go utils.RunCandidateImportSweeper(time.Hour)
+	go utils.RunVoteReminderWorker(15 * time.Minute)
 
 	host := os.Getenv("HOST")
 	if host == "" {
@@ -0,0 +1,161 @@
+// MigrateMedia moves the uploaded media from a local images directory into the
+// blob store configured by STORAGE_DRIVER, and rewrites the image columns so
+// that every record holds a plain store key:
+//
+//	go run ./cmd/MigrateMedia [-from internal/assets/images] [-dry-run] [-delete]
+//
+// Blobs already in the store are left alone, so the command can be run again
+// after a partial migration. Run it once for a new S3 bucket as well, which
+// uploads the default picture.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	from := flag.String("from", "internal/assets/images", "local directory holding the existing media")
+	dryRun := flag.Bool("dry-run", false, "only report what would be changed")
+	deleteSource := flag.Bool("delete", false, "remove local files once they are in the store")
+	flag.Parse()
+
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Println("No .env file, using the environment")
+	}
+	db.ConnectToDatabase()
+
+	source := &utils.LocalBlobStore{Dir: *from}
+	target := utils.GetBlobStore()
+	failed := 0
+	if localTarget, ok := target.(*utils.LocalBlobStore); ok && filepath.Clean(localTarget.Dir) == filepath.Clean(*from) {
+		fmt.Println("The store is the source directory, no files to copy")
+	} else {
+		failed += migrateBlobs(source, target, *dryRun, *deleteSource)
+	}
+	failed += normalizeImageReferences(target, *dryRun)
+
+	if failed > 0 {
+		fmt.Printf("Finished with %d problems\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("Finished")
+}
+
+func migrateBlobs(source *utils.LocalBlobStore, target utils.BlobStore, dryRun, deleteSource bool) int {
+	keys, err := source.List("")
+	if err != nil {
+		fmt.Printf("Failed to list %s: %s\n", source.Dir, err)
+		return 1
+	}
+	copied, skipped, failed := 0, 0, 0
+	for _, key := range keys {
+		if !utils.IsImageKey(key) {
+			continue
+		}
+		exists, err := target.Exists(key)
+		if err != nil {
+			fmt.Printf("Failed to check %s: %s\n", key, err)
+			failed++
+			continue
+		}
+		if exists {
+			skipped++
+		} else if dryRun {
+			fmt.Printf("Would copy %s\n", key)
+			copied++
+			continue
+		} else {
+			data, err := utils.ReadBlob(source, key)
+			if err == nil {
+				err = target.Put(key, data, contentType(key))
+			}
+			if err != nil {
+				fmt.Printf("Failed to copy %s: %s\n", key, err)
+				failed++
+				continue
+			}
+			copied++
+		}
+		if deleteSource && !dryRun {
+			err = source.Delete(key)
+			if err != nil {
+				fmt.Printf("Failed to remove local %s: %s\n", key, err)
+				failed++
+			}
+		}
+	}
+	fmt.Printf("Copied %d files, %d were already in the store\n", copied, skipped)
+	return failed
+}
+
+// normalizeImageReferences turns image values saved as paths or URLs into
+// store keys and reports references to keys missing from the store.
+func normalizeImageReferences(target utils.BlobStore, dryRun bool) int {
+	values, err := repositories.GetImageReferences()
+	if err != nil {
+		fmt.Printf("Failed to read image references: %s\n", err)
+		return 1
+	}
+	renamed, failed := 0, 0
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		key := imageKey(value)
+		if key != value {
+			if dryRun {
+				fmt.Printf("Would rewrite %q to %q\n", value, key)
+			} else {
+				err = repositories.RenameImageReference(value, key)
+				if err != nil {
+					fmt.Printf("Failed to rewrite %q: %s\n", value, err)
+					failed++
+					continue
+				}
+			}
+			renamed++
+		}
+		if !utils.IsImageKey(key) {
+			fmt.Printf("Reference %q is not a valid image key\n", value)
+			failed++
+			continue
+		}
+		exists, err := target.Exists(key)
+		if err != nil || !exists {
+			fmt.Printf("Referenced image %s is missing from the store\n", key)
+			failed++
+		}
+	}
+	fmt.Printf("Rewrote %d image references\n", renamed)
+	return failed
+}
+
+// imageKey strips the directories, /images/ URL prefix and query string older
+// records may carry.
+func imageKey(value string) string {
+	value = strings.TrimSpace(value)
+	if index := strings.IndexAny(value, "?#"); index >= 0 {
+		value = value[:index]
+	}
+	return path.Base(strings.ReplaceAll(value, "\\", "/"))
+}
+
+func contentType(key string) string {
+	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(key)))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type CaptchaConfig struct {
+	Provider            string
+	SiteKey             string
+	SecretKey           string
+	ProofOfWorkBits     int
+	ProofOfWorkFallback bool
+}
+
+// GetCaptchaConfig reads CAPTCHA_PROVIDER ("recaptcha", "hcaptcha", "turnstile"
+// or "local") with CAPTCHA_SITE_KEY / CAPTCHA_SECRET_KEY. The RECAPTCHA_* keys
+// are still honoured and imply reCAPTCHA when no provider is set. The local
+// stand-in is only used when CAPTCHA_PROVIDER=local is set explicitly.
+func GetCaptchaConfig() CaptchaConfig {
+	captchaConfig := CaptchaConfig{
+		Provider:            strings.ToLower(os.Getenv("CAPTCHA_PROVIDER")),
+		SiteKey:             os.Getenv("CAPTCHA_SITE_KEY"),
+		SecretKey:           os.Getenv("CAPTCHA_SECRET_KEY"),
+		ProofOfWorkFallback: os.Getenv("CAPTCHA_POW_FALLBACK") != "false",
+	}
+	if captchaConfig.SiteKey == "" {
+		captchaConfig.SiteKey = os.Getenv("RECAPTCHA_SITE_KEY")
+	}
+	if captchaConfig.SecretKey == "" {
+		captchaConfig.SecretKey = os.Getenv("RECAPTCHA_SECRET_KEY")
+	}
+	if captchaConfig.Provider == "" && captchaConfig.SecretKey != "" {
+		captchaConfig.Provider = "recaptcha"
+	}
+
+	bits, err := strconv.Atoi(os.Getenv("CAPTCHA_POW_BITS"))
+	if err != nil || bits < 1 || bits > 32 {
+		bits = 16
+	}
+	captchaConfig.ProofOfWorkBits = bits
+	return captchaConfig
+}
@@ -6,6 +6,10 @@ import (
 )
 
 func SetUpLogger() *slog.Logger {
+	err := os.MkdirAll("logs", 0755)
+	if err != nil {
+		panic(err)
+	}
 	file, err := os.OpenFile(
 		"logs/ElectiVote.log",
 		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type MailConfig struct {
+	Transport   string
+	From        string
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	ImplicitTLS bool
+	Dir         string
+}
+
+// GetMailConfig reads MAIL_TRANSPORT ("smtp", "file" or "memory") and the
+// SMTP_* / MAIL_* settings. When no SMTP host is configured the legacy
+// GMAIL_* and OUTLOOK_* credentials are used so older deployments keep working.
+func GetMailConfig() MailConfig {
+	mailConfig := MailConfig{
+		Transport:   strings.ToLower(os.Getenv("MAIL_TRANSPORT")),
+		From:        os.Getenv("MAIL_FROM"),
+		Host:        os.Getenv("SMTP_HOST"),
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		ImplicitTLS: strings.ToLower(os.Getenv("SMTP_TLS")) == "tls",
+		Dir:         os.Getenv("MAIL_DIR"),
+	}
+	if mailConfig.Transport == "" {
+		mailConfig.Transport = "smtp"
+	}
+	if mailConfig.Dir == "" {
+		mailConfig.Dir = "logs/mail"
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		port = 587
+		if mailConfig.ImplicitTLS {
+			port = 465
+		}
+	}
+	mailConfig.Port = port
+
+	if mailConfig.Host == "" {
+		switch {
+		case os.Getenv("GMAIL_EMAIL") != "":
+			mailConfig.Host = "smtp.gmail.com"
+			mailConfig.Username = os.Getenv("GMAIL_EMAIL")
+			mailConfig.Password = os.Getenv("GMAIL_PASSWORD")
+		case os.Getenv("OUTLOOK_EMAIL") != "":
+			mailConfig.Host = "smtp-mail.outlook.com"
+			mailConfig.Username = os.Getenv("OUTLOOK_EMAIL")
+			mailConfig.Password = os.Getenv("OUTLOOK_PASSWORD")
+		}
+	}
+	if mailConfig.From == "" {
+		mailConfig.From = mailConfig.Username
+	}
+	return mailConfig
+}
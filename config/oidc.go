@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+type OIDCProvider struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// GetOIDCProviders reads OIDC_PROVIDERS (e.g. "google,microsoft") and the
+// OIDC_<NAME>_* variables for each entry. Providers missing an issuer or
+// client ID are skipped.
+func GetOIDCProviders() []OIDCProvider {
+	providers := []OIDCProvider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			DisplayName:  os.Getenv(prefix + "DISPLAY_NAME"),
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       []string{"openid", "email", "profile"},
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			continue
+		}
+		if provider.DisplayName == "" {
+			provider.DisplayName = strings.ToUpper(name[:1]) + name[1:]
+		}
+		if provider.RedirectURL == "" {
+			provider.RedirectURL = GetBaseURL() + "/electivote/oidc/" + name + "/callback/"
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+func GetOIDCProvider(name string) (OIDCProvider, bool) {
+	for _, provider := range GetOIDCProviders() {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return OIDCProvider{}, false
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var redisClient *redis.Client
+var redisOnce sync.Once
+
+func GetRedisClient() *redis.Client {
+	redisOnce.Do(func() {
+		redisAddr := os.Getenv("REDIS_URL")
+		if strings.HasPrefix(redisAddr, "redis://") {
+			redisAddr = strings.TrimPrefix(redisAddr, "redis://")
+		}
+		if redisAddr == "" {
+			redisAddr = "localhost:6379"
+		}
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     redisAddr,
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       0,
+		})
+	})
+	return redisClient
+}
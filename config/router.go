@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"text/template"
 	"github.com/gin-gonic/gin"
 )
@@ -22,15 +23,36 @@ func GetPort() string {
 	return port
 }
 
-func SetUpRouter() *gin.Engine {
+// GetBaseURL is the public address used in links sent outside the app, such as
+// emails and OAuth redirects. APP_BASE_URL overrides the host/port default.
+func GetBaseURL() string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		return "http://" + GetHost() + ":" + GetPort()
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// SetUpRouter creates the engine and loads the HTML templates. extraFuncs
+// adds template helpers from packages that config cannot import.
+func SetUpRouter(extraFuncs template.FuncMap) *gin.Engine {
 	router := gin.Default()
-	router.SetFuncMap(template.FuncMap{
+	funcMap := template.FuncMap{
 		"AddOne": func(i int) int {
 			return i + 1
 		},
-	})
+		"deref": func(i *uint) uint {
+			if i == nil {
+				return 0
+			}
+			return *i
+		},
+	}
+	for name, function := range extraFuncs {
+		funcMap[name] = function
+	}
+	router.SetFuncMap(funcMap)
 	router.LoadHTMLGlob("internal/views/html/*.html")
-	router.Static("/images", "internal/assets/images")
 	router.MaxMultipartMemory = 8 << 20
 	return router
 }
\ No newline at end of file
@@ -1,17 +1,230 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gorilla/securecookie"
+	gorillaSessions "github.com/gorilla/sessions"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionKeyPrefix     = "session:"
+	userSessionKeyPrefix = "user_sessions:"
+	defaultSessionTTL    = 24 * time.Hour
 )
 
+type RedisStore struct {
+	client  *redis.Client
+	codecs  []securecookie.Codec
+	options *gorillaSessions.Options
+}
+
+var sessionStore *RedisStore
+var sessionStoreOnce sync.Once
+
 func SetUpSessionStore() sessions.Store {
-	mainStore := cookie.NewStore([]byte("main"))
-	mainStore.Options(sessions.Options{
-		MaxAge:   0,
-		HttpOnly: true,
-		Path:     "/",
-		Secure:   false,
+	return GetSessionStore()
+}
+
+func GetSessionStore() *RedisStore {
+	sessionStoreOnce.Do(func() {
+		sessionStore = NewRedisStore(GetRedisClient(), getSessionKeyPairs()...)
+		sessionStore.Options(sessions.Options{
+			MaxAge:   0,
+			HttpOnly: true,
+			Path:     "/",
+			Secure:   os.Getenv("SESSION_SECURE") == "true",
+			SameSite: http.SameSiteLaxMode,
+		})
 	})
-	return mainStore
-}
\ No newline at end of file
+	return sessionStore
+}
+
+func getSessionKeyPairs() [][]byte {
+	secret := os.Getenv("SESSION_SECRET")
+	if secret == "" {
+		panic("SESSION_SECRET is not configured")
+	}
+	keyPairs := [][]byte{[]byte(secret)}
+	if encryptionKey := os.Getenv("SESSION_ENCRYPTION_KEY"); encryptionKey != "" {
+		keyPairs = append(keyPairs, []byte(encryptionKey))
+	} else {
+		keyPairs = append(keyPairs, nil)
+	}
+
+	// SESSION_PREVIOUS_SECRET keeps cookies signed with a rotated-out key valid.
+	if previousSecret := os.Getenv("SESSION_PREVIOUS_SECRET"); previousSecret != "" {
+		keyPairs = append(keyPairs, []byte(previousSecret), nil)
+	}
+	return keyPairs
+}
+
+func NewRedisStore(client *redis.Client, keyPairs ...[]byte) *RedisStore {
+	return &RedisStore{
+		client: client,
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		options: &gorillaSessions.Options{
+			Path:   "/",
+			MaxAge: 0,
+		},
+	}
+}
+
+func (s *RedisStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+func (s *RedisStore) Get(r *http.Request, name string) (*gorillaSessions.Session, error) {
+	return gorillaSessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *RedisStore) New(r *http.Request, name string) (*gorillaSessions.Session, error) {
+	session := gorillaSessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	err = securecookie.DecodeMulti(name, cookie.Value, &session.ID, s.codecs...)
+	if err != nil {
+		session.ID = ""
+		return session, nil
+	}
+
+	data, err := s.client.Get(r.Context(), sessionKeyPrefix+session.ID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		session.ID = ""
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&session.Values)
+	if err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *RedisStore) Save(r *http.Request, w http.ResponseWriter, session *gorillaSessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.client.Del(r.Context(), sessionKeyPrefix+session.ID).Err(); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gorillaSessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := generateSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(session.Values)
+	if err != nil {
+		return err
+	}
+	err = s.client.Set(r.Context(), sessionKeyPrefix+session.ID, buffer.Bytes(), sessionTTL(session.Options)).Err()
+	if err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gorillaSessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// DeleteSession removes a session from Redis, e.g. before its values move to a
+// fresh ID.
+func (s *RedisStore) DeleteSession(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, sessionKeyPrefix+sessionID).Err()
+}
+
+func (s *RedisStore) TrackUserSession(ctx context.Context, username, sessionID string) error {
+	key := userSessionKeyPrefix + username
+	err := s.client.SAdd(ctx, key, sessionID).Err()
+	if err != nil {
+		return err
+	}
+	return s.client.Expire(ctx, key, sessionTTL(s.options)).Err()
+}
+
+func (s *RedisStore) UntrackUserSession(ctx context.Context, username, sessionID string) error {
+	return s.client.SRem(ctx, userSessionKeyPrefix+username, sessionID).Err()
+}
+
+// CountUserSessions drops index entries whose session already expired before counting.
+func (s *RedisStore) CountUserSessions(ctx context.Context, username string) (int, error) {
+	key := userSessionKeyPrefix + username
+	sessionIDs, err := s.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, sessionID := range sessionIDs {
+		exists, err := s.client.Exists(ctx, sessionKeyPrefix+sessionID).Result()
+		if err != nil {
+			return 0, err
+		}
+		if exists == 0 {
+			s.client.SRem(ctx, key, sessionID)
+			continue
+		}
+		total++
+	}
+	return total, nil
+}
+
+func (s *RedisStore) RevokeUserSessions(ctx context.Context, username string) error {
+	key := userSessionKeyPrefix + username
+	sessionIDs, err := s.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{key}
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionKeyPrefix+sessionID)
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func sessionTTL(options *gorillaSessions.Options) time.Duration {
+	if options.MaxAge > 0 {
+		return time.Duration(options.MaxAge) * time.Second
+	}
+	return defaultSessionTTL
+}
+
+func generateSessionID() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(key), "="), nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+type StorageConfig struct {
+	Driver            string
+	LocalDir          string
+	Private           bool
+	URLTTL            time.Duration
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+	S3PublicURL       string
+}
+
+// GetStorageConfig reads STORAGE_DRIVER ("local" or "s3") and the
+// STORAGE_* / S3_* settings. With STORAGE_PRIVATE=true images are only
+// reachable through signed URLs that expire after about STORAGE_URL_TTL.
+// S3_ENDPOINT points at an S3 compatible server such as MinIO, which is
+// addressed path-style unless S3_PATH_STYLE=false.
+func GetStorageConfig() StorageConfig {
+	storageConfig := StorageConfig{
+		Driver:            strings.ToLower(os.Getenv("STORAGE_DRIVER")),
+		LocalDir:          os.Getenv("STORAGE_LOCAL_DIR"),
+		Private:           os.Getenv("STORAGE_PRIVATE") == "true",
+		S3Endpoint:        strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+		S3Region:          os.Getenv("S3_REGION"),
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3PublicURL:       strings.TrimRight(os.Getenv("S3_PUBLIC_URL"), "/"),
+	}
+	if storageConfig.Driver == "" {
+		storageConfig.Driver = "local"
+	}
+	if storageConfig.LocalDir == "" {
+		storageConfig.LocalDir = "internal/assets/images"
+	}
+
+	ttl, err := time.ParseDuration(os.Getenv("STORAGE_URL_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = time.Hour
+	}
+	storageConfig.URLTTL = ttl
+
+	if storageConfig.S3Region == "" {
+		storageConfig.S3Region = "us-east-1"
+	}
+	storageConfig.S3PathStyle = storageConfig.S3Endpoint != ""
+	if pathStyle := os.Getenv("S3_PATH_STYLE"); pathStyle != "" {
+		storageConfig.S3PathStyle = pathStyle == "true"
+	}
+	if storageConfig.S3Endpoint == "" {
+		storageConfig.S3Endpoint = "https://s3." + storageConfig.S3Region + ".amazonaws.com"
+	}
+	return storageConfig
+}
@@ -36,6 +36,11 @@ func ConnectToDatabase() {
 		logger.Error("Error connecting to database", "error", err)
 		panic(err.Error())
 	}
+	err = dedupeVoteRecords(database, logger)
+	if err != nil {
+		logger.Error("Error removing duplicate vote records", "error", err)
+		panic(err.Error())
+	}
 	err = database.AutoMigrate(
 		&models.User{},
 		&models.Profile{},
@@ -45,6 +50,20 @@ func ConnectToDatabase() {
 		&models.VoteHistory{},
 		&models.Feedback{},
 		&models.Support{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.VoterRoll{},
+		&models.VoterRollEntry{},
+		&models.RememberToken{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.OutboundEmail{},
+		&models.PersonalAccessToken{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.VoteTemplate{},
+		&models.VoteTemplateCandidate{},
+		&models.ImageVariants{},
 	)
 	if err != nil {
 		logger.Error("Error migrating database", "error", err)
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"log/slog"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
+)
+
+const voteRecordUserIndex = "idx_vote_records_vote_user"
+
+// duplicateVoteRecords are the ballots past the first one a user cast in a
+// vote, which the unique vote/user index no longer allows.
+const duplicateVoteRecords = `SELECT r.vote_record_id, r.candidate_id
+	FROM vote_records r
+	JOIN (
+		SELECT vote_id, user_id, MIN(vote_record_id) AS kept_id
+		FROM vote_records
+		GROUP BY vote_id, user_id
+		HAVING COUNT(*) > 1
+	) kept ON r.vote_id = kept.vote_id AND r.user_id = kept.user_id AND r.vote_record_id <> kept.kept_id`
+
+// dedupeVoteRecords runs before AutoMigrate creates the unique vote/user
+// index, which fails on databases where the old check-then-insert race let a
+// user vote twice. The earliest ballot is kept; the later ones are deleted
+// and taken off their candidates' totals.
+func dedupeVoteRecords(database *gorm.DB, logger *slog.Logger) error {
+	migrator := database.Migrator()
+	if !migrator.HasTable(&models.VoteRecord{}) || migrator.HasIndex(&models.VoteRecord{}, voteRecordUserIndex) {
+		return nil
+	}
+	return database.Transaction(func(tx *gorm.DB) error {
+		duplicates := []struct {
+			VoteRecordID uint
+			CandidateID  uint
+		}{}
+		err := tx.Raw(duplicateVoteRecords).Scan(&duplicates).Error
+		if err != nil {
+			return err
+		}
+		if len(duplicates) == 0 {
+			return nil
+		}
+
+		extraVotes := map[uint]int{}
+		voteRecordIDs := make([]uint, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			extraVotes[duplicate.CandidateID]++
+			voteRecordIDs = append(voteRecordIDs, duplicate.VoteRecordID)
+		}
+		for candidateID, extra := range extraVotes {
+			err = tx.Model(&models.Candidate{}).
+				Where("candidate_id = ?", candidateID).
+				UpdateColumn("total_votes", gorm.Expr("GREATEST(total_votes, ?) - ?", extra, extra)).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Where("vote_record_id IN ?", voteRecordIDs).Delete(&models.VoteRecord{}).Error
+		if err != nil {
+			return err
+		}
+		logger.Warn(
+			"dedupeVoteRecords - removed duplicate ballots before adding the unique vote/user index",
+			"Removed", len(voteRecordIDs),
+		)
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func OrganizationFactory(organizationName, organizationLogo string, ownerID uint, createdTime models.CustomTime) models.Organization {
+	if organizationLogo == "" {
+		organizationLogo = "default.png"
+	}
+	return models.Organization{
+		OrganizationName: organizationName,
+		OrganizationLogo: organizationLogo,
+		OwnerID:          ownerID,
+		CreatedTime:      createdTime,
+	}
+}
+
+func UpdateOrganizationFactory(organizationName, organizationLogo string) models.Organization {
+	return models.Organization{
+		OrganizationName: organizationName,
+		OrganizationLogo: organizationLogo,
+	}
+}
+
+func OrganizationMemberFactory(organizationID, userID uint, memberRole string, joinedTime models.CustomTime) models.OrganizationMember {
+	return models.OrganizationMember{
+		OrganizationId: organizationID,
+		UserId:         userID,
+		MemberRole:     memberRole,
+		JoinedTime:     joinedTime,
+	}
+}
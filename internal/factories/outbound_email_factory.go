@@ -0,0 +1,18 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func OutboundEmailFactory(recipient, subject, body, textBody string, createdTime models.CustomTime) models.OutboundEmail {
+	if len(subject) > 255 {
+		subject = subject[:255]
+	}
+	return models.OutboundEmail{
+		Recipient:       recipient,
+		Subject:         subject,
+		Body:            body,
+		TextBody:        textBody,
+		Status:          "pending",
+		NextAttemptTime: createdTime,
+		CreatedTime:     createdTime,
+	}
+}
@@ -0,0 +1,19 @@
+package factories
+
+import (
+	"strings"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func PersonalAccessTokenFactory(userID uint, name, tokenPrefix, tokenHash string, scopes []string, createdTime, expiresTime models.CustomTime) models.PersonalAccessToken {
+	return models.PersonalAccessToken{
+		UserId:      userID,
+		Name:        name,
+		TokenPrefix: tokenPrefix,
+		TokenHash:   tokenHash,
+		Scopes:      strings.Join(scopes, ","),
+		CreatedTime: createdTime,
+		ExpiresTime: expiresTime,
+	}
+}
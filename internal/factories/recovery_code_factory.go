@@ -0,0 +1,10 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func RecoveryCodeFactory(userID uint, codeHash string) models.RecoveryCode {
+	return models.RecoveryCode{
+		UserId:   userID,
+		CodeHash: codeHash,
+	}
+}
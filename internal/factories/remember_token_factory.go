@@ -0,0 +1,19 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func RememberTokenFactory(userID uint, selector, tokenHash, userAgent, clientIP string, createdTime, expiresTime models.CustomTime) models.RememberToken {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	return models.RememberToken{
+		UserId:       userID,
+		Selector:     selector,
+		TokenHash:    tokenHash,
+		UserAgent:    userAgent,
+		ClientIP:     clientIP,
+		CreatedTime:  createdTime,
+		LastUsedTime: createdTime,
+		ExpiresTime:  expiresTime,
+	}
+}
@@ -0,0 +1,14 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func UserIdentityFactory(userID uint, provider, subject, email string, createdTime models.CustomTime) models.UserIdentity {
+	return models.UserIdentity{
+		UserId:        userID,
+		Provider:      provider,
+		Subject:       subject,
+		Email:         email,
+		CreatedTime:   createdTime,
+		LastLoginTime: createdTime,
+	}
+}
@@ -0,0 +1,26 @@
+package factories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func VoteTemplateFactory(moderatorID uint, templateName string, vote models.Vote, createdTime models.CustomTime) models.VoteTemplate {
+	return models.VoteTemplate{
+		ModeratorID:     moderatorID,
+		TemplateName:    templateName,
+		VoteTitle:       vote.VoteTitle,
+		VoteDescription: vote.VoteDescription,
+		OrganizationID:  vote.OrganizationID,
+		VoterRollID:     vote.VoterRollID,
+		PublicBallot:    vote.PublicBallot,
+		CreatedTime:     createdTime,
+	}
+}
+
+func VoteTemplateCandidateFactory(candidate models.Candidate) models.VoteTemplateCandidate {
+	return models.VoteTemplateCandidate{
+		CandidateName:        candidate.CandidateName,
+		CandidateDescription: candidate.CandidateDescription,
+		CandidatePicture:     candidate.CandidatePicture,
+	}
+}
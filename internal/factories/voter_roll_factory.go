@@ -0,0 +1,17 @@
+package factories
+
+import "github.com/AndreanDjabbar/ElectiVote/internal/models"
+
+func VoterRollFactory(voterRollName string, organizationID uint) models.VoterRoll {
+	return models.VoterRoll{
+		VoterRollName:  voterRollName,
+		OrganizationId: organizationID,
+	}
+}
+
+func VoterRollEntryFactory(voterRollID, userID uint) models.VoterRollEntry {
+	return models.VoterRollEntry{
+		VoterRollId: voterRollID,
+		UserId:      userID,
+	}
+}
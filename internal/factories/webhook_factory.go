@@ -0,0 +1,29 @@
+package factories
+
+import (
+	"strings"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func WebhookEndpointFactory(moderatorID uint, url, secret string, events []string, createdTime models.CustomTime) models.WebhookEndpoint {
+	return models.WebhookEndpoint{
+		ModeratorID: moderatorID,
+		URL:         url,
+		Secret:      secret,
+		Events:      strings.Join(events, ","),
+		Active:      true,
+		CreatedTime: createdTime,
+	}
+}
+
+func WebhookDeliveryFactory(webhookEndpointID uint, event, payload string, createdTime models.CustomTime) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		WebhookEndpointID: webhookEndpointID,
+		Event:             event,
+		Payload:           payload,
+		Status:            "pending",
+		NextAttemptTime:   createdTime,
+		CreatedTime:       createdTime,
+	}
+}
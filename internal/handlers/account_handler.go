@@ -0,0 +1,731 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	changeEmailTimeout     = 5 * time.Minute
+	changeEmailMaxAttempts = 5
+)
+
+func ViewChangePasswordPage(c *gin.Context) {
+	logger.Info(
+		"ViewChangePasswordPage - rendering change password page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	context := gin.H{
+		"title":     "Change Password",
+		"csrfToken": middlewares.GetCSRFToken(c),
+	}
+	c.HTML(
+		http.StatusOK,
+		"changePassword.html",
+		context,
+	)
+}
+
+func ChangePasswordPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	currentPassword := c.PostForm("currentPassword")
+	newPassword := c.PostForm("newPassword")
+	newPassword2 := c.PostForm("newPassword2")
+	currentPasswordErr, newPasswordErr, newPassword2Err := "", "", ""
+
+	if _, err := repositories.CheckPasswordByUSername(currentUser.Username, currentPassword); err != nil {
+		middlewares.LogSecurityEvent(c, "change_password_wrong_password", currentUser.Username)
+		currentPasswordErr = "Current password is incorrect"
+	}
+
+	if len(newPassword) < 5 || len(newPassword) > 255 {
+		logger.Warn(
+			"ChangePasswordPage - Password must be between 5 and 255 characters",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		newPasswordErr = "Password must be between 5 and 255 characters"
+	}
+
+	if newPassword != newPassword2 {
+		logger.Warn(
+			"ChangePasswordPage - password and password confirmation must be same",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		newPassword2Err = "Password and Password Confirmation must be same"
+	}
+
+	if currentPasswordErr != "" || newPasswordErr != "" || newPassword2Err != "" {
+		context := gin.H{
+			"title":              "Change Password",
+			"csrfToken":          middlewares.GetCSRFToken(c),
+			"currentPasswordErr": currentPasswordErr,
+			"newPasswordErr":     newPasswordErr,
+			"newPassword2Err":    newPassword2Err,
+		}
+		c.HTML(
+			http.StatusBadRequest,
+			"changePassword.html",
+			context,
+		)
+		return
+	}
+
+	passwordHashed, err := utils.HashPassword(newPassword)
+	if err == nil {
+		_, err = repositories.UpdatePasswordByEmail(currentUser.Email, passwordHashed)
+	}
+	if err != nil {
+		logger.Error(
+			"ChangePasswordPage - failed to update password",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			"Internal Server Error",
+			"/electivote/profile-page/",
+		)
+		return
+	}
+
+	// Other devices were authenticated with the old password, so sign them
+	// out and start a fresh session for this one.
+	err = middlewares.RevokeAllSessions(c, currentUser.Username)
+	if err != nil {
+		logger.Error(
+			"ChangePasswordPage - failed to revoke sessions and remember tokens",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+	}
+	middlewares.SetSession(c, currentUser.Username)
+	middlewares.LogSecurityEvent(c, "password_changed", currentUser.Username)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+func renderChangeEmailPage(c *gin.Context, statusCode int, extra gin.H) {
+	context := gin.H{
+		"title":        "Change Email",
+		"csrfToken":    middlewares.GetCSRFToken(c),
+		"currentEmail": middlewares.GetCurrentUser(c).Email,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"changeEmail.html",
+		context,
+	)
+}
+
+func ViewChangeEmailPage(c *gin.Context) {
+	logger.Info(
+		"ViewChangeEmailPage - rendering change email page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	renderChangeEmailPage(c, http.StatusOK, nil)
+}
+
+func ChangeEmailPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	newEmail := strings.TrimSpace(c.PostForm("newEmail"))
+	currentPassword := c.PostForm("currentPassword")
+	newEmailErr, currentPasswordErr := "", ""
+
+	if _, err := repositories.CheckPasswordByUSername(currentUser.Username, currentPassword); err != nil {
+		middlewares.LogSecurityEvent(c, "change_email_wrong_password", currentUser.Username)
+		currentPasswordErr = "Current password is incorrect"
+	}
+
+	if !utils.IsValidEmail(newEmail) {
+		logger.Warn(
+			"ChangeEmailPage - Email is not valid",
+			"Email Inputted", newEmail,
+			"Client IP", c.ClientIP(),
+		)
+		newEmailErr = "Email is not valid"
+	} else if strings.EqualFold(newEmail, currentUser.Email) {
+		newEmailErr = "This is already your email"
+	} else if _, err := repositories.GetUserByEmail(newEmail); !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warn(
+			"ChangeEmailPage - Email is already used",
+			"Email Inputted", newEmail,
+			"Client IP", c.ClientIP(),
+		)
+		newEmailErr = "Email is already used"
+	}
+
+	if newEmailErr != "" || currentPasswordErr != "" {
+		renderChangeEmailPage(c, http.StatusBadRequest, gin.H{
+			"newEmail":           newEmail,
+			"newEmailErr":        newEmailErr,
+			"currentPasswordErr": currentPasswordErr,
+		})
+		return
+	}
+
+	otp, err := utils.GenerateOTP()
+	if err != nil {
+		logger.Error(
+			"ChangeEmailPage - failed to generate OTP",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+	sendOTPEmail(c, newEmail, otp, currentUser.Locale)
+	middlewares.SetChangeEmailSession(c, newEmail, otp)
+	logger.Info(
+		"ChangeEmailPage - OTP sent to new email",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"action", "redirecting to change email verification page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/change-email-verification-page/",
+	)
+}
+
+// getPendingEmailChange returns the new address and OTP stored by
+// ChangeEmailPage, or empty strings once the request is missing or stale.
+func getPendingEmailChange(c *gin.Context) (string, string) {
+	session := sessions.Default(c)
+	newEmail, _ := session.Get("changeEmail").(string)
+	otp, _ := session.Get("changeEmailOtp").(string)
+	createdAt, _ := session.Get("changeEmailCreatedAt").(int64)
+	if newEmail == "" || otp == "" {
+		return "", ""
+	}
+	if time.Since(time.Unix(createdAt, 0)) > changeEmailTimeout {
+		middlewares.DeleteChangeEmailSession(c)
+		return "", ""
+	}
+	return newEmail, otp
+}
+
+func ViewChangeEmailVerificationPage(c *gin.Context) {
+	newEmail, _ := getPendingEmailChange(c)
+	if newEmail == "" {
+		logger.Warn(
+			"ViewChangeEmailVerificationPage - no pending email change",
+			"Client IP", c.ClientIP(),
+			"action", "redirecting to change email page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/change-email-page/",
+		)
+		return
+	}
+
+	context := gin.H{
+		"title":     "Verify Email",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"newEmail":  newEmail,
+	}
+	c.HTML(
+		http.StatusOK,
+		"changeEmailVerification.html",
+		context,
+	)
+}
+
+func ChangeEmailVerificationPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	newEmail, otp := getPendingEmailChange(c)
+	if newEmail == "" {
+		logger.Warn(
+			"ChangeEmailVerificationPage - no pending email change",
+			"Client IP", c.ClientIP(),
+			"action", "redirecting to change email page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/change-email-page/",
+		)
+		return
+	}
+
+	otpInput := strings.TrimSpace(c.PostForm("otp"))
+	if subtle.ConstantTimeCompare([]byte(otpInput), []byte(otp)) != 1 {
+		attempts := middlewares.IncrementChangeEmailAttempts(c)
+		middlewares.LogSecurityEvent(c, "change_email_wrong_otp", currentUser.Username, "Attempts", attempts)
+		if attempts >= changeEmailMaxAttempts {
+			middlewares.DeleteChangeEmailSession(c)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/change-email-page/",
+			)
+			return
+		}
+		context := gin.H{
+			"title":     "Verify Email",
+			"csrfToken": middlewares.GetCSRFToken(c),
+			"newEmail":  newEmail,
+			"otpErr":    "Invalid OTP",
+		}
+		c.HTML(
+			http.StatusBadRequest,
+			"changeEmailVerification.html",
+			context,
+		)
+		return
+	}
+
+	middlewares.DeleteChangeEmailSession(c)
+	err := repositories.UpdateUserEmail(currentUser.ID, newEmail)
+	if err != nil {
+		logger.Error(
+			"ChangeEmailVerificationPage - failed to update email",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusConflict,
+			"Failed to update email, it may already be in use",
+			"/electivote/change-email-page/",
+		)
+		return
+	}
+
+	sendEmailChangedNotification(currentUser.Email, newEmail, currentUser.Locale)
+	middlewares.LogSecurityEvent(c, "email_changed", currentUser.Username, "Old Email", currentUser.Email, "New Email", newEmail)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+func sendEmailChangedNotification(oldEmail, newEmail, locale string) {
+	err := utils.QueueTemplateEmail(oldEmail, locale, "email_changed", map[string]interface{}{
+		"newEmail": newEmail,
+	})
+	if err != nil {
+		logger.Error(
+			"sendEmailChangedNotification - failed to queue email",
+			"error", err.Error(),
+		)
+	}
+}
+
+func UpdateEmailLanguagePage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	locale := utils.NormalizeLocale(c.PostForm("locale"))
+	err := repositories.UpdateUserLocale(currentUser.ID, locale)
+	if err != nil {
+		logger.Error(
+			"UpdateEmailLanguagePage - failed to update locale",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+	logger.Info(
+		"UpdateEmailLanguagePage - email language updated",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Locale", locale,
+		"action", "redirecting to profile page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+const defaultAccountDeletionGraceDays = 14
+
+type accountExport struct {
+	ExportedTime time.Time                `json:"exported_time"`
+	User         accountExportUser        `json:"user"`
+	Profile      accountExportProfile     `json:"profile"`
+	Feedbacks    []accountExportFeedback  `json:"feedbacks"`
+	VoteRecords  []accountExportBallot    `json:"vote_records"`
+	Candidates   []accountExportCandidate `json:"candidates"`
+}
+
+type accountExportUser struct {
+	ID                    uint       `json:"id"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	TwoFactorEnabled      bool       `json:"two_factor_enabled"`
+	DeletionScheduledTime *time.Time `json:"deletion_scheduled_time,omitempty"`
+}
+
+type accountExportProfile struct {
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	Age         uint       `json:"age"`
+	PhoneNumber string     `json:"phone_number"`
+	Birthday    *time.Time `json:"birthday,omitempty"`
+	Picture     string     `json:"picture"`
+}
+
+type accountExportFeedback struct {
+	FeedbackMessage string    `json:"feedback_message"`
+	FeedbackRate    uint      `json:"feedback_rate"`
+	FeedbackDate    time.Time `json:"feedback_date"`
+}
+
+type accountExportBallot struct {
+	VoteTitle     string    `json:"vote_title"`
+	VoteCode      string    `json:"vote_code"`
+	CandidateName string    `json:"candidate_name"`
+	VotedTime     time.Time `json:"voted_time"`
+}
+
+// accountExportCandidate is a candidate of a vote the user moderates; its
+// picture is included in the ZIP export under pictures/candidates/.
+type accountExportCandidate struct {
+	VoteTitle            string `json:"vote_title"`
+	CandidateName        string `json:"candidate_name"`
+	CandidateDescription string `json:"candidate_description"`
+	Picture              string `json:"picture"`
+}
+
+func accountDeletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultAccountDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func buildAccountExport(user models.User) (accountExport, models.Profile, error) {
+	profile, err := repositories.GetProfilesByUsername(user.Username)
+	if err != nil {
+		return accountExport{}, profile, err
+	}
+	feedbacks, err := repositories.GetFeedbacksByUserID(user.ID)
+	if err != nil {
+		return accountExport{}, profile, err
+	}
+	voteRecords, err := repositories.GetVoteRecordsByUserID(user.ID)
+	if err != nil {
+		return accountExport{}, profile, err
+	}
+	candidates, err := repositories.GetCandidatesByModeratorID(user.ID)
+	if err != nil {
+		return accountExport{}, profile, err
+	}
+
+	export := accountExport{
+		ExportedTime: time.Now(),
+		User: accountExportUser{
+			ID:               user.ID,
+			Username:         user.Username,
+			Email:            user.Email,
+			Role:             user.Role,
+			TwoFactorEnabled: user.TwoFactorEnabled,
+		},
+		Profile: accountExportProfile{
+			FirstName:   profile.FirstName,
+			LastName:    profile.LastName,
+			Age:         profile.Age,
+			PhoneNumber: profile.PhoneNumber,
+			Picture:     profile.Picture,
+		},
+		Feedbacks:   []accountExportFeedback{},
+		VoteRecords: []accountExportBallot{},
+		Candidates:  []accountExportCandidate{},
+	}
+	if !user.DeletionScheduledTime.IsZero() {
+		export.User.DeletionScheduledTime = &user.DeletionScheduledTime.Time
+	}
+	if profile.Birthday.Valid {
+		export.Profile.Birthday = &profile.Birthday.Time
+	}
+	for _, feedback := range feedbacks {
+		export.Feedbacks = append(export.Feedbacks, accountExportFeedback{
+			FeedbackMessage: feedback.FeedbackMessage,
+			FeedbackRate:    feedback.FeedbackRate,
+			FeedbackDate:    feedback.FeedbackDate.Time,
+		})
+	}
+	for _, voteRecord := range voteRecords {
+		export.VoteRecords = append(export.VoteRecords, accountExportBallot{
+			VoteTitle:     voteRecord.Vote.VoteTitle,
+			VoteCode:      voteRecord.Vote.VoteCode,
+			CandidateName: voteRecord.Candidate.CandidateName,
+			VotedTime:     voteRecord.VotedTime.Time,
+		})
+	}
+	for _, candidate := range candidates {
+		export.Candidates = append(export.Candidates, accountExportCandidate{
+			VoteTitle:            candidate.Vote.VoteTitle,
+			CandidateName:        candidate.CandidateName,
+			CandidateDescription: candidate.CandidateDescription,
+			Picture:              candidate.CandidatePicture,
+		})
+	}
+	return export, profile, nil
+}
+
+func ExportAccountDataPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	export, profile, err := buildAccountExport(currentUser)
+	if err != nil {
+		logger.Error(
+			"ExportAccountDataPage - failed to collect account data",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+
+	middlewares.LogSecurityEvent(c, "account_data_exported", currentUser.Username, "Format", c.DefaultQuery("format", "zip"))
+	filename := fmt.Sprintf("electivote-%s-%s", currentUser.Username, time.Now().Format("20060102"))
+	if c.Query("format") == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.IndentedJSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Status(http.StatusOK)
+	archive := zip.NewWriter(c.Writer)
+	defer archive.Close()
+
+	dataFile, err := archive.Create("data.json")
+	if err == nil {
+		encoder := json.NewEncoder(dataFile)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(export)
+	}
+	if err == nil && profile.Picture != "" && profile.Picture != "default.png" {
+		err = addBlobToZip(archive, filepath.Base(profile.Picture), "pictures/"+filepath.Base(profile.Picture))
+	}
+	addedPictures := map[string]bool{}
+	for _, candidate := range export.Candidates {
+		picture := filepath.Base(candidate.Picture)
+		if err != nil || candidate.Picture == "" || picture == utils.DefaultImage || addedPictures[picture] {
+			continue
+		}
+		addedPictures[picture] = true
+		err = addBlobToZip(archive, picture, "pictures/candidates/"+picture)
+	}
+	if err != nil {
+		logger.Error(
+			"ExportAccountDataPage - failed to write archive",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+	}
+}
+
+func addBlobToZip(archive *zip.Writer, key, name string) error {
+	blob, err := utils.GetBlobStore().Open(key)
+	if errors.Is(err, utils.ErrBlobNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, blob)
+	return err
+}
+
+func ViewDeleteAccountPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	logger.Info(
+		"ViewDeleteAccountPage - rendering delete account page",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+	)
+	context := gin.H{
+		"title":     "Delete Account",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"graceDays": int(accountDeletionGracePeriod().Hours() / 24),
+	}
+	c.HTML(
+		http.StatusOK,
+		"deleteAccount.html",
+		context,
+	)
+}
+
+func DeleteAccountPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if _, err := repositories.CheckPasswordByUSername(currentUser.Username, c.PostForm("currentPassword")); err != nil {
+		middlewares.LogSecurityEvent(c, "delete_account_wrong_password", currentUser.Username)
+		context := gin.H{
+			"title":              "Delete Account",
+			"csrfToken":          middlewares.GetCSRFToken(c),
+			"graceDays":          int(accountDeletionGracePeriod().Hours() / 24),
+			"currentPasswordErr": "Current password is incorrect",
+		}
+		c.HTML(
+			http.StatusBadRequest,
+			"deleteAccount.html",
+			context,
+		)
+		return
+	}
+
+	deletionTime := time.Now().Add(accountDeletionGracePeriod())
+	err := repositories.ScheduleUserDeletion(currentUser.ID, deletionTime)
+	if err != nil {
+		logger.Error(
+			"DeleteAccountPage - failed to schedule deletion",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+	sendAccountDeletionScheduledEmail(currentUser.Email, deletionTime, currentUser.Locale)
+	middlewares.LogSecurityEvent(c, "account_deletion_scheduled", currentUser.Username, "Deletion Time", deletionTime.Format(time.RFC3339))
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+func CancelAccountDeletionPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	err := repositories.CancelUserDeletion(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"CancelAccountDeletionPage - failed to cancel deletion",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+	middlewares.LogSecurityEvent(c, "account_deletion_cancelled", currentUser.Username)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+func sendAccountDeletionScheduledEmail(email string, deletionTime time.Time, locale string) {
+	err := utils.QueueTemplateEmail(email, locale, "account_deletion", map[string]interface{}{
+		"deletionTime": deletionTime.Format("02 Jan 2006 15:04"),
+	})
+	if err != nil {
+		logger.Error(
+			"sendAccountDeletionScheduledEmail - failed to queue email",
+			"error", err.Error(),
+		)
+	}
+}
+
+// RunAccountDeletionWorker anonymizes accounts whose grace period has ended.
+// It is started once from main and checks every interval.
+func RunAccountDeletionWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		processScheduledAccountDeletions()
+		<-ticker.C
+	}
+}
+
+func processScheduledAccountDeletions() {
+	users, err := repositories.GetUsersDueForDeletion(time.Now())
+	if err != nil {
+		logger.Error(
+			"processScheduledAccountDeletions - failed to get users due for deletion",
+			"error", err.Error(),
+		)
+		return
+	}
+	for _, user := range users {
+		err := deleteAccount(user)
+		if err != nil {
+			logger.Error(
+				"processScheduledAccountDeletions - failed to delete account",
+				"error", err.Error(),
+				"User ID", user.ID,
+			)
+			continue
+		}
+		logger.Info(
+			"processScheduledAccountDeletions - account anonymized",
+			"User ID", user.ID,
+		)
+	}
+}
+
+func deleteAccount(user models.User) error {
+	profile, err := repositories.GetProfilesByUsername(user.Username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	randomPassword, err := utils.GenerateRandomSecret()
+	if err != nil {
+		return err
+	}
+	passwordHashed, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return err
+	}
+
+	err = config.GetSessionStore().RevokeUserSessions(context.Background(), user.Username)
+	if err != nil {
+		return err
+	}
+	err = repositories.BumpSessionGeneration(user.ID)
+	if err != nil {
+		return err
+	}
+	err = repositories.AnonymizeUser(user.ID, passwordHashed)
+	if err != nil {
+		return err
+	}
+
+	utils.RemoveUnusedImages(profile.Picture)
+	return nil
+}
@@ -0,0 +1,649 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ViewAdminDashboardPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	stats, err := repositories.GetSystemStats()
+	if err != nil {
+		logger.Error(
+			"ViewAdminDashboardPage - failed to get system stats",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/home-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewAdminDashboardPage - rendering admin dashboard page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":     "Admin Dashboard",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"stats":     stats,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminDashboard.html",
+		context,
+	)
+}
+
+func ViewAdminUsersPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	query := c.Query("q")
+	users, err := repositories.SearchUsers(query)
+	if err != nil {
+		logger.Error(
+			"ViewAdminUsersPage - failed to search users",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/dashboard-page/",
+		)
+		return
+	}
+
+	activeSessions := map[uint]int{}
+	lockedUsers := map[uint]bool{}
+	for _, user := range users {
+		activeSessions[user.ID] = middlewares.CountActiveSessions(c, user.Username)
+		lockedUsers[user.ID] = middlewares.IsAccountLocked(c, user.Username)
+	}
+
+	logger.Info(
+		"ViewAdminUsersPage - rendering admin users page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Query", query,
+	)
+	context := gin.H{
+		"title":          "Manage Users",
+		"csrfToken":      middlewares.GetCSRFToken(c),
+		"users":          users,
+		"query":          query,
+		"activeSessions": activeSessions,
+		"lockedUsers":    lockedUsers,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminUsers.html",
+		context,
+	)
+}
+
+func SuspendUserPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	userID, _ := strconv.Atoi(c.Param("userID"))
+	user, err := repositories.GetUserByUserID(uint(userID))
+	if err != nil {
+		logger.Warn(
+			"SuspendUserPage - user not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"User ID", userID,
+		)
+		utils.RenderError(
+			c,
+			http.StatusNotFound,
+			"User not found",
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	if user.Username == username {
+		logger.Warn(
+			"SuspendUserPage - admin cannot suspend own account",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusBadRequest,
+			"You cannot suspend your own account",
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	suspended := c.PostForm("suspended") == "true"
+	err = repositories.SetUserSuspended(user.ID, suspended)
+	if err != nil {
+		logger.Error(
+			"SuspendUserPage - failed to update user suspension",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"SuspendUserPage - user suspension updated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Target Username", user.Username,
+		"Suspended", suspended,
+		"action", "redirecting to admin users page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/admin/users-page/",
+	)
+}
+
+func ForcePasswordResetPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	userID, _ := strconv.Atoi(c.Param("userID"))
+	user, err := repositories.GetUserByUserID(uint(userID))
+	if err != nil {
+		logger.Warn(
+			"ForcePasswordResetPage - user not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"User ID", userID,
+		)
+		utils.RenderError(
+			c,
+			http.StatusNotFound,
+			"User not found",
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	err = repositories.SetPasswordResetRequired(user.Email, true)
+	if err != nil {
+		logger.Error(
+			"ForcePasswordResetPage - failed to flag password reset",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	// The account may be compromised, so it is signed out everywhere until the
+	// owner sets a new password.
+	err = middlewares.RevokeAllSessions(c, user.Username)
+	if err != nil {
+		logger.Error(
+			"ForcePasswordResetPage - failed to revoke sessions",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	err = sendResetPasswordEmail(user.Email, user.Locale)
+	if err != nil {
+		logger.Error(
+			"ForcePasswordResetPage - failed to send reset password email",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ForcePasswordResetPage - password reset forced",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Target Username", user.Username,
+		"action", "redirecting to admin users page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/admin/users-page/",
+	)
+}
+
+func RevokeUserSessionsPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	userID, _ := strconv.Atoi(c.Param("userID"))
+	user, err := repositories.GetUserByUserID(uint(userID))
+	if err != nil {
+		logger.Warn(
+			"RevokeUserSessionsPage - user not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"User ID", userID,
+		)
+		utils.RenderError(
+			c,
+			http.StatusNotFound,
+			"User not found",
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	err = middlewares.RevokeAllSessions(c, user.Username)
+	if err != nil {
+		logger.Error(
+			"RevokeUserSessionsPage - failed to revoke sessions",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"RevokeUserSessionsPage - user sessions revoked",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Target Username", user.Username,
+		"action", "redirecting to admin users page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/admin/users-page/",
+	)
+}
+
+func UnlockUserPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	userID, _ := strconv.Atoi(c.Param("userID"))
+	user, err := repositories.GetUserByUserID(uint(userID))
+	if err != nil {
+		logger.Warn(
+			"UnlockUserPage - user not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"User ID", userID,
+		)
+		utils.RenderError(
+			c,
+			http.StatusNotFound,
+			"User not found",
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	err = middlewares.UnlockAccount(c, user.Username)
+	if err != nil {
+		logger.Error(
+			"UnlockUserPage - failed to unlock user",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/users-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"UnlockUserPage - user unlocked",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Target Username", user.Username,
+		"action", "redirecting to admin users page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/admin/users-page/",
+	)
+}
+
+func ViewAdminVotesPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	votes, err := repositories.GetAllVotes()
+	if err != nil {
+		logger.Error(
+			"ViewAdminVotesPage - failed to get votes",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/dashboard-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewAdminVotesPage - rendering admin votes page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":     "All Votes",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"votes":     votes,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminVotes.html",
+		context,
+	)
+}
+
+func ViewAdminFeedbacksPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	feedbacks, err := repositories.GetAllFeedbacks()
+	if err != nil {
+		logger.Error(
+			"ViewAdminFeedbacksPage - failed to get feedbacks",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/dashboard-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewAdminFeedbacksPage - rendering admin feedbacks page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":     "All Feedbacks",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"feedbacks": feedbacks,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminFeedbacks.html",
+		context,
+	)
+}
+
+func ViewAdminSupportsPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	supports, err := repositories.GetAllSupports()
+	if err != nil {
+		logger.Error(
+			"ViewAdminSupportsPage - failed to get supports",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/dashboard-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewAdminSupportsPage - rendering admin supports page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":     "Supports",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"supports":  supports,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminSupports.html",
+		context,
+	)
+}
+
+func ViewAdminEmailsPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	status := c.Query("status")
+	emails, err := repositories.GetOutboundEmails(status, 200)
+	if err != nil {
+		logger.Error(
+			"ViewAdminEmailsPage - failed to get outbound emails",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/dashboard-page/",
+		)
+		return
+	}
+
+	statusCounts, err := repositories.CountOutboundEmailsByStatus()
+	if err != nil {
+		logger.Error(
+			"ViewAdminEmailsPage - failed to count outbound emails",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+	}
+
+	logger.Info(
+		"ViewAdminEmailsPage - rendering admin emails page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":        "Email Deliveries",
+		"csrfToken":    middlewares.GetCSRFToken(c),
+		"emails":       emails,
+		"status":       status,
+		"statusCounts": statusCounts,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminEmails.html",
+		context,
+	)
+}
+
+func RequeueEmailPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	outboundEmailID, _ := strconv.Atoi(c.Param("outboundEmailID"))
+	err := repositories.RequeueOutboundEmail(uint(outboundEmailID), time.Now())
+	if err != nil {
+		logger.Error(
+			"RequeueEmailPage - failed to requeue email",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/admin/emails-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"RequeueEmailPage - email requeued",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Outbound Email ID", outboundEmailID,
+		"action", "redirecting to admin emails page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/admin/emails-page/?status=dead",
+	)
+}
+
+// emailPreviewData holds sample values used to render each email template in
+// the admin preview.
+var emailPreviewData = map[string]map[string]interface{}{
+	"otp": {
+		"otp": "123456",
+	},
+	"reset_password": {
+		"resetURL": "https://example.com/electivote/reset-password-page/?token=sample",
+	},
+	"email_changed": {
+		"newEmail": "new.address@example.com",
+	},
+	"account_deletion": {
+		"deletionTime": time.Now().AddDate(0, 0, 14).Format("2006-01-02 15:04"),
+	},
+	"account_locked": {
+		"username":       "sample_user",
+		"lockoutMinutes": 15,
+	},
+	"vote_invitation": {
+		"voteTitle":     "Class President Election",
+		"username":      "sample_user",
+		"voteCode":      "ABC123",
+		"voteURL":       "https://example.com/electivote/vote-page/ABC123/",
+		"moderatorName": "moderator",
+		"startTime":     time.Now().Add(time.Hour).Format("2006-01-02 15:04"),
+	},
+	"vote_reminder": {
+		"voteTitle": "Class President Election",
+		"username":  "sample_user",
+		"voteCode":  "ABC123",
+		"voteURL":   "https://example.com/electivote/vote-page/ABC123/",
+	},
+	"vote_results": {
+		"voteTitle":  "Class President Election",
+		"totalVotes": 42,
+		"winnerName": "Jane Doe",
+		"candidates": []struct {
+			Name  string
+			Votes int
+		}{
+			{Name: "Jane Doe", Votes: 25},
+			{Name: "John Smith", Votes: 17},
+		},
+		"resultURL": "https://example.com/electivote/verify-results/1/sample/",
+	},
+}
+
+func ViewAdminEmailTemplatesPage(c *gin.Context) {
+	logger.Info(
+		"ViewAdminEmailTemplatesPage - rendering admin email templates page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	context := gin.H{
+		"title":         "Email Templates",
+		"csrfToken":     middlewares.GetCSRFToken(c),
+		"templateNames": utils.EmailTemplateNames,
+		"locales":       utils.SupportedLocales,
+	}
+	c.HTML(
+		http.StatusOK,
+		"adminEmailTemplates.html",
+		context,
+	)
+}
+
+func PreviewEmailTemplatePage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	templateName := c.Param("templateName")
+	data, ok := emailPreviewData[templateName]
+	if !ok {
+		logger.Warn(
+			"PreviewEmailTemplatePage - unknown template",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Template", templateName,
+		)
+		utils.RenderError(c, http.StatusNotFound, "Email template not found", "/electivote/admin/email-templates-page/")
+		return
+	}
+
+	rendered, err := utils.RenderEmail(templateName, c.Param("locale"), data)
+	if err != nil {
+		logger.Error(
+			"PreviewEmailTemplatePage - failed to render template",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Template", templateName,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, err.Error(), "/electivote/admin/email-templates-page/")
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.Data(
+			http.StatusOK,
+			"text/plain; charset=utf-8",
+			[]byte("Subject: "+rendered.Subject+"\n\n"+rendered.TextBody),
+		)
+		return
+	}
+	c.Data(
+		http.StatusOK,
+		"text/html; charset=utf-8",
+		[]byte(rendered.HTMLBody),
+	)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type apiCandidateRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// getAPIModeratedCandidate loads the :candidateID candidate of a vote the
+// current user manages, answering 404 otherwise.
+func getAPIModeratedCandidate(c *gin.Context, voteData models.Vote) (models.Candidate, bool) {
+	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
+	candidate, err := repositories.GetCandidateByCandidateID(uint(candidateID))
+	if err != nil || candidate.CandidateID == 0 || candidate.VoteId != voteData.VoteID {
+		utils.RenderAPIError(c, http.StatusNotFound, "not_found", "Candidate not found", nil)
+		return models.Candidate{}, false
+	}
+	return candidate, true
+}
+
+func APIListCandidates(c *gin.Context) {
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	candidates, err := repositories.GetCandidatesByVoteID(voteData.VoteID)
+	if err != nil {
+		logger.Error(
+			"APIListCandidates - failed to get candidates by vote ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	pageCandidates, page := utils.Paginate(toAPICandidates(candidates, true), utils.GetAPIPage(c))
+	utils.RenderAPIList(c, pageCandidates, page)
+}
+
+func APICreateCandidate(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	request := apiCandidateRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+	name, description := "", ""
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if request.Description != nil {
+		description = *request.Description
+	}
+	if len(name) < 3 {
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid candidate input", map[string]string{
+			"name": "Candidate name must be at least 3 characters",
+		})
+		return
+	}
+
+	candidate, err := repositories.AddCandidate(factories.CandidateFactory(name, description, "default.png", voteData.VoteID))
+	if err != nil {
+		logger.Error(
+			"APICreateCandidate - Error Adding Candidate",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APICreateCandidate - Candidate Added",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Candidate ID", candidate.CandidateID,
+	)
+	utils.RenderAPIData(c, http.StatusCreated, toAPICandidate(candidate, true))
+}
+
+func APIGetCandidate(c *gin.Context) {
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	candidate, ok := getAPIModeratedCandidate(c, voteData)
+	if !ok {
+		return
+	}
+	utils.RenderAPIData(c, http.StatusOK, toAPICandidate(candidate, true))
+}
+
+func APIUpdateCandidate(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	candidate, ok := getAPIModeratedCandidate(c, voteData)
+	if !ok {
+		return
+	}
+	request := apiCandidateRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+	if request.Name != nil {
+		candidate.CandidateName = *request.Name
+	}
+	if request.Description != nil {
+		candidate.CandidateDescription = *request.Description
+	}
+	if len(candidate.CandidateName) < 3 {
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid candidate input", map[string]string{
+			"name": "Candidate name must be at least 3 characters",
+		})
+		return
+	}
+
+	updatedCandidate := factories.CandidateFactory(candidate.CandidateName, candidate.CandidateDescription, candidate.CandidatePicture, voteData.VoteID)
+	_, err := repositories.UpdateCandidate(candidate.CandidateID, updatedCandidate)
+	if err != nil {
+		logger.Error(
+			"APIUpdateCandidate - Error Updating Candidate",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APIUpdateCandidate - Candidate Updated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Candidate ID", candidate.CandidateID,
+	)
+	utils.RenderAPIData(c, http.StatusOK, toAPICandidate(candidate, true))
+}
+
+func APIDeleteCandidate(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	candidate, ok := getAPIModeratedCandidate(c, voteData)
+	if !ok {
+		return
+	}
+	err := repositories.DeleteCandidate(candidate.CandidateID)
+	if err != nil {
+		logger.Error(
+			"APIDeleteCandidate - Error Deleting Candidate",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APIDeleteCandidate - Candidate Deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Candidate ID", candidate.CandidateID,
+	)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type apiUser struct {
+	ID               uint   `json:"id"`
+	Username         string `json:"username"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	TwoFactorEnabled bool   `json:"twoFactorEnabled"`
+	Locale           string `json:"locale"`
+}
+
+type apiVote struct {
+	ID             uint      `json:"id"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	Code           string    `json:"code"`
+	ModeratorID    uint      `json:"moderatorId"`
+	OrganizationID *uint     `json:"organizationId"`
+	VoterRollID    *uint     `json:"voterRollId"`
+	PublicBallot   bool      `json:"publicBallot"`
+	Start          time.Time `json:"start"`
+}
+
+type apiCandidate struct {
+	ID          uint   `json:"id"`
+	VoteID      uint   `json:"voteId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Picture     string `json:"picture"`
+	TotalVotes  *uint  `json:"totalVotes,omitempty"`
+}
+
+// apiBallot repeats PublicBallot at the top level so clients can warn the
+// voter that their name and choice appear in the result exports.
+type apiBallot struct {
+	Vote         apiVote        `json:"vote"`
+	Candidates   []apiCandidate `json:"candidates"`
+	HasVoted     bool           `json:"hasVoted"`
+	PublicBallot bool           `json:"publicBallot"`
+}
+
+type apiVoteResult struct {
+	Vote       apiVote        `json:"vote"`
+	TotalVotes uint           `json:"totalVotes"`
+	Winner     *apiCandidate  `json:"winner"`
+	Candidates []apiCandidate `json:"candidates"`
+}
+
+type apiVoteHistory struct {
+	ID            uint      `json:"id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	ModeratorName string    `json:"moderatorName"`
+	WinnerName    string    `json:"winnerName"`
+	WinnerPicture string    `json:"winnerPicture"`
+	TotalVotes    uint      `json:"totalVotes"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+}
+
+type apiLoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func toAPIUser(user models.User) apiUser {
+	return apiUser{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Locale:           utils.NormalizeLocale(user.Locale),
+	}
+}
+
+func toAPIVote(vote models.Vote) apiVote {
+	return apiVote{
+		ID:             vote.VoteID,
+		Title:          vote.VoteTitle,
+		Description:    vote.VoteDescription,
+		Code:           vote.VoteCode,
+		ModeratorID:    vote.ModeratorID,
+		OrganizationID: vote.OrganizationID,
+		VoterRollID:    vote.VoterRollID,
+		PublicBallot:   vote.PublicBallot,
+		Start:          vote.Start.Time,
+	}
+}
+
+// toAPICandidate hides the running vote count unless the caller may see results.
+func toAPICandidate(candidate models.Candidate, withVotes bool) apiCandidate {
+	result := apiCandidate{
+		ID:          candidate.CandidateID,
+		VoteID:      candidate.VoteId,
+		Name:        candidate.CandidateName,
+		Description: candidate.CandidateDescription,
+		Picture:     candidate.CandidatePicture,
+	}
+	if withVotes {
+		totalVotes := candidate.TotalVotes
+		result.TotalVotes = &totalVotes
+	}
+	return result
+}
+
+func toAPICandidates(candidates []models.Candidate, withVotes bool) []apiCandidate {
+	result := make([]apiCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		result = append(result, toAPICandidate(candidate, withVotes))
+	}
+	return result
+}
+
+func toAPIVoteHistory(voteHistory models.VoteHistory) apiVoteHistory {
+	return apiVoteHistory{
+		ID:            voteHistory.VoteHistoryID,
+		Title:         voteHistory.VoteTitle,
+		Description:   voteHistory.VoteDescription,
+		ModeratorName: voteHistory.ModeratorName,
+		WinnerName:    voteHistory.CandidateWinnerName,
+		WinnerPicture: voteHistory.CandidateWinnerPicture,
+		TotalVotes:    voteHistory.TotalVotes,
+		Start:         voteHistory.Start.Time,
+		End:           voteHistory.End.Time,
+	}
+}
+
+func APILogin(c *gin.Context) {
+	request := apiLoginRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+
+	if wait := middlewares.GetLoginWait(c, request.Username); wait > 0 {
+		middlewares.LogSecurityEvent(c, "login_throttled", request.Username, "Retry After", wait.String())
+		c.Header("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+		utils.RenderAPIError(c, http.StatusTooManyRequests, "too_many_attempts", "Too many failed attempts, please try again later", nil)
+		return
+	}
+
+	usernameErr, passwordErr := utils.ValidateLoginInput(request.Username, request.Password, c)
+	if usernameErr != "" || passwordErr != "" {
+		fields := map[string]string{}
+		if usernameErr != "" {
+			fields["username"] = usernameErr
+		}
+		if passwordErr != "" {
+			fields["password"] = passwordErr
+		}
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid login input", fields)
+		return
+	}
+
+	user, err := repositories.GetUserByUsername(request.Username)
+	if err == nil {
+		_, err = repositories.CheckPasswordByUSername(request.Username, request.Password)
+	}
+	if err != nil {
+		middlewares.RecordFailedLogin(c, request.Username, "wrong api credentials")
+		utils.RenderAPIError(c, http.StatusUnauthorized, "invalid_credentials", "Username or password is incorrect", nil)
+		return
+	}
+
+	if user.Suspended {
+		logger.Warn(
+			"APILogin - User is suspended",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+		)
+		utils.RenderAPIError(c, http.StatusForbidden, "account_suspended", "Your account has been suspended", nil)
+		return
+	}
+	if user.PasswordResetRequired {
+		logger.Warn(
+			"APILogin - Password reset required",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+		)
+		utils.RenderAPIError(c, http.StatusForbidden, "password_reset_required", "A password reset is required, please check your email", nil)
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		code := strings.TrimSpace(request.Code)
+		if code == "" {
+			utils.RenderAPIError(c, http.StatusUnauthorized, "two_factor_required", "A two-factor code is required", nil)
+			return
+		}
+		valid := utils.ValidateTOTPCode(user.ID, user.TwoFactorSecret, code)
+		if !valid && strings.Contains(code, "-") {
+			valid, err = repositories.UseRecoveryCode(user.ID, utils.HashRecoveryCode(code))
+			if err != nil {
+				logger.Error(
+					"APILogin - failed to check recovery code",
+					"error", err.Error(),
+					"Client IP", c.ClientIP(),
+					"Username", user.Username,
+				)
+			}
+		}
+		if !valid {
+			middlewares.RecordFailedLogin(c, user.Username, "wrong two-factor code")
+			utils.RenderAPIError(c, http.StatusUnauthorized, "invalid_two_factor_code", "Invalid two-factor code", nil)
+			return
+		}
+	}
+
+	token, expiresAt, err := utils.GenerateAPIToken(user)
+	if err != nil {
+		logger.Error(
+			"APILogin - failed to generate token",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	middlewares.ResetFailedLogins(c, user.Username)
+	logger.Info(
+		"APILogin - User logged in",
+		"Client IP", c.ClientIP(),
+		"Username", user.Username,
+	)
+	utils.RenderAPIData(c, http.StatusOK, gin.H{
+		"token":     token,
+		"tokenType": "Bearer",
+		"expiresAt": expiresAt,
+		"user":      toAPIUser(user),
+	})
+}
+
+func APIGetCurrentUser(c *gin.Context) {
+	utils.RenderAPIData(c, http.StatusOK, toAPIUser(middlewares.GetCurrentUser(c)))
+}
+
+func APIOpenAPIDocument(c *gin.Context) {
+	c.File("internal/views/api/openapi.json")
+}
@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type apiVoteRequest struct {
+	Title          *string `json:"title"`
+	Description    *string `json:"description"`
+	OrganizationID uint    `json:"organizationId"`
+	VoterRollID    *uint   `json:"voterRollId"`
+	PublicBallot   bool    `json:"publicBallot"`
+}
+
+type apiJoinVoteRequest struct {
+	Code string `json:"code"`
+}
+
+type apiBallotRequest struct {
+	CandidateID uint `json:"candidateId"`
+}
+
+// getAPIModeratedVote loads the vote in the :voteID path parameter, answering
+// 404 when it does not exist or the current user may not manage it.
+func getAPIModeratedVote(c *gin.Context) (models.Vote, bool) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
+		logger.Warn(
+			"getAPIModeratedVote - User is not a valid vote moderator",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Vote ID", voteID,
+		)
+		utils.RenderAPIError(c, http.StatusNotFound, "not_found", "Vote not found", nil)
+		return models.Vote{}, false
+	}
+	voteData, err := repositories.GetVoteDataByVoteID(uint(voteID))
+	if err != nil {
+		logger.Error(
+			"getAPIModeratedVote - failed to get vote data by vote ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return models.Vote{}, false
+	}
+	return voteData, true
+}
+
+func APIListVotes(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	votes, err := repositories.GetVotesDataByUsername(username)
+	if err != nil {
+		logger.Error(
+			"APIListVotes - failed to get votes data by username",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	apiVotes := make([]apiVote, 0, len(votes))
+	for _, vote := range votes {
+		apiVotes = append(apiVotes, toAPIVote(vote))
+	}
+	pageVotes, page := utils.Paginate(apiVotes, utils.GetAPIPage(c))
+	utils.RenderAPIList(c, pageVotes, page)
+}
+
+func APICreateVote(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	request := apiVoteRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+
+	fields := map[string]string{}
+	title, description := "", ""
+	if request.Title != nil {
+		title = *request.Title
+	}
+	if request.Description != nil {
+		description = *request.Description
+	}
+	if len(title) < 5 {
+		fields["title"] = "Vote title must be at least 5 characters"
+	}
+	if request.OrganizationID != 0 && !repositories.IsValidOrganizationManager(currentUser.Username, request.OrganizationID) {
+		fields["organizationId"] = "You are not allowed to create votes for this organization"
+	}
+	if request.VoterRollID != nil && *request.VoterRollID != 0 &&
+		(request.OrganizationID == 0 || !repositories.IsValidOrganizationVoterRoll(request.OrganizationID, *request.VoterRollID)) {
+		fields["voterRollId"] = "Voter roll must belong to the selected organization"
+	}
+	if len(fields) > 0 {
+		logger.Warn(
+			"APICreateVote - invalid input",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid vote input", fields)
+		return
+	}
+
+	newVote := factories.StartVoteFactory(title, description, utils.GenerateVoteCode(), currentUser.ID, models.CustomTime{Time: time.Now()})
+	if request.OrganizationID != 0 {
+		organizationID := request.OrganizationID
+		newVote.OrganizationID = &organizationID
+	}
+	if request.VoterRollID != nil && *request.VoterRollID != 0 {
+		voterRollID := *request.VoterRollID
+		newVote.VoterRollID = &voterRollID
+	}
+	newVote.PublicBallot = request.PublicBallot
+	vote, err := repositories.CreateVote(newVote)
+	if err != nil {
+		logger.Error(
+			"APICreateVote - failed to create vote",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	utils.QueueVoteCreatedWebhooks(vote)
+	go utils.QueueVoteInvitations(vote)
+	logger.Info(
+		"APICreateVote - vote created",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Vote ID", vote.VoteID,
+	)
+	utils.RenderAPIData(c, http.StatusCreated, toAPIVote(vote))
+}
+
+func APIGetVote(c *gin.Context) {
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	utils.RenderAPIData(c, http.StatusOK, toAPIVote(voteData))
+}
+
+func APIUpdateVote(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	request := apiVoteRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+
+	fields := map[string]string{}
+	title, description := voteData.VoteTitle, voteData.VoteDescription
+	if request.Title != nil {
+		title = *request.Title
+	}
+	if request.Description != nil {
+		description = *request.Description
+	}
+	if len(title) < 5 {
+		fields["title"] = "Vote title must be at least 5 characters"
+	}
+	if request.VoterRollID != nil && *request.VoterRollID != 0 &&
+		(voteData.OrganizationID == nil || !repositories.IsValidOrganizationVoterRoll(*voteData.OrganizationID, *request.VoterRollID)) {
+		fields["voterRollId"] = "Voter roll must belong to the vote organization"
+	}
+	if len(fields) > 0 {
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid vote input", fields)
+		return
+	}
+
+	_, err := repositories.UpdateVote(voteData.VoteID, factories.UpdateVoteFactory(title, description))
+	if err == nil && request.VoterRollID != nil {
+		var voterRollID *uint
+		if *request.VoterRollID != 0 {
+			voterRollID = request.VoterRollID
+		}
+		err = repositories.UpdateVoteVoterRoll(voteData.VoteID, voterRollID)
+	}
+	if err != nil {
+		logger.Error(
+			"APIUpdateVote - failed to update vote",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	voteData, err = repositories.GetVoteDataByVoteID(voteData.VoteID)
+	if err != nil {
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APIUpdateVote - vote updated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Vote ID", voteData.VoteID,
+	)
+	utils.RenderAPIData(c, http.StatusOK, toAPIVote(voteData))
+}
+
+func APIDeleteVote(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	err := archiveAndDeleteVote(voteData)
+	if err != nil {
+		logger.Error(
+			"APIDeleteVote - failed to delete vote",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APIDeleteVote - vote deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Vote ID", voteData.VoteID,
+	)
+	c.Status(http.StatusNoContent)
+}
+
+func APIGetVoteResults(c *gin.Context) {
+	voteData, ok := getAPIModeratedVote(c)
+	if !ok {
+		return
+	}
+	candidates, err := repositories.GetCandidatesByVoteID(voteData.VoteID)
+	if err != nil {
+		logger.Error(
+			"APIGetVoteResults - failed to get candidates by vote ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	result := apiVoteResult{
+		Vote:       toAPIVote(voteData),
+		Candidates: toAPICandidates(candidates, true),
+	}
+	for i, candidate := range candidates {
+		result.TotalVotes += candidate.TotalVotes
+		if candidate.TotalVotes > 0 && (result.Winner == nil || candidate.TotalVotes > *result.Winner.TotalVotes) {
+			result.Winner = &result.Candidates[i]
+		}
+	}
+	utils.RenderAPIData(c, http.StatusOK, result)
+}
+
+// getAPIBallot resolves a vote code for the current voter, answering 404 for
+// unknown codes and 403 when the voter is not on the vote's roll.
+func getAPIBallot(c *gin.Context, voteCode string) (models.Vote, bool) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if len(voteCode) != 6 {
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid vote code", map[string]string{
+			"code": "Vote code must be 6 characters",
+		})
+		return models.Vote{}, false
+	}
+	vote, err := repositories.GetVoteByVoteCode(voteCode)
+	if err != nil {
+		logger.Warn(
+			"getAPIBallot - vote code not found",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderAPIError(c, http.StatusNotFound, "not_found", "Vote code not found", nil)
+		return models.Vote{}, false
+	}
+	if !repositories.IsEligibleVoter(currentUser.ID, vote) {
+		logger.Warn(
+			"getAPIBallot - user is not on the voter roll",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"Vote ID", vote.VoteID,
+		)
+		utils.RenderAPIError(c, http.StatusForbidden, "not_eligible", "You are not on the voter roll of this vote", nil)
+		return models.Vote{}, false
+	}
+	return vote, true
+}
+
+func renderAPIBallot(c *gin.Context, vote models.Vote) {
+	candidates, err := repositories.GetCandidatesByVoteID(vote.VoteID)
+	if err != nil {
+		logger.Error(
+			"renderAPIBallot - failed to get candidates by vote ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	utils.RenderAPIData(c, http.StatusOK, apiBallot{
+		Vote:         toAPIVote(vote),
+		Candidates:   toAPICandidates(candidates, false),
+		HasVoted:     repositories.IsVoted(middlewares.GetCurrentUser(c).ID, vote.VoteCode),
+		PublicBallot: vote.PublicBallot,
+	})
+}
+
+func APIJoinVote(c *gin.Context) {
+	request := apiJoinVoteRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+	vote, ok := getAPIBallot(c, request.Code)
+	if !ok {
+		return
+	}
+	if repositories.IsVoted(middlewares.GetCurrentUser(c).ID, vote.VoteCode) {
+		utils.RenderAPIError(c, http.StatusConflict, "already_voted", "You already voted in this vote", nil)
+		return
+	}
+	renderAPIBallot(c, vote)
+}
+
+func APIGetBallot(c *gin.Context) {
+	vote, ok := getAPIBallot(c, c.Param("voteCode"))
+	if !ok {
+		return
+	}
+	renderAPIBallot(c, vote)
+}
+
+func APICastBallot(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	vote, ok := getAPIBallot(c, c.Param("voteCode"))
+	if !ok {
+		return
+	}
+	request := apiBallotRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RenderAPIError(c, http.StatusBadRequest, "invalid_request", "Request body must be JSON", nil)
+		return
+	}
+
+	err := castBallot(vote.VoteID, currentUser.ID, request.CandidateID)
+	if errors.Is(err, repositories.ErrAlreadyVoted) {
+		utils.RenderAPIError(c, http.StatusConflict, "already_voted", "You already voted in this vote", nil)
+		return
+	}
+	if errors.Is(err, repositories.ErrCandidateNotInVote) {
+		utils.RenderAPIError(c, http.StatusUnprocessableEntity, "validation_failed", "Invalid candidate", map[string]string{
+			"candidateId": "Please select a candidate of this vote",
+		})
+		return
+	}
+	if err != nil {
+		logger.Error(
+			"APICastBallot - failed to record vote",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+	logger.Info(
+		"APICastBallot - vote recorded",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Vote ID", vote.VoteID,
+	)
+	utils.RenderAPIData(c, http.StatusCreated, gin.H{
+		"voteId":      vote.VoteID,
+		"candidateId": request.CandidateID,
+	})
+}
+
+func APIListVoteHistory(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	voteHistories, err := repositories.GetVoteHistoriesByUserID(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"APIListVoteHistory - failed to get vote histories by user ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderAPIError(c, http.StatusInternalServerError, "internal_error", "Internal Server Error", nil)
+		return
+	}
+
+	apiVoteHistories := make([]apiVoteHistory, 0, len(voteHistories))
+	for _, voteHistory := range voteHistories {
+		apiVoteHistories = append(apiVoteHistories, toAPIVoteHistory(voteHistory))
+	}
+	pageVoteHistories, page := utils.Paginate(apiVoteHistories, utils.GetAPIPage(c))
+	utils.RenderAPIList(c, pageVoteHistories, page)
+}
+
+func APIGetVoteHistory(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	voteHistoryID, _ := strconv.Atoi(c.Param("voteHistoryID"))
+	voteHistory, err := repositories.GetVoteHistoryByVoteHistoryID(uint(voteHistoryID))
+	if err != nil || voteHistory.ModeratorID != currentUser.ID {
+		utils.RenderAPIError(c, http.StatusNotFound, "not_found", "Vote history not found", nil)
+		return
+	}
+	utils.RenderAPIData(c, http.StatusOK, toAPIVoteHistory(voteHistory))
+}
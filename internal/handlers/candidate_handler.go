@@ -16,20 +16,7 @@ import (
 var logger *slog.Logger = config.SetUpLogger()
 
 func ViewAddCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewAddCandidatePage - User not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -51,6 +38,7 @@ func ViewAddCandidatePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Add Candidate",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteID": voteID,
 	}
 	c.HTML(
@@ -61,20 +49,7 @@ func ViewAddCandidatePage(c *gin.Context) {
 }
 
 func AddCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"AddCandidatePage - User not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -107,6 +82,7 @@ func AddCandidatePage(c *gin.Context) {
 	if candidateNameErr != "" {
 		context := gin.H {
 			"title": "Add Candidate",
+			"csrfToken": middlewares.GetCSRFToken(c),
 			"voteID": voteID,
 			"candidateNameErr": candidateNameErr,
 			"candidateName": candidateName,
@@ -136,10 +112,7 @@ func AddCandidatePage(c *gin.Context) {
 				"/electivote/add-candidate-page/"+strconv.Itoa(voteID),
 			)
 		}
-		err := c.SaveUploadedFile(
-			candidatePicture,
-			"internal/assets/images/"+candidatePicture.Filename,
-		)
+		picture, err := utils.SaveUploadedImage(candidatePicture)
 		if err != nil {
 			logger.Error(
 				"AddCandidatePage - Error Saving Picture",
@@ -149,15 +122,17 @@ func AddCandidatePage(c *gin.Context) {
 			)
 			utils.RenderError(
 				c,
-				http.StatusInternalServerError,
+				utils.ImageUploadStatus(err),
 				err.Error(),
 				"/electivote/add-candidate-page/"+strconv.Itoa(voteID),
 			)
+			return
 		}
-		newCandidate.CandidatePicture = candidatePicture.Filename
+		newCandidate.CandidatePicture = picture
 	}
 	_, err := repositories.AddCandidate(newCandidate)
 	if err != nil {
+		utils.RemoveUnusedImages(newCandidate.CandidatePicture)
 		logger.Error(
 			"AddCandidatePage - Error Adding Candidate",
 			"error", err.Error(),
@@ -170,6 +145,7 @@ func AddCandidatePage(c *gin.Context) {
 			err.Error(),
 			"/electivote/add-candidate-page/"+strconv.Itoa(voteID),
 		)
+		return
 	}
 	logger.Info(
 		"AddCandidatePage - Candidate Added",
@@ -184,20 +160,7 @@ func AddCandidatePage(c *gin.Context) {
 }
 
 func ViewManageCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewManageCandidatePage - User not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
 
@@ -238,8 +201,10 @@ func ViewManageCandidatePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Manage Candidate",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteID": voteID,
 		"candidateID": candidateID,
+		"confirmationToken": middlewares.GetConfirmationToken(c, "delete-candidate:"+strconv.Itoa(candidateID)),
 		"candidateData": candidateData,
 	}
 	c.HTML(
@@ -250,20 +215,7 @@ func ViewManageCandidatePage(c *gin.Context) {
 }
 
 func ManageCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ManageCandidatePage - User not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
 
@@ -314,6 +266,7 @@ func ManageCandidatePage(c *gin.Context) {
 	if candidateNameErr != "" {
 		context := gin.H {
 			"title": "Manage Candidate",
+			"csrfToken": middlewares.GetCSRFToken(c),
 			"voteID": voteID,
 			"candidateID": candidateID,
 			"candidateNameErr": candidateNameErr,
@@ -346,10 +299,7 @@ func ManageCandidatePage(c *gin.Context) {
 				"/electivote/manage-candidate-page/"+strconv.Itoa(voteID)+"/"+strconv.Itoa(candidateID),
 			)
 		}
-		err := c.SaveUploadedFile(
-			candidatePicture,
-			"internal/assets/images/"+candidatePicture.Filename,
-		)
+		picture, err := utils.SaveUploadedImage(candidatePicture)
 		if err != nil {
 			logger.Error(
 				"ManageCandidatePage - Error Saving Picture",
@@ -359,12 +309,13 @@ func ManageCandidatePage(c *gin.Context) {
 			)
 			utils.RenderError(
 				c,
-				http.StatusInternalServerError,
+				utils.ImageUploadStatus(err),
 				err.Error(),
 				"/electivote/manage-candidate-page/"+strconv.Itoa(voteID)+"/"+strconv.Itoa(candidateID),
 			)
+			return
 		}
-		updatedCandidate.CandidatePicture = candidatePicture.Filename
+		updatedCandidate.CandidatePicture = picture
 	}
 
 	_, err = repositories.UpdateCandidate(uint(candidateID), updatedCandidate)
@@ -381,6 +332,10 @@ func ManageCandidatePage(c *gin.Context) {
 			err.Error(),
 			"/electivote/manage-candidate-page/"+strconv.Itoa(voteID)+"/"+strconv.Itoa(candidateID),
 		)
+		return
+	}
+	if updatedCandidate.CandidatePicture != candidateData.CandidatePicture {
+		utils.RemoveUnusedImages(candidateData.CandidatePicture)
 	}
 	logger.Info(
 		"ManageCandidatePage - Candidate Updated",
@@ -395,20 +350,7 @@ func ManageCandidatePage(c *gin.Context) {
 }
 
 func ViewDeleteCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewDeleteCandidatePage - User not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
 
@@ -448,8 +390,10 @@ func ViewDeleteCandidatePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Delete Candidate",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteID": voteID,
 		"candidateID": candidateID,
+		"confirmationToken": middlewares.GetConfirmationToken(c, "delete-candidate:"+strconv.Itoa(candidateID)),
 		"candidateData": candidateData,
 	}
 	c.HTML(
@@ -460,37 +404,41 @@ func ViewDeleteCandidatePage(c *gin.Context) {
 }
 
 func DeleteCandidatePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
+
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) || !repositories.IsValidCandidateModerator(username, uint(candidateID)) {
 		logger.Warn(
-			"DeleteCandidatePage - User not logged in",
+			"DeleteCandidatePage - User not authorized",
 			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
+			"Username", username,
+			"action", "redirecting to home page",
 		)
 		c.Redirect(
 			http.StatusFound,
-			"/electivote/login-page/",
+			"/electivote/home-page/",
 		)
 		return
 	}
 
-	username := middlewares.GetUserData(c)
-	voteID, _ := strconv.Atoi(c.Param("voteID"))
-	candidateID, _ := strconv.Atoi(c.Param("candidateID"))
-
-	if !repositories.IsValidVoteModerator(username, uint(voteID)) || !repositories.IsValidCandidateModerator(username, uint(candidateID)) {
+	if !middlewares.IsValidConfirmationToken(c, "delete-candidate:"+strconv.Itoa(candidateID)) {
 		logger.Warn(
-			"DeleteCandidatePage - User not authorized",
+			"DeleteCandidatePage - invalid confirmation token",
 			"Client IP", c.ClientIP(),
 			"Username", username,
-			"action", "redirecting to home page",
+			"action", "redirecting to delete candidate page",
 		)
 		c.Redirect(
 			http.StatusFound,
-			"/electivote/home-page/",
+			"/electivote/delete-candidate-page/"+strconv.Itoa(voteID)+"/"+strconv.Itoa(candidateID),
 		)
 		return
 	}
-	err := repositories.DeleteCandidate(uint(candidateID))
+	candidateData, err := repositories.GetCandidateByCandidateID(uint(candidateID))
+	if err == nil {
+		err = repositories.DeleteCandidate(uint(candidateID))
+	}
 	if err != nil {
 		logger.Error(
 			"DeleteCandidatePage - Error Deleting Candidate",
@@ -504,7 +452,9 @@ func DeleteCandidatePage(c *gin.Context) {
 			err.Error(),
 			"/electivote/manage-vote-page/"+strconv.Itoa(voteID),
 		)
+		return
 	}
+	utils.RemoveUnusedImages(candidateData.CandidatePicture)
 	logger.Info(
 		"DeleteCandidatePage - Candidate Deleted",
 		"Client IP", c.ClientIP(),
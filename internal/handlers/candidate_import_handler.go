@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ViewImportCandidatesPage(c *gin.Context) {
+	voteID, ok := isValidCandidateImportModerator(c, "ViewImportCandidatesPage")
+	if !ok {
+		return
+	}
+	logger.Info(
+		"ViewImportCandidatesPage - Rendering Page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	renderImportCandidatesPage(c, http.StatusOK, voteID, nil)
+}
+
+func ImportCandidatesPage(c *gin.Context) {
+	voteID, ok := isValidCandidateImportModerator(c, "ImportCandidatesPage")
+	if !ok {
+		return
+	}
+	currentUser := middlewares.GetCurrentUser(c)
+
+	candidateCSV, err := c.FormFile("candidateCSV")
+	if err != nil || candidateCSV.Size > utils.MaxCandidateImportCSVSize {
+		logger.Warn(
+			"ImportCandidatesPage - Invalid CSV file",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderImportCandidatesPage(c, http.StatusBadRequest, voteID, gin.H{
+			"candidateCSVErr": "Upload a CSV file of at most 1 MB",
+		})
+		return
+	}
+	candidatePictures, err := c.FormFile("candidatePictures")
+	if err == nil && candidatePictures.Size > utils.MaxCandidateImportZIPSize {
+		logger.Warn(
+			"ImportCandidatesPage - Pictures ZIP too large",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"Size", candidatePictures.Size,
+		)
+		renderImportCandidatesPage(c, http.StatusBadRequest, voteID, gin.H{
+			"candidatePicturesErr": "The pictures ZIP may be at most 50 MB",
+		})
+		return
+	}
+
+	csvFile, err := candidateCSV.Open()
+	if err == nil {
+		defer csvFile.Close()
+	}
+	var zipFile multipart.File
+	var zipSize int64
+	if err == nil && candidatePictures != nil {
+		zipFile, err = candidatePictures.Open()
+		if err == nil {
+			defer zipFile.Close()
+			zipSize = candidatePictures.Size
+		}
+	}
+	var existingNames []string
+	if err == nil {
+		existingNames, err = getCandidateNames(uint(voteID))
+	}
+	var candidateImport utils.CandidateImport
+	if err == nil {
+		candidateImport, err = utils.ParseCandidateImport(uint(voteID), currentUser.ID, csvFile, zipFile, zipSize, existingNames)
+	}
+	if err == nil && !candidateImport.HasErrors() {
+		err = utils.SaveCandidateImport(candidateImport)
+	}
+	if err != nil {
+		utils.DiscardCandidateImport(candidateImport)
+		logger.Error(
+			"ImportCandidatesPage - Error Preparing Import",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/import-candidates-page/"+strconv.Itoa(voteID),
+		)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if candidateImport.HasErrors() {
+		statusCode = http.StatusUnprocessableEntity
+		utils.DiscardCandidateImport(candidateImport)
+	}
+	logger.Info(
+		"ImportCandidatesPage - Rendering Preview",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Rows", len(candidateImport.Rows),
+		"Valid", !candidateImport.HasErrors(),
+	)
+	renderImportCandidatesPage(c, statusCode, voteID, gin.H{
+		"candidateImport": candidateImport,
+	})
+}
+
+func ConfirmImportCandidatesPage(c *gin.Context) {
+	voteID, ok := isValidCandidateImportModerator(c, "ConfirmImportCandidatesPage")
+	if !ok {
+		return
+	}
+	currentUser := middlewares.GetCurrentUser(c)
+
+	candidateImport, err := utils.ClaimCandidateImport(c.PostForm("importToken"), uint(voteID), currentUser.ID)
+	if err != nil || candidateImport.HasErrors() {
+		logger.Warn(
+			"ConfirmImportCandidatesPage - Import not found",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusBadRequest,
+			utils.ErrCandidateImportNotFound.Error(),
+			"/electivote/import-candidates-page/"+strconv.Itoa(voteID),
+		)
+		return
+	}
+
+	newCandidates := make([]models.Candidate, 0, len(candidateImport.Rows))
+	publishedPictures := []string{}
+	for _, row := range candidateImport.Rows {
+		candidatePicture, err := utils.PublishCandidateImportPicture(candidateImport, row)
+		if err != nil {
+			utils.RemoveUnusedImages(publishedPictures...)
+			utils.DiscardCandidateImport(candidateImport)
+			logger.Error(
+				"ConfirmImportCandidatesPage - Error Saving Picture",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", currentUser.Username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/import-candidates-page/"+strconv.Itoa(voteID),
+			)
+			return
+		}
+		if row.StagedFile != "" {
+			publishedPictures = append(publishedPictures, candidatePicture)
+		}
+		newCandidates = append(newCandidates, factories.CandidateFactory(row.Name, row.Description, candidatePicture, uint(voteID)))
+	}
+
+	_, err = repositories.AddCandidates(newCandidates)
+	if err != nil {
+		utils.RemoveUnusedImages(publishedPictures...)
+		utils.DiscardCandidateImport(candidateImport)
+		logger.Error(
+			"ConfirmImportCandidatesPage - Error Adding Candidates",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/import-candidates-page/"+strconv.Itoa(voteID),
+		)
+		return
+	}
+	utils.DiscardCandidateImport(candidateImport)
+
+	logger.Info(
+		"ConfirmImportCandidatesPage - Candidates Imported",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Candidates", len(newCandidates),
+		"action", "redirecting to manage vote page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-vote-page/"+strconv.Itoa(voteID),
+	)
+}
+
+func CancelImportCandidatesPage(c *gin.Context) {
+	voteID, ok := isValidCandidateImportModerator(c, "CancelImportCandidatesPage")
+	if !ok {
+		return
+	}
+	currentUser := middlewares.GetCurrentUser(c)
+	candidateImport, err := utils.GetCandidateImport(c.PostForm("importToken"), uint(voteID), currentUser.ID)
+	if err == nil {
+		utils.DiscardCandidateImport(candidateImport)
+	}
+
+	logger.Info(
+		"CancelImportCandidatesPage - Import Cancelled",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"action", "redirecting to manage vote page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-vote-page/"+strconv.Itoa(voteID),
+	)
+}
+
+func isValidCandidateImportModerator(c *gin.Context, source string) (int, bool) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
+		logger.Warn(
+			source+" - User not authorized",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to home page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/home-page/",
+		)
+		return 0, false
+	}
+	return voteID, true
+}
+
+func renderImportCandidatesPage(c *gin.Context, statusCode, voteID int, extra gin.H) {
+	context := gin.H{
+		"title":     "Import Candidates",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"voteID":    voteID,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"importCandidates.html",
+		context,
+	)
+}
+
+func getCandidateNames(voteID uint) ([]string, error) {
+	candidates, err := repositories.GetCandidatesByVoteID(voteID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.CandidateName)
+	}
+	return names, nil
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
@@ -15,28 +14,17 @@ import (
 )
 
 func ViewGiveFeedbackPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewHomePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	logger.Info(
 		"ViewGiveFeedbackPage - rendering give feedback page",
 		"Client IP", c.ClientIP(),
 		"Username", username,
 	)
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
+	captcha := utils.NewCaptchaWidget()
 	context := gin.H{
-		"title":   "Feedback",
-		"siteKey": siteKey,
+		"title":     "Feedback",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"captcha":   captcha,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -46,34 +34,9 @@ func ViewGiveFeedbackPage(c *gin.Context) {
 }
 
 func GiveFeedbackPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"GiveFeedbackPage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
-	username := middlewares.GetUserData(c)
-	userID, err := repositories.GetUserIdByUsername(username)
-	if err != nil {
-		logger.Error(
-			"GiveFeedbackPage - failed to get user id",
-			"Username", username,
-			"Client IP", c.ClientIP(),
-			"error", err,
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
+	captcha := utils.NewCaptchaWidget()
+	username := middlewares.GetCurrentUser(c).Username
+	userID := int(middlewares.GetCurrentUser(c).ID)
 	feedbackMessage := c.PostForm("feedback")
 	feedbackRateStr := c.PostForm("rating")
 	feedbackRate, err := strconv.Atoi(feedbackRateStr)
@@ -103,7 +66,8 @@ func GiveFeedbackPage(c *gin.Context) {
 		)
 		context := gin.H{
 			"title":              "Feedback",
-			"siteKey":            siteKey,
+			"csrfToken":          middlewares.GetCSRFToken(c),
+			"captcha":            captcha,
 			"feedbackMessage":    feedbackMessage,
 			"feedbackRate":       feedbackRateStr,
 			"feedbackMessageErr": feedbackMessageErr,
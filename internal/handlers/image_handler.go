@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ServeImage answers /images/<key>. Images in the local store are served from
+// disk, images in a remote store are redirected to. When storage is private
+// the URL must carry a valid signature.
+func ServeImage(c *gin.Context) {
+	key := strings.Trim(c.Param("key"), "/")
+	if !utils.IsImageKey(key) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	storageConfig := config.GetStorageConfig()
+	if storageConfig.Private && !utils.IsValidImageSignature(key, c.Query("expires"), c.Query("signature")) {
+		logger.Warn(
+			"ServeImage - invalid or expired image signature",
+			"Client IP", c.ClientIP(),
+			"Image", key,
+		)
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	store := utils.GetBlobStore()
+	localStore, ok := store.(*utils.LocalBlobStore)
+	if !ok {
+		c.Redirect(http.StatusFound, store.URL(key))
+		return
+	}
+	path, err := localStore.Path(key)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if storageConfig.Private {
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(storageConfig.URLTTL.Seconds())))
+	} else {
+		c.Header("Cache-Control", "public, max-age=86400")
+	}
+	c.File(path)
+}
@@ -3,9 +3,9 @@ package handlers
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"sync"
 
+	"github.com/AndreanDjabbar/ElectiVote/config"
 	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
 	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
 	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
@@ -14,30 +14,17 @@ import (
 )
 
 func ViewLoginPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ViewLoginPage - User already logged in",
-			"username", username,
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	logger.Info(
 		"ViewLoginPage - Login page acessed",
 		"Client IP", c.ClientIP(),
 	)
 
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
+	captcha := utils.NewCaptchaWidget()
 	context := gin.H{
-		"title":   "Login",
-		"siteKey": siteKey,
+		"title":         "Login",
+		"csrfToken":     middlewares.GetCSRFToken(c),
+		"oidcProviders": config.GetOIDCProviders(),
+		"captcha":       captcha,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -47,24 +34,28 @@ func ViewLoginPage(c *gin.Context) {
 }
 
 func LoginPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		logger.Warn(
-			"LoginPage - User already logged in",
-			"username", middlewares.GetUserData(c),
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	username := c.PostForm("username")
 	password := c.PostForm("password")
 	remember := c.PostForm("remember")
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
+	captcha := utils.NewCaptchaWidget()
+
+	if wait := middlewares.GetLoginWait(c, username); wait > 0 {
+		middlewares.LogSecurityEvent(c, "login_throttled", username, "Retry After", wait.String())
+		context := gin.H{
+			"title":         "Login",
+			"csrfToken":     middlewares.GetCSRFToken(c),
+			"oidcProviders": config.GetOIDCProviders(),
+			"username":      username,
+			"captcha":       captcha,
+			"passwordErr":   fmt.Sprintf("Too many failed attempts, please try again in %d seconds", int(wait.Seconds())+1),
+		}
+		c.HTML(
+			http.StatusTooManyRequests,
+			"login.html",
+			context,
+		)
+		return
+	}
 
 	usernameErr, passwordErr := utils.ValidateLoginInput(username, password, c)
 	var usernameCheckErr, passwordCheckErr error
@@ -115,15 +106,59 @@ func LoginPage(c *gin.Context) {
 		passwordErr = "Password is incorrect"
 	}
 
-	if !utils.IsValidReCAPTCHA(c) {
+	if usernameErr == "" && passwordErr == "" {
+		user, err := repositories.GetUserByUsername(username)
+		if err == nil && user.Suspended {
+			logger.Warn(
+				"LoginPage - User is suspended",
+				"username", username,
+				"client IP", c.ClientIP(),
+			)
+			usernameErr = "Your account has been suspended"
+		}
+		if err == nil && user.PasswordResetRequired {
+			logger.Warn(
+				"LoginPage - Password reset required",
+				"username", username,
+				"client IP", c.ClientIP(),
+			)
+			passwordErr = "A password reset is required, please check your email"
+		}
+	}
+
+	if !utils.IsValidCaptcha(c) {
 		logger.Warn(
-			"LoginPage - Invalid ReCAPTCHA",
+			"LoginPage - Invalid CAPTCHA",
 			"client IP", c.ClientIP(),
 		)
-		captchaErr = "Invalid ReCAPTCHA"
+		captchaErr = "Invalid CAPTCHA"
+	}
+
+	if usernameCheckErr != nil || passwordCheckErr != nil {
+		reason := "wrong password"
+		if usernameCheckErr != nil {
+			reason = "unknown username"
+		}
+		middlewares.RecordFailedLogin(c, username, reason)
 	}
 
 	if usernameErr == "" && passwordErr == "" && captchaErr == "" {
+		user, err := repositories.GetUserByUsername(username)
+		if err == nil && user.TwoFactorEnabled {
+			middlewares.SetTwoFactorSession(c, username, remember == "on")
+			logger.Info(
+				"LoginPage - Password verified, two-factor code required",
+				"username", username,
+				"client IP", c.ClientIP(),
+				"action", "redirecting to two-factor page",
+			)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/two-factor-page/",
+			)
+			return
+		}
+		middlewares.ResetFailedLogins(c, username)
 		if remember == "on" {
 			middlewares.SetCookies(c, username)
 		} else {
@@ -142,13 +177,15 @@ func LoginPage(c *gin.Context) {
 		return
 	}
 	context := gin.H{
-		"title":       "Login",
-		"usernameErr": usernameErr,
-		"passwordErr": passwordErr,
-		"captchaErr":  captchaErr,
-		"username":    username,
-		"password":    password,
-		"siteKey":     siteKey,
+		"title":         "Login",
+		"csrfToken":     middlewares.GetCSRFToken(c),
+		"oidcProviders": config.GetOIDCProviders(),
+		"usernameErr":   usernameErr,
+		"passwordErr":   passwordErr,
+		"captchaErr":    captchaErr,
+		"username":      username,
+		"password":      password,
+		"captcha":       captcha,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -173,27 +210,47 @@ func LogoutPage(c *gin.Context) {
 	)
 }
 
-func ViewForgotPasswordPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ViewForgotPasswordPage - User already logged in",
-			"username", username,
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
+func LogoutAllDevicesPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	err := middlewares.RevokeAllSessions(c, username)
+	if err != nil {
+		logger.Error(
+			"LogoutAllDevicesPage - failed to revoke sessions",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
 		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/profile-page/",
 		)
 		return
 	}
+
+	logger.Info(
+		"LogoutAllDevicesPage - User logged out of all devices",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"action", "redirecting to login page",
+	)
+	middlewares.DeleteSession(c)
+	middlewares.DeleteCookie(c)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/login-page/",
+	)
+}
+
+func ViewForgotPasswordPage(c *gin.Context) {
 	logger.Info(
 		"ViewForgotPasswordPage - Rendering Forgot Password Page",
 		"Client IP", c.ClientIP(),
 	)
 	context := gin.H{
-		"title": "Forgot Password",
+		"title":     "Forgot Password",
+		"csrfToken": middlewares.GetCSRFToken(c),
 	}
 	c.HTML(
 		http.StatusOK,
@@ -222,19 +279,26 @@ func verifyResetToken(tokenString string) (string, error) {
 	return userEmail, nil
 }
 
-func ForgotPasswordPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ForgotPasswordPage - User already logged in",
-			"username", username,
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
+func sendResetPasswordEmail(email, locale string) error {
+	tokenString, err := utils.GenerateResetToken(email)
+	if err != nil {
+		return err
+	}
+	resetURL := fmt.Sprintf("%s/electivote/reset-password-page/%s", config.GetBaseURL(), tokenString)
+	err = utils.QueueTemplateEmail(email, locale, "reset_password", map[string]interface{}{
+		"resetURL": resetURL,
+	})
+	if err != nil {
+		logger.Error(
+			"sendResetPasswordEmail - Failed to queue email",
+			"error", err,
 		)
-		c.Redirect(http.StatusFound, "/electivote/home-page/")
-		return
+		return err
 	}
+	return nil
+}
 
+func ForgotPasswordPage(c *gin.Context) {
 	logger.Info(
 		"ForgotPasswordPage - Forgot Password page accessed",
 		"Client IP", c.ClientIP(),
@@ -259,7 +323,7 @@ func ForgotPasswordPage(c *gin.Context) {
 		emailErr = "Email is not valid"
 	}
 
-	_, err := repositories.GetUserByEmail(email)
+	user, err := repositories.GetUserByEmail(email)
 	if err != nil {
 		logger.Warn(
 			"ForgotPasswordPage - Email not found",
@@ -275,15 +339,16 @@ func ForgotPasswordPage(c *gin.Context) {
 			"Client IP", c.ClientIP(),
 		)
 		context := gin.H{
-			"title":    "Forgot Password",
-			"emailErr": emailErr,
-			"email":    email,
+			"title":     "Forgot Password",
+			"csrfToken": middlewares.GetCSRFToken(c),
+			"emailErr":  emailErr,
+			"email":     email,
 		}
 		c.HTML(http.StatusOK, "forgotPassword.html", context)
 		return
 	}
 
-	tokenString, err := utils.GenerateResetToken(email)
+	err = sendResetPasswordEmail(email, user.Locale)
 	if err != nil {
 		logger.Error(
 			"ForgotPasswordPage - Internal Server Error",
@@ -292,62 +357,6 @@ func ForgotPasswordPage(c *gin.Context) {
 		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/home-page/")
 		return
 	}
-	emailDomain := utils.GetEmailDomain(email)
-	emailProvider := utils.GetEmailProvider(emailDomain)
-	resetURL := fmt.Sprintf("http://localhost:8080/electivote/reset-password-page/%s", tokenString)
-	body := fmt.Sprintf(`
-    <html>
-    <head>
-        <style>
-            .email-container {
-                font-family: Arial, sans-serif;
-                line-height: 1.6;
-                color: #333;
-            }
-            .email-header {
-                font-size: 20px;
-                font-weight: bold;
-                margin-bottom: 20px;
-            }
-            .email-content {
-                font-size: 16px;
-                margin-bottom: 30px;
-            }
-            .email-link {
-                display: inline-block;
-                padding: 10px 15px;
-                background-color: #4CAF50;
-                color: white;
-                text-decoration: none;
-                border-radius: 5px;
-            }
-        </style>
-    </head>
-    <body>
-        <div class="email-container">
-            <div class="email-header">Reset Password</div>
-            <div class="email-content">
-                <p>To reset your password, please click the following link:</p>
-                <p>
-                    <a href="%s" class="email-link">Reset Your Password</a>
-                </p>
-                <p>If you did not request a password reset, please ignore this email.</p>
-            </div>
-        </div>
-    </body>
-    </html>
-`, resetURL)
-
-	subject := "Reset your password"
-	go func() {
-		err = utils.SendEmail(email, emailProvider, body, subject)
-		if err != nil {
-			logger.Error(
-				"ForgotPasswordPage - Failed to send email",
-				"error", err,
-			)
-		}
-	}()
 	logger.Info(
 		"ForgotPasswordPage - Email sent",
 		"client IP", c.ClientIP(),
@@ -357,21 +366,6 @@ func ForgotPasswordPage(c *gin.Context) {
 }
 
 func ViewResetPasswordPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ViewResetPasswordPage - User already logged in",
-			"username", username,
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	logger.Info(
 		"ViewResetPasswordPage - Rendering Reset Password Page",
 		"Client IP", c.ClientIP(),
@@ -394,9 +388,10 @@ func ViewResetPasswordPage(c *gin.Context) {
 		return
 	}
 	context := gin.H{
-		"title": "Reset Password",
-		"email": email,
-		"token": token,
+		"title":     "Reset Password",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"email":     email,
+		"token":     token,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -406,20 +401,6 @@ func ViewResetPasswordPage(c *gin.Context) {
 }
 
 func ResetPasswordPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ResetPasswordPage - User already logged in",
-			"username", username,
-			"client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
 	token := c.Param("token")
 	email, err := verifyResetToken(token)
 	if err != nil {
@@ -459,6 +440,7 @@ func ResetPasswordPage(c *gin.Context) {
 	if passwordErr != "" {
 		context := gin.H{
 			"title":       "Reset Password",
+			"csrfToken":   middlewares.GetCSRFToken(c),
 			"passwordErr": passwordErr,
 			"email":       email,
 			"token":       token,
@@ -500,6 +482,25 @@ func ResetPasswordPage(c *gin.Context) {
 		)
 		return
 	}
+	err = repositories.SetPasswordResetRequired(email, false)
+	if err != nil {
+		logger.Error(
+			"ResetPasswordPage - failed to clear password reset flag",
+			"client IP", c.ClientIP(),
+			"error", err,
+		)
+	}
+	username, err := repositories.GetUsernameByEmail(email)
+	if err == nil {
+		err = middlewares.RevokeAllSessions(c, username)
+	}
+	if err != nil {
+		logger.Error(
+			"ResetPasswordPage - failed to revoke sessions and remember tokens",
+			"client IP", c.ClientIP(),
+			"error", err,
+		)
+	}
 	logger.Info(
 		"ResetPasswordPage - Password has been reset",
 		"client IP", c.ClientIP(),
@@ -4,25 +4,23 @@ import (
 	"net/http"
 
 	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func ViewHomePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewHomePage - User is not logged in",
+	username := middlewares.GetCurrentUser(c).Username
+	votesData, err := repositories.GetVotesDataByUsername(username)
+	if err != nil {
+		logger.Error(
+			"ViewHomePage - failed to get votes data by username",
+			"error", err.Error(),
 			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
+			"Username", username,
 		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
 	}
 
-	username := middlewares.GetUserData(c)
-
 	logger.Info(
 		"ViewHomePage - rendering home page",
 		"Client IP", c.ClientIP(),
@@ -30,6 +28,9 @@ func ViewHomePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Home",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"organizationVotes": utils.GroupVotesByOrganization(votesData),
+		"isAdmin": middlewares.IsAdmin(c),
 	}
 	c.HTML(
 		http.StatusOK,
@@ -39,19 +40,7 @@ func ViewHomePage(c *gin.Context) {
 }
 
 func ViewAboutUsPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewHomePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	logger.Info(
 		"ViewAboutUsPage - rendering about us page",
 		"Client IP", c.ClientIP(),
@@ -59,6 +48,7 @@ func ViewAboutUsPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "About Us",
+		"csrfToken": middlewares.GetCSRFToken(c),
 	}
 	c.HTML(
 		http.StatusOK,
@@ -68,19 +58,7 @@ func ViewAboutUsPage(c *gin.Context) {
 }
 
 func ViewSupportPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewHomePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	logger.Info(
 		"ViewSupportPage - rendering support page",
 		"Client IP", c.ClientIP(),
@@ -88,6 +66,7 @@ func ViewSupportPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Support",
+		"csrfToken": middlewares.GetCSRFToken(c),
 	}
 	c.HTML(
 		http.StatusOK,
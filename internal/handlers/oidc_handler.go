@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const oidcLoginTimeout = 10 * time.Minute
+
+var oidcUsernameCleaner = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func OIDCLoginPage(c *gin.Context) {
+	provider, ok := config.GetOIDCProvider(c.Param("provider"))
+	if !ok {
+		logger.Warn(
+			"OIDCLoginPage - unknown provider",
+			"Client IP", c.ClientIP(),
+			"Provider", c.Param("provider"),
+		)
+		utils.RenderError(c, http.StatusNotFound, "Login provider not found", "/electivote/login-page/")
+		return
+	}
+
+	state, stateErr := utils.GenerateOIDCState()
+	nonce, nonceErr := utils.GenerateOIDCState()
+	verifier, verifierErr := utils.GenerateOIDCState()
+	if err := errors.Join(stateErr, nonceErr, verifierErr); err != nil {
+		logger.Error(
+			"OIDCLoginPage - failed to generate state",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/login-page/")
+		return
+	}
+
+	authURL, err := utils.OIDCAuthURL(provider, state, nonce, verifier)
+	if err != nil {
+		logger.Error(
+			"OIDCLoginPage - failed to reach provider",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Provider", provider.Name,
+		)
+		utils.RenderError(c, http.StatusBadGateway, "Login provider is unavailable", "/electivote/login-page/")
+		return
+	}
+
+	middlewares.SetOIDCSession(c, provider.Name, state, nonce, verifier)
+	logger.Info(
+		"OIDCLoginPage - redirecting to provider",
+		"Client IP", c.ClientIP(),
+		"Provider", provider.Name,
+	)
+	c.Redirect(
+		http.StatusFound,
+		authURL,
+	)
+}
+
+func OIDCCallbackPage(c *gin.Context) {
+	provider, ok := config.GetOIDCProvider(c.Param("provider"))
+	if !ok {
+		logger.Warn(
+			"OIDCCallbackPage - unknown provider",
+			"Client IP", c.ClientIP(),
+			"Provider", c.Param("provider"),
+		)
+		utils.RenderError(c, http.StatusNotFound, "Login provider not found", "/electivote/login-page/")
+		return
+	}
+
+	session := sessions.Default(c)
+	sessionProvider, _ := session.Get("oidcProvider").(string)
+	state, _ := session.Get("oidcState").(string)
+	nonce, _ := session.Get("oidcNonce").(string)
+	verifier, _ := session.Get("oidcVerifier").(string)
+	createdAt, _ := session.Get("oidcCreatedAt").(int64)
+	middlewares.DeleteOIDCSession(c)
+
+	if sessionProvider != provider.Name || state == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 ||
+		time.Since(time.Unix(createdAt, 0)) > oidcLoginTimeout {
+		middlewares.LogSecurityEvent(c, "oidc_state_mismatch", "", "Provider", provider.Name)
+		utils.RenderError(c, http.StatusBadRequest, "Login request expired, please try again", "/electivote/login-page/")
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		logger.Warn(
+			"OIDCCallbackPage - provider returned an error",
+			"Client IP", c.ClientIP(),
+			"Provider", provider.Name,
+			"error", providerErr,
+			"action", "redirecting to login page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/login-page/",
+		)
+		return
+	}
+
+	claims, err := utils.ExchangeOIDCCode(provider, c.Query("code"), verifier, nonce)
+	if err != nil {
+		middlewares.LogSecurityEvent(c, "oidc_login_failed", "", "Provider", provider.Name, "error", err.Error())
+		utils.RenderError(c, http.StatusUnauthorized, "Could not verify your login, please try again", "/electivote/login-page/")
+		return
+	}
+
+	user, err := findOrCreateOIDCUser(provider, claims, utils.GetRequestLocale(c))
+	if err != nil {
+		logger.Warn(
+			"OIDCCallbackPage - failed to resolve user",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Provider", provider.Name,
+			"Email", claims.Email,
+		)
+		utils.RenderError(c, http.StatusForbidden, err.Error(), "/electivote/login-page/")
+		return
+	}
+
+	if user.Suspended {
+		logger.Warn(
+			"OIDCCallbackPage - User is suspended",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+		)
+		utils.RenderError(c, http.StatusForbidden, "Your account has been suspended", "/electivote/login-page/")
+		return
+	}
+	if user.PasswordResetRequired {
+		logger.Warn(
+			"OIDCCallbackPage - Password reset required",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+		)
+		utils.RenderError(c, http.StatusForbidden, "A password reset is required, please check your email", "/electivote/login-page/")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		middlewares.SetTwoFactorSession(c, user.Username, false)
+		logger.Info(
+			"OIDCCallbackPage - Provider login verified, two-factor code required",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+			"Provider", provider.Name,
+			"action", "redirecting to two-factor page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/two-factor-page/",
+		)
+		return
+	}
+
+	middlewares.ResetFailedLogins(c, user.Username)
+	middlewares.SetSession(c, user.Username)
+	logger.Info(
+		"OIDCCallbackPage - User logged in",
+		"Client IP", c.ClientIP(),
+		"Username", user.Username,
+		"Provider", provider.Name,
+		"action", "redirecting to home page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/home-page/",
+	)
+}
+
+// findOrCreateOIDCUser resolves the provider identity to a local user. Known
+// identities log straight in; otherwise the user is matched by verified email
+// and linked, or a new account with a first profile is created.
+func findOrCreateOIDCUser(provider config.OIDCProvider, claims utils.OIDCClaims, locale string) (models.User, error) {
+	now := models.CustomTime{Time: time.Now()}
+	identity, err := repositories.GetUserIdentity(provider.Name, claims.Subject)
+	if err == nil {
+		err = repositories.UpdateUserIdentityLogin(identity.UserIdentityID, claims.Email, now)
+		if err != nil {
+			logger.Error(
+				"findOrCreateOIDCUser - failed to update identity",
+				"error", err.Error(),
+				"Provider", provider.Name,
+			)
+		}
+		return identity.User, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.User{}, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return models.User{}, errors.New("Your provider account has no verified email address")
+	}
+
+	user, err := repositories.GetUserByEmail(claims.Email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, err
+		}
+		user, err = createOIDCUser(claims.Email, locale)
+		if err != nil {
+			return models.User{}, err
+		}
+		logger.Info(
+			"findOrCreateOIDCUser - created user from provider login",
+			"Provider", provider.Name,
+			"Username", user.Username,
+		)
+	}
+
+	_, err = repositories.CreateUserIdentity(factories.UserIdentityFactory(user.ID, provider.Name, claims.Subject, claims.Email, now))
+	if err != nil {
+		return models.User{}, err
+	}
+	logger.Info(
+		"findOrCreateOIDCUser - linked provider identity",
+		"Provider", provider.Name,
+		"Username", user.Username,
+	)
+	return user, nil
+}
+
+func createOIDCUser(email, locale string) (models.User, error) {
+	username, err := generateOIDCUsername(email)
+	if err != nil {
+		return models.User{}, err
+	}
+	randomPassword, err := utils.GenerateRandomSecret()
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	newUser := factories.CreateUser(username, hashedPassword, email, "user")
+	newUser.Locale = locale
+	user, err := repositories.RegisterUser(newUser)
+	if err != nil {
+		return models.User{}, err
+	}
+	_, err = repositories.CreateProfile(factories.CreateFirstProfile(int(user.ID)))
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// generateOIDCUsername derives a username from the email local part, padding
+// it to the 5 character minimum and adding a numeric suffix when taken.
+func generateOIDCUsername(email string) (string, error) {
+	base := oidcUsernameCleaner.ReplaceAllString(strings.Split(email, "@")[0], "")
+	if len(base) > 50 {
+		base = base[:50]
+	}
+	for len(base) < 5 {
+		base += "_"
+	}
+	username := base
+	for i := 1; i <= 100; i++ {
+		_, err := repositories.GetUserByUsername(username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", errors.New("could not generate a unique username")
+}
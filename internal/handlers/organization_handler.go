@@ -0,0 +1,656 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ViewOrganizationsPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	var organizations, managedOrganizations []models.Organization
+	var organizationsErr, managedOrganizationsErr error
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		organizations, organizationsErr = repositories.GetOrganizationsByUsername(username)
+	}()
+
+	go func() {
+		defer wg.Done()
+		managedOrganizations, managedOrganizationsErr = repositories.GetManagedOrganizationsByUsername(username)
+	}()
+
+	wg.Wait()
+
+	if organizationsErr != nil {
+		logger.Error(
+			"ViewOrganizationsPage - failed to get organizations by username",
+			"error", organizationsErr.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			organizationsErr.Error(),
+			"/electivote/home-page/",
+		)
+		return
+	}
+
+	if managedOrganizationsErr != nil {
+		logger.Error(
+			"ViewOrganizationsPage - failed to get managed organizations by username",
+			"error", managedOrganizationsErr.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			managedOrganizationsErr.Error(),
+			"/electivote/home-page/",
+		)
+		return
+	}
+
+	managedOrganizationIDs := map[uint]bool{}
+	for _, organization := range managedOrganizations {
+		managedOrganizationIDs[organization.OrganizationID] = true
+	}
+
+	logger.Info(
+		"ViewOrganizationsPage - rendering organizations page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":                  "Organizations",
+		"csrfToken":              middlewares.GetCSRFToken(c),
+		"organizations":          organizations,
+		"managedOrganizationIDs": managedOrganizationIDs,
+	}
+	c.HTML(
+		http.StatusOK,
+		"organizations.html",
+		context,
+	)
+}
+
+func ViewCreateOrganizationPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	logger.Info(
+		"ViewCreateOrganizationPage - rendering create organization page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	context := gin.H{
+		"title":     "Create Organization",
+		"csrfToken": middlewares.GetCSRFToken(c),
+	}
+	c.HTML(
+		http.StatusOK,
+		"createOrganization.html",
+		context,
+	)
+}
+
+func CreateOrganizationPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationName := c.PostForm("organizationName")
+	organizationLogo, organizationLogoErr := c.FormFile("organizationLogo")
+	organizationNameErr := utils.ValidateOrganizationInput(organizationName, c)
+
+	if organizationNameErr != "" {
+		context := gin.H{
+			"title":               "Create Organization",
+			"csrfToken":           middlewares.GetCSRFToken(c),
+			"organizationName":    organizationName,
+			"organizationNameErr": organizationNameErr,
+		}
+		c.HTML(
+			http.StatusBadRequest,
+			"createOrganization.html",
+			context,
+		)
+		return
+	}
+
+	ownerID := int(middlewares.GetCurrentUser(c).ID)
+
+	createdTime := models.CustomTime{Time: time.Now()}
+	newOrganization := factories.OrganizationFactory(organizationName, "", uint(ownerID), createdTime)
+
+	if organizationLogo != nil {
+		if organizationLogoErr != nil {
+			logger.Error(
+				"CreateOrganizationPage - failed to get organization logo",
+				"error", organizationLogoErr.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusBadRequest,
+				organizationLogoErr.Error(),
+				"/electivote/create-organization-page/",
+			)
+			return
+		}
+		logo, err := utils.SaveUploadedImage(organizationLogo)
+		if err != nil {
+			logger.Error(
+				"CreateOrganizationPage - failed to save organization logo",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				utils.ImageUploadStatus(err),
+				err.Error(),
+				"/electivote/create-organization-page/",
+			)
+			return
+		}
+		newOrganization.OrganizationLogo = logo
+	}
+
+	organization, err := repositories.CreateOrganization(newOrganization)
+	if err != nil {
+		logger.Error(
+			"CreateOrganizationPage - failed to create organization",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/create-organization-page/",
+		)
+		return
+	}
+
+	owner := factories.OrganizationMemberFactory(organization.OrganizationID, uint(ownerID), "owner", createdTime)
+	_, err = repositories.AddOrganizationMember(owner)
+	if err != nil {
+		logger.Error(
+			"CreateOrganizationPage - failed to add organization owner",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"CreateOrganizationPage - organization created",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Organization ID", organization.OrganizationID,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(int(organization.OrganizationID)),
+	)
+}
+
+func renderManageOrganizationPage(c *gin.Context, statusCode int, organizationID uint, extra gin.H) {
+	username := middlewares.GetCurrentUser(c).Username
+	var organization models.Organization
+	var members []models.OrganizationMember
+	var voterRolls []models.VoterRoll
+	var organizationErr, membersErr, voterRollsErr error
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		organization, organizationErr = repositories.GetOrganizationByOrganizationID(organizationID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		members, membersErr = repositories.GetOrganizationMembersByOrganizationID(organizationID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		voterRolls, voterRollsErr = repositories.GetVoterRollsByOrganizationID(organizationID)
+	}()
+
+	wg.Wait()
+
+	for _, err := range []error{organizationErr, membersErr, voterRollsErr} {
+		if err != nil {
+			logger.Error(
+				"renderManageOrganizationPage - failed to get organization data",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+				"Organization ID", organizationID,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/organizations-page/",
+			)
+			return
+		}
+	}
+
+	context := gin.H{
+		"title":        "Manage Organization",
+		"csrfToken":    middlewares.GetCSRFToken(c),
+		"organization": organization,
+		"members":      members,
+		"voterRolls":   voterRolls,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"manageOrganization.html",
+		context,
+	)
+}
+
+func ViewManageOrganizationPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"ViewManageOrganizationPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewManageOrganizationPage - rendering manage organization page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Organization ID", organizationID,
+	)
+	renderManageOrganizationPage(c, http.StatusOK, uint(organizationID), nil)
+}
+
+func ManageOrganizationPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"ManageOrganizationPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	organizationName := c.PostForm("organizationName")
+	organizationLogo, organizationLogoErr := c.FormFile("organizationLogo")
+	organizationNameErr := utils.ValidateOrganizationInput(organizationName, c)
+
+	if organizationNameErr != "" {
+		renderManageOrganizationPage(c, http.StatusBadRequest, uint(organizationID), gin.H{
+			"organizationNameErr": organizationNameErr,
+		})
+		return
+	}
+
+	updatedOrganization := factories.UpdateOrganizationFactory(organizationName, "")
+	previousOrganization, _ := repositories.GetOrganizationByOrganizationID(uint(organizationID))
+
+	if organizationLogo != nil {
+		if organizationLogoErr != nil {
+			logger.Error(
+				"ManageOrganizationPage - failed to get organization logo",
+				"error", organizationLogoErr.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusBadRequest,
+				organizationLogoErr.Error(),
+				"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+			)
+			return
+		}
+		logo, err := utils.SaveUploadedImage(organizationLogo)
+		if err != nil {
+			logger.Error(
+				"ManageOrganizationPage - failed to save organization logo",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				utils.ImageUploadStatus(err),
+				err.Error(),
+				"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+			)
+			return
+		}
+		updatedOrganization.OrganizationLogo = logo
+	}
+
+	_, err := repositories.UpdateOrganization(uint(organizationID), updatedOrganization)
+	if err != nil {
+		logger.Error(
+			"ManageOrganizationPage - failed to update organization",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+	if updatedOrganization.OrganizationLogo != "" && updatedOrganization.OrganizationLogo != previousOrganization.OrganizationLogo {
+		utils.RemoveUnusedImages(previousOrganization.OrganizationLogo)
+	}
+
+	err = repositories.SetOrganizationRequireTwoFactor(uint(organizationID), c.PostForm("requireTwoFactor") == "on")
+	if err != nil {
+		logger.Error(
+			"ManageOrganizationPage - failed to update two-factor policy",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"ManageOrganizationPage - organization updated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Organization ID", organizationID,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+	)
+}
+
+func AddOrganizationMemberPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"AddOrganizationMemberPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	memberUsername := c.PostForm("memberUsername")
+	memberRole := c.PostForm("memberRole")
+	memberErr := ""
+
+	if !utils.IsValidOrganizationRole(memberRole) {
+		logger.Warn(
+			"AddOrganizationMemberPage - invalid member role",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member Role Inputted", memberRole,
+		)
+		memberErr = "Member role must be admin or member"
+	}
+
+	memberUser, err := repositories.GetUserByUsername(memberUsername)
+	if err != nil {
+		logger.Warn(
+			"AddOrganizationMemberPage - member username not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member Username Inputted", memberUsername,
+		)
+		memberErr = "Username not found"
+	}
+
+	if memberErr == "" && repositories.IsOrganizationMember(memberUser.ID, uint(organizationID)) {
+		logger.Warn(
+			"AddOrganizationMemberPage - user is already a member",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member Username Inputted", memberUsername,
+		)
+		memberErr = "User is already a member of this organization"
+	}
+
+	if memberErr != "" {
+		renderManageOrganizationPage(c, http.StatusBadRequest, uint(organizationID), gin.H{
+			"memberErr":      memberErr,
+			"memberUsername": memberUsername,
+		})
+		return
+	}
+
+	joinedTime := models.CustomTime{Time: time.Now()}
+	newMember := factories.OrganizationMemberFactory(uint(organizationID), memberUser.ID, memberRole, joinedTime)
+	_, err = repositories.AddOrganizationMember(newMember)
+	if err != nil {
+		logger.Error(
+			"AddOrganizationMemberPage - failed to add organization member",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"AddOrganizationMemberPage - organization member added",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Member Username", memberUsername,
+		"Organization ID", organizationID,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+	)
+}
+
+func UpdateOrganizationMemberPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	memberID, _ := strconv.Atoi(c.Param("memberID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"UpdateOrganizationMemberPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	member, err := repositories.GetOrganizationMemberByMemberID(uint(memberID))
+	if err != nil || member.OrganizationId != uint(organizationID) || member.MemberRole == "owner" {
+		logger.Warn(
+			"UpdateOrganizationMemberPage - member cannot be updated",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member ID", memberID,
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	memberRole := c.PostForm("memberRole")
+	if !utils.IsValidOrganizationRole(memberRole) {
+		logger.Warn(
+			"UpdateOrganizationMemberPage - invalid member role",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member Role Inputted", memberRole,
+		)
+		renderManageOrganizationPage(c, http.StatusBadRequest, uint(organizationID), gin.H{
+			"memberErr": "Member role must be admin or member",
+		})
+		return
+	}
+
+	err = repositories.UpdateOrganizationMemberRole(uint(memberID), memberRole)
+	if err != nil {
+		logger.Error(
+			"UpdateOrganizationMemberPage - failed to update member role",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"UpdateOrganizationMemberPage - member role updated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Member ID", memberID,
+		"Member Role", memberRole,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+	)
+}
+
+func RemoveOrganizationMemberPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	memberID, _ := strconv.Atoi(c.Param("memberID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"RemoveOrganizationMemberPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	member, err := repositories.GetOrganizationMemberByMemberID(uint(memberID))
+	if err != nil || member.OrganizationId != uint(organizationID) || member.MemberRole == "owner" {
+		logger.Warn(
+			"RemoveOrganizationMemberPage - member cannot be removed",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Member ID", memberID,
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	err = repositories.DeleteOrganizationMember(uint(memberID))
+	if err != nil {
+		logger.Error(
+			"RemoveOrganizationMemberPage - failed to remove member",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"RemoveOrganizationMemberPage - member removed",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Member ID", memberID,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+	)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// personalAccessTokenPrefixLength keeps "ev_pat_" plus a few characters of
+// the secret so users can tell their tokens apart on the profile page.
+const personalAccessTokenPrefixLength = 11
+
+func CreatePersonalAccessTokenPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	name := strings.TrimSpace(c.PostForm("name"))
+	expiresInDays, _ := strconv.Atoi(c.PostForm("expiresInDays"))
+
+	scopes := []string{}
+	for _, scope := range c.PostFormArray("scopes") {
+		if utils.IsValidAPIScope(scope) && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var validationErr string
+	switch {
+	case len(name) < 3 || len(name) > 100:
+		validationErr = "Token name must be between 3 and 100 characters"
+	case len(scopes) == 0:
+		validationErr = "Select at least one scope for the token"
+	case !slices.Contains(utils.PersonalAccessTokenExpiryDays, expiresInDays):
+		validationErr = "Invalid token expiry"
+	}
+	if validationErr != "" {
+		logger.Warn(
+			"CreatePersonalAccessTokenPage - invalid input",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"error", validationErr,
+		)
+		utils.RenderError(c, http.StatusBadRequest, validationErr, "/electivote/profile-page/")
+		return
+	}
+
+	token, tokenHash, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		logger.Error(
+			"CreatePersonalAccessTokenPage - failed to generate token",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+
+	now := time.Now()
+	personalAccessToken, err := repositories.CreatePersonalAccessToken(factories.PersonalAccessTokenFactory(
+		currentUser.ID,
+		name,
+		token[:personalAccessTokenPrefixLength],
+		tokenHash,
+		scopes,
+		models.CustomTime{Time: now},
+		models.CustomTime{Time: now.AddDate(0, 0, expiresInDays)},
+	))
+	if err != nil {
+		logger.Error(
+			"CreatePersonalAccessTokenPage - failed to create token",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/profile-page/")
+		return
+	}
+
+	middlewares.SetNewAPITokenFlash(c, token)
+	middlewares.LogSecurityEvent(
+		c, "api_token_created", currentUser.Username,
+		"Personal Access Token ID", personalAccessToken.PersonalAccessTokenID,
+		"Scopes", personalAccessToken.Scopes,
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+func RevokePersonalAccessTokenPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	personalAccessTokenID, _ := strconv.Atoi(c.Param("personalAccessTokenID"))
+	revoked, err := repositories.DeletePersonalAccessToken(currentUser.ID, uint(personalAccessTokenID))
+	if err != nil {
+		logger.Error(
+			"RevokePersonalAccessTokenPage - failed to delete token",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/profile-page/",
+		)
+		return
+	}
+	if revoked {
+		middlewares.LogSecurityEvent(
+			c, "api_token_revoked", currentUser.Username,
+			"Personal Access Token ID", personalAccessTokenID,
+		)
+	}
+
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
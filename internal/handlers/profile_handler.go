@@ -15,20 +15,7 @@ import (
 )
 
 func ViewProfilePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewProfilePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	var userProfile models.Profile
 	var userEmail string
 	var err, profileErr, emailErr error
@@ -89,6 +76,40 @@ func ViewProfilePage(c *gin.Context) {
 		return
 	}
 
+	rememberTokens, err := repositories.GetRememberTokensByUserID(middlewares.GetCurrentUser(c).ID)
+	if err != nil {
+		logger.Error(
+			"ViewProfilePage - failed to get remember tokens",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/home-page/",
+		)
+		return
+	}
+
+	personalAccessTokens, err := repositories.GetPersonalAccessTokensByUserID(middlewares.GetCurrentUser(c).ID)
+	if err != nil {
+		logger.Error(
+			"ViewProfilePage - failed to get personal access tokens",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/home-page/",
+		)
+		return
+	}
+
 	logger.Info(
 		"ViewProfilePage - rendering profile page",
 		"Client IP", c.ClientIP(),
@@ -96,11 +117,21 @@ func ViewProfilePage(c *gin.Context) {
 	)
 	formattedDob := utils.FormattedDob(userProfile.Birthday)
 	context := gin.H{
-		"title":       "Edit Profile",
-		"username":    username,
-		"userProfile": userProfile,
-		"userEmail":   userEmail,
-		"birthday":    formattedDob,
+		"title":                 "Edit Profile",
+		"csrfToken":             middlewares.GetCSRFToken(c),
+		"username":              username,
+		"userProfile":           userProfile,
+		"userEmail":             userEmail,
+		"birthday":              formattedDob,
+		"activeSessions":        middlewares.CountActiveSessions(c, username),
+		"rememberTokens":        rememberTokens,
+		"personalAccessTokens":  personalAccessTokens,
+		"newAPIToken":           middlewares.PopNewAPITokenFlash(c),
+		"apiScopes":             utils.APIScopes,
+		"apiTokenExpiryDays":    utils.PersonalAccessTokenExpiryDays,
+		"twoFactorEnabled":      middlewares.GetCurrentUser(c).TwoFactorEnabled,
+		"deletionScheduledTime": middlewares.GetCurrentUser(c).DeletionScheduledTime,
+		"locale":                utils.NormalizeLocale(middlewares.GetCurrentUser(c).Locale),
 	}
 
 	c.HTML(
@@ -111,20 +142,7 @@ func ViewProfilePage(c *gin.Context) {
 }
 
 func ViewEditProfilePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewEditProfilePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	var userProfile models.Profile
 	var userEmail string
 	var err, profileErr, emailErr error
@@ -193,6 +211,7 @@ func ViewEditProfilePage(c *gin.Context) {
 	formattedDob := utils.FormattedDob(userProfile.Birthday)
 	context := gin.H{
 		"title":       "Edit Profile",
+		"csrfToken":   middlewares.GetCSRFToken(c),
 		"username":    username,
 		"userProfile": userProfile,
 		"userEmail":   userEmail,
@@ -207,19 +226,7 @@ func ViewEditProfilePage(c *gin.Context) {
 }
 
 func EditProfilePage(c *gin.Context) {
-    if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"EditProfilePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-        c.Redirect(
-            http.StatusFound,
-            "/electivote/login-page/",
-        )
-        return
-    }
-    username := middlewares.GetUserData(c)
+    username := middlewares.GetCurrentUser(c).Username
     var firstNameErr, lastNameErr, phoneErr, ageErr, dobErr string
     var finalDOB models.NullTime
 	var userProfile models.Profile
@@ -322,8 +329,7 @@ func EditProfilePage(c *gin.Context) {
 					"/electivote/edit-profile-page/",
 				)
 			}
-			newProfile.Picture = file.Filename
-			err = c.SaveUploadedFile(file, "internal/assets/images/"+file.Filename)
+			newProfile.Picture, err = utils.SaveUploadedImage(file)
 			if err != nil {
 				logger.Error(
 					"EditProfilePage - failed to save file",
@@ -333,7 +339,7 @@ func EditProfilePage(c *gin.Context) {
 				)
 				utils.RenderError(
 					c,
-					http.StatusInternalServerError,
+					utils.ImageUploadStatus(err),
 					err.Error(),
 					"/electivote/edit-profile-page/",
 				)
@@ -355,6 +361,9 @@ func EditProfilePage(c *gin.Context) {
 				)
 				return
 			}
+			if newProfile.Picture != userProfile.Picture {
+				utils.RemoveUnusedImages(userProfile.Picture)
+			}
 			logger.Info(
 				"EditProfilePage - profile updated",
 				"Client IP", c.ClientIP(),
@@ -412,4 +421,58 @@ func EditProfilePage(c *gin.Context) {
         "editProfile.html",
         context,
     )
-}
\ No newline at end of file
+}
+
+func RevokeRememberTokenPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	rememberTokenID, _ := strconv.Atoi(c.Param("rememberTokenID"))
+	tokens, err := repositories.GetRememberTokensByUserID(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"RevokeRememberTokenPage - failed to get remember tokens",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/profile-page/",
+		)
+		return
+	}
+
+	for _, token := range tokens {
+		if token.RememberTokenID != uint(rememberTokenID) {
+			continue
+		}
+		err = repositories.DeleteRememberToken(token.RememberTokenID)
+		if err != nil {
+			logger.Error(
+				"RevokeRememberTokenPage - failed to delete remember token",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", currentUser.Username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/profile-page/",
+			)
+			return
+		}
+		logger.Info(
+			"RevokeRememberTokenPage - remember token revoked",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"Remember Token ID", rememberTokenID,
+		)
+	}
+
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"os"
 	"time"
 	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
 	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
@@ -13,29 +12,15 @@ import (
 )
 
 func ViewRegisterPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ViewRegisterPage - User is already logged in",
-			"Username", username,
-			"Client IP", c.ClientIP(),
-			"Action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	logger.Info(
 		"ViewRegisterPage - rendering register page",
 		"Client IP", c.ClientIP(),
 	)
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
+	captcha := utils.NewCaptchaWidget()
 	context := gin.H {
 		"title": "Register",
-		"siteKey": siteKey,
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"captcha": captcha,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -45,32 +30,15 @@ func ViewRegisterPage(c *gin.Context) {
 }
 
 func RegisterPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"RegisterPage - User is already logged in",
-			"Username", username,
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	username := c.PostForm("username")
 	password := c.PostForm("password")
 	password2 := c.PostForm("password2")
 	email := c.PostForm("email")
-	siteKey := os.Getenv("RECAPTCHA_SITE_KEY")
+	captcha := utils.NewCaptchaWidget()
 
 	usernameErr, passwordErr, password2Err, emailErr := utils.ValidateRegisterInput(username, password, password2, email, c)
 	captchaErr := ""
 
-	emailDomain := utils.GetEmailDomain(email)
-	emailProvider := utils.GetEmailProvider(emailDomain)
 	otp, err := utils.GenerateOTP()
 	if err != nil {
 		logger.Error(
@@ -81,12 +49,12 @@ func RegisterPage(c *gin.Context) {
 		emailErr = "Failed to generate OTP"
 	}
 
-	if !utils.IsValidReCAPTCHA(c) {
+	if !utils.IsValidCaptcha(c) {
 		logger.Warn(
-			"RegisterPage - Invalid ReCAPTCHA",
+			"RegisterPage - Invalid CAPTCHA",
 			"clientIP", c.ClientIP(),
 		)
-		captchaErr = "Invalid ReCAPTCHA"
+		captchaErr = "Invalid CAPTCHA"
 	}
 
 	if usernameErr == "" && passwordErr == "" && password2Err == "" && emailErr == "" && captchaErr == "" {
@@ -101,52 +69,7 @@ func RegisterPage(c *gin.Context) {
 			return
 		}
 
-		subject := "ElectiVote Email Verification"
-		body := `
-		<html>
-		<head>
-			<style>
-				.container {
-					font-family: Arial, sans-serif;
-					max-width: 600px;
-					margin: auto;
-					padding: 20px;
-					border: 1px solid #ddd;
-					border-radius: 10px;
-					box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);
-				}
-				.otp-code {
-					font-size: 24px;
-					font-weight: bold;
-					color: #000000;
-				}
-				.note {
-					font-size: 14px;
-					color: #555555;
-				}
-			</style>
-		</head>
-		<body>
-			<div class="container">
-				<p>Hello,</p>
-				<p>Your OTP Code is: <span class="otp-code">` + otp + `</span></p>
-				<p class="note">Please use this code to verify your email address. <strong>Note:</strong> The OTP is valid for <strong>5 minutes</strong> from the time it was generated.</p>
-				<p>If you did not request this verification, please ignore this email.</p>
-				<p>Thank you!</p>
-			</div>
-		</body>
-		</html>
-		`
-		go func() {
-			err = utils.SendEmail(email, emailProvider, body, subject)
-			if err != nil {
-				logger.Error(
-					"RegisterPage - failed to send email",
-					"Client IP", c.ClientIP(),
-					"error", err.Error(),
-				)
-			}
-		}()
+		sendOTPEmail(c, email, otp, utils.GetRequestLocale(c))
 		logger.Info(
 			"RegisterPage - email sent",
 			"Client IP", c.ClientIP(),
@@ -160,16 +83,17 @@ func RegisterPage(c *gin.Context) {
 		return
 	}
 	context := gin.H{
-		"title":       "Register",
-		"usernameErr": usernameErr,
-		"passwordErr": passwordErr,
+		"title":        "Register",
+		"csrfToken":    middlewares.GetCSRFToken(c),
+		"usernameErr":  usernameErr,
+		"passwordErr":  passwordErr,
 		"password2Err": password2Err,
-		"emailErr":    emailErr,
-		"captchaErr":  captchaErr,
-		"username":    username,
-		"password":    password,
-		"password2":   password2,
-		"siteKey":     siteKey,
+		"emailErr":     emailErr,
+		"captchaErr":   captchaErr,
+		"username":     username,
+		"password":     password,
+		"password2":    password2,
+		"captcha":      captcha,
 		"email":       email,	
 	}
 	c.HTML(
@@ -179,8 +103,22 @@ func RegisterPage(c *gin.Context) {
 	)
 }
 
+func sendOTPEmail(c *gin.Context, email, otp, locale string) {
+	err := utils.QueueTemplateEmail(email, locale, "otp", map[string]interface{}{
+		"otp": otp,
+	})
+	if err != nil {
+		logger.Error(
+			"sendOTPEmail - failed to queue email",
+			"Client IP", c.ClientIP(),
+			"error", err.Error(),
+		)
+	}
+}
+
 func registerUser(c *gin.Context, username string, password string, email string) {
 	newUser := factories.CreateUser(username, password, email, "user")
+	newUser.Locale = utils.GetRequestLocale(c)
 
 	_, err := repositories.RegisterUser(newUser)
 	if err != nil {
@@ -218,21 +156,6 @@ func registerUser(c *gin.Context, username string, password string, email string
 }
 
 func ViewVerifyEmailPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"ViewVerifyEmailPage - User is already logged in",
-			"Username", username,
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
-
 	session := sessions.Default(c)
 	otp := session.Get("otp")
 
@@ -255,6 +178,7 @@ func ViewVerifyEmailPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Verify Email",
+		"csrfToken": middlewares.GetCSRFToken(c),
 	}
 	c.HTML(
 		http.StatusOK,
@@ -264,20 +188,6 @@ func ViewVerifyEmailPage(c *gin.Context) {
 }
 
 func VerifyEmailPage(c *gin.Context) {
-	if middlewares.IsLogged(c) {
-		username := middlewares.GetUserData(c)
-		logger.Warn(
-			"VerifyEmailPage - User is already logged in",
-			"Username", username,
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to home page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/home-page/",
-		)
-		return
-	}
 	session := sessions.Default(c)
 	username := session.Get("username")
 	email := session.Get("email")
@@ -340,8 +250,9 @@ func VerifyEmailPage(c *gin.Context) {
 
 	if otpErr != "" {
 		context := gin.H{
-			"title": "Verify Email",
-			"otpErr": otpErr,
+			"title":     "Verify Email",
+			"csrfToken": middlewares.GetCSRFToken(c),
+			"otpErr":    otpErr,
 		}
 		c.HTML(
 			http.StatusOK,
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ExportVoteResultPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
+		logger.Warn(
+			"ExportVoteResultPage - User is not a valid vote moderator",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to home page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/home-page/",
+		)
+		return
+	}
+
+	voteData, err := repositories.GetVoteDataByVoteID(uint(voteID))
+	if err != nil {
+		logger.Error(
+			"ExportVoteResultPage - failed to get vote data by vote ID",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-vote-page/",
+		)
+		return
+	}
+
+	export, err := utils.BuildVoteResultsExport(voteData)
+	if err != nil {
+		logger.Error(
+			"ExportVoteResultPage - failed to build results export",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			fmt.Sprintf("/electivote/vote-result-page/%d/", voteID),
+		)
+		return
+	}
+	utils.SignResultsExport(&export)
+	writeResultsExport(c, export, "ExportVoteResultPage", fmt.Sprintf("/electivote/vote-result-page/%d/", voteID))
+}
+
+func ExportVoteHistoryPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	voteHistoryID, _ := strconv.Atoi(c.Param("voteHistoryID"))
+	voteHistory, err := repositories.GetVoteHistoryByVoteHistoryID(uint(voteHistoryID))
+	if err != nil || voteHistory.ModeratorID != currentUser.ID {
+		logger.Warn(
+			"ExportVoteHistoryPage - User does not own the vote history",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"action", "redirecting to vote history page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/vote-history-page/",
+		)
+		return
+	}
+
+	export, err := utils.GetVoteHistoryResultsExport(voteHistory)
+	if err != nil {
+		logger.Error(
+			"ExportVoteHistoryPage - failed to load results snapshot",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/vote-history-page/",
+		)
+		return
+	}
+	writeResultsExport(c, export, "ExportVoteHistoryPage", fmt.Sprintf("/electivote/vote-history-page/%d/", voteHistoryID))
+}
+
+func VerifyResultsPage(c *gin.Context) {
+	voteHistoryID, _ := strconv.Atoi(c.Param("voteHistoryID"))
+	signature := c.Param("signature")
+	isValid := false
+	context := gin.H{
+		"title":     "Verify Results",
+		"csrfToken": middlewares.GetCSRFToken(c),
+	}
+
+	voteHistory, err := repositories.GetVoteHistoryByVoteHistoryID(uint(voteHistoryID))
+	if err == nil {
+		export, err := utils.GetVoteHistoryResultsExport(voteHistory)
+		if err == nil && utils.IsValidResultsSignature(export, signature) {
+			isValid = true
+			context["results"] = export
+		}
+	}
+	context["isValid"] = isValid
+
+	logger.Info(
+		"VerifyResultsPage - rendering results verification page",
+		"Client IP", c.ClientIP(),
+		"Vote History ID", voteHistoryID,
+		"Valid", isValid,
+	)
+	c.HTML(
+		http.StatusOK,
+		"resultsVerification.html",
+		context,
+	)
+}
+
+func writeResultsExport(c *gin.Context, export utils.ResultsExport, source, backURL string) {
+	username := middlewares.GetCurrentUser(c).Username
+	format := c.DefaultQuery("format", "csv")
+
+	var body []byte
+	var contentType string
+	switch format {
+	case "csv":
+		var buffer bytes.Buffer
+		err := utils.WriteResultsCSV(&buffer, export)
+		if err != nil {
+			logger.Error(
+				source+" - failed to write results CSV",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				backURL,
+			)
+			return
+		}
+		body = buffer.Bytes()
+		contentType = "text/csv; charset=utf-8"
+	case "json":
+		encoded, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			logger.Error(
+				source+" - failed to marshal results JSON",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				backURL,
+			)
+			return
+		}
+		body = encoded
+		contentType = "application/json; charset=utf-8"
+	case "pdf":
+		body = utils.RenderResultsPDF(export)
+		contentType = "application/pdf"
+	default:
+		logger.Warn(
+			source+" - unsupported export format",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Format", format,
+		)
+		utils.RenderError(
+			c,
+			http.StatusBadRequest,
+			"Unsupported export format",
+			backURL,
+		)
+		return
+	}
+
+	logger.Info(
+		source+" - exporting vote results",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Format", format,
+		"Status", export.Status,
+	)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", utils.ResultsExportFilename(export, format)))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, body)
+}
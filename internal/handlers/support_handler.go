@@ -8,9 +8,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
+	"github.com/AndreanDjabbar/ElectiVote/config"
 	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
 	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
 	"github.com/AndreanDjabbar/ElectiVote/internal/models"
@@ -25,30 +25,13 @@ var ctx = context.Background()
 var saweriaSecretKey = os.Getenv("SAWERIA_SECRET_KEY")
 
 func initRedis() {
-    redisAddr := os.Getenv("REDIS_URL")
-    if strings.HasPrefix(redisAddr, "redis://") {
-        redisAddr = strings.TrimPrefix(redisAddr, "redis://")
-    }
-    redisClient = redis.NewClient(&redis.Options{
-        Addr: redisAddr,
-        Password: "", 
-        DB: 0,
-    })
+    redisClient = config.GetRedisClient()
 }
 
 func ViewSaweriaPage(c *gin.Context) {
     initRedis()
-    if !middlewares.IsLogged(c) {
-        logger.Warn(
-            "ViewSaweriaPage - User is not logged in",
-            "Client IP", c.ClientIP(),
-            "action", "redirecting to login page",
-        )
-        c.Redirect(http.StatusFound, "/electivote/login-page/")
-        return
-    }
 
-    username := middlewares.GetUserData(c)
+    username := middlewares.GetCurrentUser(c).Username
 
     status, transactionID := CheckDonationStatus()
     if status {
@@ -78,7 +61,8 @@ func ViewSaweriaPage(c *gin.Context) {
         http.StatusOK,
         "saweriaPage.html",
         gin.H{
-            "title": "Saweria",
+            "title":     "Saweria",
+            "csrfToken": middlewares.GetCSRFToken(c),
         },
     )
 }
@@ -187,15 +171,6 @@ func CheckDonationStatus() (bool, string) {
 
 
 func ViewThanksPage(c *gin.Context) {
-    if !middlewares.IsLogged(c) {
-        logger.Warn(
-            "ThanksPage - User is not logged in",
-            "Client IP", c.ClientIP(),
-            "action", "redirecting to login page",
-        )
-        c.Redirect(http.StatusFound, "/electivote/login-page/")
-        return
-    }
     transactionID := c.Query("transaction_id")
     if !IsValidTransaction(transactionID) {
         c.Redirect(
@@ -206,7 +181,8 @@ func ViewThanksPage(c *gin.Context) {
     }
 
     context := gin.H{
-        "title": "Thanks",
+        "title":     "Thanks",
+        "csrfToken": middlewares.GetCSRFToken(c),
     }
     c.HTML(
         http.StatusOK,
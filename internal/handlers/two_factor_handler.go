@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	twoFactorLoginTimeout     = 5 * time.Minute
+	twoFactorLoginMaxAttempts = 5
+)
+
+func renderTwoFactorSetupPage(c *gin.Context, statusCode int, extra gin.H) {
+	currentUser := middlewares.GetCurrentUser(c)
+	context := gin.H{
+		"title":             "Two-Factor Authentication",
+		"csrfToken":         middlewares.GetCSRFToken(c),
+		"twoFactorEnabled":  currentUser.TwoFactorEnabled,
+		"twoFactorRequired": middlewares.IsTwoFactorRequired(currentUser),
+	}
+	if currentUser.TwoFactorEnabled {
+		unusedRecoveryCodes, err := repositories.CountUnusedRecoveryCodes(currentUser.ID)
+		if err != nil {
+			logger.Error(
+				"renderTwoFactorSetupPage - failed to count recovery codes",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", currentUser.Username,
+			)
+		}
+		context["unusedRecoveryCodes"] = unusedRecoveryCodes
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"twoFactorSetup.html",
+		context,
+	)
+}
+
+func ViewTwoFactorSetupPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if currentUser.TwoFactorEnabled {
+		logger.Info(
+			"ViewTwoFactorSetupPage - rendering two-factor status page",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderTwoFactorSetupPage(c, http.StatusOK, nil)
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		logger.Error(
+			"ViewTwoFactorSetupPage - failed to generate TOTP secret",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/profile-page/",
+		)
+		return
+	}
+	middlewares.SetPendingTwoFactorSecret(c, secret)
+
+	logger.Info(
+		"ViewTwoFactorSetupPage - rendering two-factor enrollment page",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+	)
+	renderTwoFactorSetupPage(c, http.StatusOK, gin.H{
+		"secret":          secret,
+		"provisioningURI": utils.TOTPProvisioningURI(currentUser.Username, secret),
+	})
+}
+
+func TwoFactorSetupPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	session := sessions.Default(c)
+	secret, ok := session.Get("pendingTwoFactorSecret").(string)
+	if currentUser.TwoFactorEnabled || !ok || secret == "" {
+		logger.Warn(
+			"TwoFactorSetupPage - no pending two-factor enrollment",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"action", "redirecting to two-factor setup page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/two-factor-setup-page/",
+		)
+		return
+	}
+
+	code := c.PostForm("code")
+	if !utils.ValidateTOTPCode(currentUser.ID, secret, code) {
+		logger.Warn(
+			"TwoFactorSetupPage - invalid TOTP code",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderTwoFactorSetupPage(c, http.StatusBadRequest, gin.H{
+			"secret":          secret,
+			"provisioningURI": utils.TOTPProvisioningURI(currentUser.Username, secret),
+			"codeErr":         "Invalid code, please try again",
+		})
+		return
+	}
+
+	err := repositories.EnableTwoFactor(currentUser.ID, secret)
+	if err != nil {
+		logger.Error(
+			"TwoFactorSetupPage - failed to enable two-factor authentication",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/two-factor-setup-page/",
+		)
+		return
+	}
+	middlewares.DeletePendingTwoFactorSecret(c)
+	middlewares.RegenerateSession(c)
+
+	recoveryCodes, err := issueRecoveryCodes(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"TwoFactorSetupPage - failed to issue recovery codes",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/two-factor-setup-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"TwoFactorSetupPage - two-factor authentication enabled",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+	)
+	c.HTML(
+		http.StatusOK,
+		"twoFactorSetup.html",
+		gin.H{
+			"title":            "Two-Factor Authentication",
+			"csrfToken":        middlewares.GetCSRFToken(c),
+			"twoFactorEnabled": true,
+			"recoveryCodes":    recoveryCodes,
+		},
+	)
+}
+
+func issueRecoveryCodes(userID uint) ([]string, error) {
+	recoveryCodes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashedCodes := []models.RecoveryCode{}
+	for _, recoveryCode := range recoveryCodes {
+		hashedCodes = append(hashedCodes, factories.RecoveryCodeFactory(userID, utils.HashRecoveryCode(recoveryCode)))
+	}
+	err = repositories.ReplaceRecoveryCodes(userID, hashedCodes)
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+func RegenerateRecoveryCodesPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if !currentUser.TwoFactorEnabled || !utils.ValidateTOTPCode(currentUser.ID, currentUser.TwoFactorSecret, c.PostForm("code")) {
+		logger.Warn(
+			"RegenerateRecoveryCodesPage - invalid TOTP code",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderTwoFactorSetupPage(c, http.StatusBadRequest, gin.H{
+			"manageCodeErr": "Invalid code, please try again",
+		})
+		return
+	}
+
+	recoveryCodes, err := issueRecoveryCodes(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"RegenerateRecoveryCodesPage - failed to issue recovery codes",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/two-factor-setup-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"RegenerateRecoveryCodesPage - recovery codes regenerated",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+	)
+	renderTwoFactorSetupPage(c, http.StatusOK, gin.H{
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func DisableTwoFactorPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if middlewares.IsTwoFactorRequired(currentUser) {
+		logger.Warn(
+			"DisableTwoFactorPage - two-factor authentication is required by policy",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderTwoFactorSetupPage(c, http.StatusForbidden, gin.H{
+			"manageCodeErr": "Two-factor authentication is required for your account",
+		})
+		return
+	}
+
+	if !currentUser.TwoFactorEnabled || !utils.ValidateTOTPCode(currentUser.ID, currentUser.TwoFactorSecret, c.PostForm("code")) {
+		logger.Warn(
+			"DisableTwoFactorPage - invalid TOTP code",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderTwoFactorSetupPage(c, http.StatusBadRequest, gin.H{
+			"manageCodeErr": "Invalid code, please try again",
+		})
+		return
+	}
+
+	err := repositories.DisableTwoFactor(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"DisableTwoFactorPage - failed to disable two-factor authentication",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/two-factor-setup-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"DisableTwoFactorPage - two-factor authentication disabled",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"action", "redirecting to profile page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/profile-page/",
+	)
+}
+
+// getPendingTwoFactorLogin returns the username whose password was already
+// verified by LoginPage, or "" once the pending step is missing or stale.
+func getPendingTwoFactorLogin(c *gin.Context) (string, bool) {
+	session := sessions.Default(c)
+	username, ok := session.Get("twoFactorUsername").(string)
+	if !ok || username == "" {
+		return "", false
+	}
+	createdAt, _ := session.Get("twoFactorCreatedAt").(int64)
+	if time.Since(time.Unix(createdAt, 0)) > twoFactorLoginTimeout {
+		middlewares.DeleteTwoFactorSession(c)
+		return "", false
+	}
+	remember, _ := session.Get("twoFactorRemember").(bool)
+	return username, remember
+}
+
+func ViewTwoFactorLoginPage(c *gin.Context) {
+	username, _ := getPendingTwoFactorLogin(c)
+	if username == "" {
+		logger.Warn(
+			"ViewTwoFactorLoginPage - no pending two-factor login",
+			"Client IP", c.ClientIP(),
+			"action", "redirecting to login page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/login-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"ViewTwoFactorLoginPage - rendering two-factor login page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+	)
+	c.HTML(
+		http.StatusOK,
+		"twoFactorLogin.html",
+		gin.H{
+			"title":     "Two-Factor Authentication",
+			"csrfToken": middlewares.GetCSRFToken(c),
+		},
+	)
+}
+
+func TwoFactorLoginPage(c *gin.Context) {
+	username, remember := getPendingTwoFactorLogin(c)
+	if username == "" {
+		logger.Warn(
+			"TwoFactorLoginPage - no pending two-factor login",
+			"Client IP", c.ClientIP(),
+			"action", "redirecting to login page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/login-page/",
+		)
+		return
+	}
+
+	if wait := middlewares.GetLoginWait(c, username); wait > 0 {
+		middlewares.LogSecurityEvent(c, "login_throttled", username, "Retry After", wait.String())
+		c.HTML(
+			http.StatusTooManyRequests,
+			"twoFactorLogin.html",
+			gin.H{
+				"title":     "Two-Factor Authentication",
+				"csrfToken": middlewares.GetCSRFToken(c),
+				"codeErr":   fmt.Sprintf("Too many failed attempts, please try again in %d seconds", int(wait.Seconds())+1),
+			},
+		)
+		return
+	}
+
+	user, err := repositories.GetUserByUsername(username)
+	if err != nil || !user.TwoFactorEnabled {
+		logger.Warn(
+			"TwoFactorLoginPage - user not found or two-factor disabled",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to login page",
+		)
+		middlewares.DeleteTwoFactorSession(c)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/login-page/",
+		)
+		return
+	}
+
+	code := strings.TrimSpace(c.PostForm("code"))
+	valid := utils.ValidateTOTPCode(user.ID, user.TwoFactorSecret, code)
+	usedRecoveryCode := false
+	if !valid && strings.Contains(code, "-") {
+		valid, err = repositories.UseRecoveryCode(user.ID, utils.HashRecoveryCode(code))
+		if err != nil {
+			logger.Error(
+				"TwoFactorLoginPage - failed to check recovery code",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+		}
+		usedRecoveryCode = valid
+	}
+
+	if !valid {
+		middlewares.RecordFailedLogin(c, username, "wrong two-factor code")
+		attempts := middlewares.IncrementTwoFactorAttempts(c)
+		logger.Warn(
+			"TwoFactorLoginPage - invalid two-factor code",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Attempts", attempts,
+		)
+		if attempts >= twoFactorLoginMaxAttempts {
+			middlewares.DeleteTwoFactorSession(c)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/login-page/",
+			)
+			return
+		}
+		c.HTML(
+			http.StatusBadRequest,
+			"twoFactorLogin.html",
+			gin.H{
+				"title":     "Two-Factor Authentication",
+				"csrfToken": middlewares.GetCSRFToken(c),
+				"codeErr":   "Invalid code, please try again",
+			},
+		)
+		return
+	}
+
+	middlewares.DeleteTwoFactorSession(c)
+	middlewares.ResetFailedLogins(c, username)
+	if remember {
+		middlewares.SetCookies(c, username)
+	} else {
+		middlewares.SetSession(c, username)
+	}
+	logger.Info(
+		"TwoFactorLoginPage - User logged in",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Used Recovery Code", usedRecoveryCode,
+		"action", "redirecting to home page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/home-page/",
+	)
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"sync"
@@ -16,19 +18,23 @@ import (
 )
 
 func ViewCreateVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewCreateVotePage - User is not logged in",
+	username := middlewares.GetCurrentUser(c).Username
+	organizations, voterRolls, err := getManagedOrganizationsData(username)
+	if err != nil {
+		logger.Error(
+			"ViewCreateVotePage - failed to get managed organizations",
+			"error", err.Error(),
 			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
+			"Username", username,
 		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/home-page/",
 		)
 		return
 	}
-	username := middlewares.GetUserData(c)
 	logger.Info(
 		"ViewCreateVotePage - rendering create vote page",
 		"Client IP", c.ClientIP(),
@@ -36,6 +42,9 @@ func ViewCreateVotePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Create Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"organizations": organizations,
+		"voterRolls": voterRolls,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -45,54 +54,61 @@ func ViewCreateVotePage(c *gin.Context) {
 }
 
 func CreateVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"CreateVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
 	voteTitleErr := ""
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteTitle := c.PostForm("voteTitle")
 	voteDesc := c.PostForm("voteDesc")
 	voteCode := utils.GenerateVoteCode()
 	start := models.CustomTime{Time: time.Now()}
-	moderatorID, err := repositories.GetUserIdByUsername(username)
-	if err != nil {
-		logger.Error(
-			"CreateVotePage - failed to get user ID by username",
-			"error", err.Error(),
+	moderatorID := int(middlewares.GetCurrentUser(c).ID)
+
+	if len(voteTitle) < 5 {
+		logger.Warn(
+			"CreateVotePage - vote title must be at least 5 characters",
+			"Vote Title Inputted", voteTitle,
 			"Client IP", c.ClientIP(),
 			"Username", username,
 		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/create-vote-page/",
+		voteTitleErr = "Vote title must be at least 5 characters"
+	}
+
+	organizationErr := ""
+	organizationID, _ := strconv.Atoi(c.PostForm("organization"))
+	voterRollID, _ := strconv.Atoi(c.PostForm("voterRoll"))
+	publicBallot := c.PostForm("publicBallot") == "on"
+	if organizationID != 0 && !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"CreateVotePage - user is not a valid organization manager",
+			"Organization ID Inputted", organizationID,
+			"Client IP", c.ClientIP(),
+			"Username", username,
 		)
+		organizationErr = "You are not allowed to create votes for this organization"
 	}
 
-	if len(voteTitle) < 5 {
+	if voterRollID != 0 && (organizationID == 0 || !repositories.IsValidOrganizationVoterRoll(uint(organizationID), uint(voterRollID))) {
 		logger.Warn(
-			"CreateVotePage - vote title must be at least 5 characters",
-			"Vote Title Inputted", voteTitle,
+			"CreateVotePage - voter roll does not belong to organization",
+			"Voter Roll ID Inputted", voterRollID,
 			"Client IP", c.ClientIP(),
 			"Username", username,
 		)
-		voteTitleErr = "Vote title must be at least 5 characters"
+		organizationErr = "Voter roll must belong to the selected organization"
 	}
 
-	if voteTitleErr == "" {
+	if voteTitleErr == "" && organizationErr == "" {
 		newVote := factories.StartVoteFactory(voteTitle, voteDesc, voteCode, uint(moderatorID), start)
+		if organizationID != 0 {
+			voteOrganizationID := uint(organizationID)
+			newVote.OrganizationID = &voteOrganizationID
+		}
+		if voterRollID != 0 {
+			voteVoterRollID := uint(voterRollID)
+			newVote.VoterRollID = &voteVoterRollID
+		}
+		newVote.PublicBallot = publicBallot
 	
-		_, err = repositories.CreateVote(newVote)
+		vote, err := repositories.CreateVote(newVote)
 		if err != nil {
 			logger.Error(
 				"CreateVotePage - failed to create vote",
@@ -106,8 +122,11 @@ func CreateVotePage(c *gin.Context) {
 				err.Error(),
 				"/electivote/create-vote-page/",
 			)
+			return
 		}
 
+		utils.QueueVoteCreatedWebhooks(vote)
+		go utils.QueueVoteInvitations(vote)
 		logger.Info(
 			"CreateVotePage - vote created",
 			"Client IP", c.ClientIP(),
@@ -120,11 +139,27 @@ func CreateVotePage(c *gin.Context) {
 		)
 		return
 	}
+	organizations, voterRolls, err := getManagedOrganizationsData(username)
+	if err != nil {
+		logger.Error(
+			"CreateVotePage - failed to get managed organizations",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+	}
 	context := gin.H {
 		"title": "Create Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteTitleErr": voteTitleErr,
+		"organizationErr": organizationErr,
 		"voteTitle": voteTitle,
 		"voteDesc": voteDesc,
+		"organizations": organizations,
+		"voterRolls": voterRolls,
+		"selectedOrganization": organizationID,
+		"selectedVoterRoll": voterRollID,
+		"publicBallot": publicBallot,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -134,19 +169,7 @@ func CreateVotePage(c *gin.Context) {
 }
 
 func ViewManageVotesPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewManageVotesPage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	votesData, err := repositories.GetVotesDataByUsername(username)
 	if err != nil {
 		logger.Error(
@@ -170,6 +193,7 @@ func ViewManageVotesPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Manage Votes",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"votes": votesData,
 	}
 	c.HTML(
@@ -180,20 +204,7 @@ func ViewManageVotesPage(c *gin.Context) {
 }
 
 func ViewManageVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewManageVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -261,6 +272,20 @@ func ViewManageVotePage(c *gin.Context) {
 		return
 	}
 
+	voterRolls := []models.VoterRoll{}
+	if voteData.OrganizationID != nil {
+		var voterRollsErr error
+		voterRolls, voterRollsErr = repositories.GetVoterRollsByOrganizationID(*voteData.OrganizationID)
+		if voterRollsErr != nil {
+			logger.Error(
+				"ViewManageVotePage - failed to get voter rolls by organization ID",
+				"error", voterRollsErr.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+		}
+	}
+
 	logger.Info(
 		"ViewManageVotePage - rendering manage vote page",
 		"Client IP", c.ClientIP(),
@@ -268,8 +293,10 @@ func ViewManageVotePage(c *gin.Context) {
 	)
 	context := gin.H{
 		"title":      "Manage Vote",
+		"csrfToken":  middlewares.GetCSRFToken(c),
 		"voteData":   voteData,
 		"candidates": candidates,
+		"voterRolls": voterRolls,
 	}
 
 	c.HTML(
@@ -280,20 +307,7 @@ func ViewManageVotePage(c *gin.Context) {
 }
 
 func ManageVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ManageVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -339,7 +353,19 @@ func ManageVotePage(c *gin.Context) {
 		voteTitleErr = "Vote title must be at least 5 characters"
 	}
 
-	if voteTitleErr == "" {
+	voterRollErr := ""
+	voterRollID, _ := strconv.Atoi(c.PostForm("voterRoll"))
+	if voterRollID != 0 && (voteData.OrganizationID == nil || !repositories.IsValidOrganizationVoterRoll(*voteData.OrganizationID, uint(voterRollID))) {
+		logger.Warn(
+			"ManageVotePage - voter roll does not belong to vote organization",
+			"Voter Roll ID Inputted", voterRollID,
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		voterRollErr = "Voter roll must belong to the vote organization"
+	}
+
+	if voteTitleErr == "" && voterRollErr == "" {
 		newVote := factories.UpdateVoteFactory(voteTitle, voteDesc)
 		_, err := repositories.UpdateVote(uint(voteID), newVote)
 		if err != nil {
@@ -357,6 +383,30 @@ func ManageVotePage(c *gin.Context) {
 			)
 		}
 
+		if voteData.OrganizationID != nil {
+			var newVoterRollID *uint
+			if voterRollID != 0 {
+				selectedVoterRollID := uint(voterRollID)
+				newVoterRollID = &selectedVoterRollID
+			}
+			err = repositories.UpdateVoteVoterRoll(uint(voteID), newVoterRollID)
+			if err != nil {
+				logger.Error(
+					"ManageVotePage - failed to update vote voter roll",
+					"error", err.Error(),
+					"Client IP", c.ClientIP(),
+					"Username", username,
+				)
+				utils.RenderError(
+					c,
+					http.StatusInternalServerError,
+					err.Error(),
+					"/electivote/manage-vote-page/",
+				)
+				return
+			}
+		}
+
 		logger.Info(
 			"ManageVotePage - vote updated",
 			"Client IP", c.ClientIP(),
@@ -369,10 +419,19 @@ func ManageVotePage(c *gin.Context) {
 		)
 		return
 	}
+	voterRolls := []models.VoterRoll{}
+	if voteData.OrganizationID != nil {
+		voterRolls, _ = repositories.GetVoterRollsByOrganizationID(*voteData.OrganizationID)
+	}
+	candidates, _ := repositories.GetCandidatesByVoteID(uint(voteID))
 	context := gin.H {
 		"title": "Manage Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteData": voteData,
+		"candidates": candidates,
+		"voterRolls": voterRolls,
 		"voteTitleErr": voteTitleErr,
+		"voterRollErr": voterRollErr,
 		"voteTitle": voteTitle,
 		"voteDesc": voteDesc,
 	}
@@ -384,20 +443,7 @@ func ManageVotePage(c *gin.Context) {
 }
 
 func ViewDeleteVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewDeleteVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -436,6 +482,8 @@ func ViewDeleteVotePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Delete Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
+		"confirmationToken": middlewares.GetConfirmationToken(c, "delete-vote:"+strconv.Itoa(voteID)),
 		"voteData": voteData,
 	}
 	c.HTML(
@@ -446,20 +494,7 @@ func ViewDeleteVotePage(c *gin.Context) {
 }
 
 func DeleteVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"DeleteVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -475,61 +510,24 @@ func DeleteVotePage(c *gin.Context) {
 		return
 	}
 
-	voteData, err := repositories.GetVoteDataByVoteID(uint(voteID))
-	if err != nil {
-		logger.Error(
-			"DeleteVotePage - failed to get vote data by vote ID",
-			"error", err.Error(),
-			"Client IP", c.ClientIP(),
-			"Username", username,
-		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/manage-vote-page/",
-		)
-	}
-
-	moderatorName, err := repositories.GetModeratorNameByModeratorID(voteData.ModeratorID)
-	if err != nil {
-		logger.Error(
-			"DeleteVotePage - failed to get moderator name by moderator ID",
-			"error", err.Error(),
+	if !middlewares.IsValidConfirmationToken(c, "delete-vote:"+strconv.Itoa(voteID)) {
+		logger.Warn(
+			"DeleteVotePage - invalid confirmation token",
 			"Client IP", c.ClientIP(),
 			"Username", username,
+			"action", "redirecting to delete vote page",
 		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/manage-vote-page/",
-		)
-	}
-
-	candidateWinner, err := repositories.GetCandidateWinner(uint(voteID))
-	if err != nil {
-		logger.Error(
-			"DeleteVotePage - failed to get candidate winner",
-			"error", err.Error(),
-			"Client IP", c.ClientIP(),
-			"Username", username,
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/delete-vote-page/"+strconv.Itoa(voteID),
 		)
+		return
 	}
 
-	voteTitle := voteData.VoteTitle
-	voteDescription := voteData.VoteDescription
-	start := voteData.Start
-	candidateWinnerName := candidateWinner.CandidateName
-	candidateWinnerVotes := candidateWinner.TotalVotes
-	candidateWinnerPicture := candidateWinner.CandidatePicture
-	end := models.CustomTime{Time: time.Now()}
-	voteHistory := factories.VoteHistoryFactory(voteData.ModeratorID, candidateWinnerVotes, moderatorName, voteTitle, voteDescription, candidateWinnerName, candidateWinnerPicture, start, end)
-	err = repositories.CreateVoteHistory(voteHistory)
-
+	voteData, err := repositories.GetVoteDataByVoteID(uint(voteID))
 	if err != nil {
 		logger.Error(
-			"DeleteVotePage - failed to create vote history",
+			"DeleteVotePage - failed to get vote data by vote ID",
 			"error", err.Error(),
 			"Client IP", c.ClientIP(),
 			"Username", username,
@@ -542,7 +540,7 @@ func DeleteVotePage(c *gin.Context) {
 		)
 	}
 
-	err = repositories.DeleteVote(uint(voteID))
+	err = archiveAndDeleteVote(voteData)
 	if err != nil {
 		logger.Error(
 			"DeleteVotePage - failed to delete vote",
@@ -556,6 +554,7 @@ func DeleteVotePage(c *gin.Context) {
 			err.Error(),
 			"/electivote/manage-vote-page/",
 		)
+		return
 	}
 
 	logger.Info(
@@ -571,19 +570,7 @@ func DeleteVotePage(c *gin.Context) {
 }
 
 func ViewJoinVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewJoinVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	logger.Info(
 		"ViewJoinVotePage - rendering join vote page",
 		"Client IP", c.ClientIP(),
@@ -591,6 +578,7 @@ func ViewJoinVotePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Join Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
 	}
 	c.HTML(
 		http.StatusOK,
@@ -600,37 +588,10 @@ func ViewJoinVotePage(c *gin.Context) {
 }
 
 func JoinVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"JoinVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
 	voteCodeErr := ""
 	voteCode := c.PostForm("voteCode")
-	username := middlewares.GetUserData(c)
-	userID, err := repositories.GetUserIdByUsername(username)
-	if err != nil {
-		logger.Error(
-			"JoinVotePage - failed to get user ID by username",
-			"error", err.Error(),
-			"Client IP", c.ClientIP(),
-			"Username", username,
-		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/join-vote-page/",
-		)
-		return
-	}
+	username := middlewares.GetCurrentUser(c).Username
+	userID := int(middlewares.GetCurrentUser(c).ID)
 
 	if repositories.IsVoted(uint(userID), voteCode) {
 		logger.Warn(
@@ -650,7 +611,7 @@ func JoinVotePage(c *gin.Context) {
 		voteCodeErr = "Vote code must be 6 characters"
 	}
 
-	_, err = repositories.GetVoteByVoteCode(voteCode)
+	vote, err := repositories.GetVoteByVoteCode(voteCode)
 	if len(voteCode) == 6 && err != nil {
 		logger.Warn(
 			"JoinVotePage - vote code not found",
@@ -660,9 +621,20 @@ func JoinVotePage(c *gin.Context) {
 		voteCodeErr = "Vote code not found"
 	}
 
+	if err == nil && !repositories.IsEligibleVoter(uint(userID), vote) {
+		logger.Warn(
+			"JoinVotePage - user is not on the voter roll",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Vote ID", vote.VoteID,
+		)
+		voteCodeErr = "You are not on the voter roll of this vote"
+	}
+
 	if voteCodeErr != "" {
 		context := gin.H {
 			"title": "Join Vote",
+			"csrfToken": middlewares.GetCSRFToken(c),
 			"voteCode": voteCode,
 			"voteCodeErr": voteCodeErr,
 		}
@@ -686,19 +658,7 @@ func JoinVotePage(c *gin.Context) {
 }
 
 func ViewVotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewVotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteCode := c.Param("voteCode")
 	voteID, err := repositories.GetVoteIDByVoteCode(voteCode)
 	if err != nil {
@@ -732,6 +692,21 @@ func ViewVotePage(c *gin.Context) {
 		)
 	}
 
+	userID := middlewares.GetCurrentUser(c).ID
+	if !repositories.IsEligibleVoter(userID, VoteData) {
+		logger.Warn(
+			"ViewVotePage - user is not on the voter roll",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to join vote page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/join-vote-page/",
+		)
+		return
+	}
+
 	candidates, err := repositories.GetCandidatesByVoteID(uint(voteID))
 	if err != nil {
 		logger.Error(
@@ -755,10 +730,12 @@ func ViewVotePage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Vote",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"candidates": candidates,
 		"voteTitle": VoteData.VoteTitle,
 		"voteDescription": VoteData.VoteDescription,
 		"voteCode": voteCode,
+		"publicBallot": VoteData.PublicBallot,
 	}
 	c.HTML(
 		http.StatusOK,
@@ -768,19 +745,7 @@ func ViewVotePage(c *gin.Context) {
 }	
 
 func VotePage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"VotePage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	votedErr := ""
 	voted := c.PostForm("voted")
 	voteCode := c.Param("voteCode")
@@ -816,20 +781,20 @@ func VotePage(c *gin.Context) {
 		)
 	}
 
-	userID, err := repositories.GetUserIdByUsername(username)
-	if err != nil {
-		logger.Error(
-			"VotePage - failed to get user ID by username",
-			"error", err.Error(),
+	userID := int(middlewares.GetCurrentUser(c).ID)
+
+	if !repositories.IsEligibleVoter(uint(userID), VoteData) {
+		logger.Warn(
+			"VotePage - user is not on the voter roll",
 			"Client IP", c.ClientIP(),
 			"Username", username,
+			"action", "redirecting to join vote page",
 		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/home-page/",
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/join-vote-page/",
 		)
+		return
 	}
 
 	candidates, err := repositories.GetCandidatesByVoteID(uint(voteID))
@@ -860,11 +825,13 @@ func VotePage(c *gin.Context) {
 	if votedErr != "" {
 		context := gin.H {
 			"title": "Vote",
+			"csrfToken": middlewares.GetCSRFToken(c),
 			"votedErr": votedErr,
 			"voteCode": voteCode,
 			"voted":voted,
 			"voteTitle": VoteData.VoteTitle,
 			"voteDescription": VoteData.VoteDescription,
+			"publicBallot": VoteData.PublicBallot,
 			"candidates": candidates,
 		}
 		c.HTML(
@@ -876,30 +843,46 @@ func VotePage(c *gin.Context) {
 	}
 
 	votedInt, _ := strconv.Atoi(voted)
-	votedTime := models.CustomTime{Time: time.Now()}
-
-	votedRecord := factories.VoteRecordFactory(uint(voteID), uint(userID), uint(votedInt), votedTime)
-	_, err = repositories.CreateVoteRecord(votedRecord)
-	if err != nil {
-		logger.Error(
-			"VotePage - failed to create vote record",
-			"error", err.Error(),
+	err = castBallot(uint(voteID), uint(userID), uint(votedInt))
+	if errors.Is(err, repositories.ErrAlreadyVoted) {
+		logger.Warn(
+			"VotePage - user already voted in this vote",
 			"Client IP", c.ClientIP(),
 			"Username", username,
 		)
 		utils.RenderError(
 			c,
-			http.StatusInternalServerError,
-			err.Error(),
+			http.StatusConflict,
+			"You already voted in this vote",
 			"/electivote/home-page/",
 		)
 		return
 	}
-
-	err = repositories.IncrementCandidateVote(uint(votedInt))
+	if errors.Is(err, repositories.ErrCandidateNotInVote) {
+		logger.Warn(
+			"VotePage - candidate does not belong to this vote",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		c.HTML(
+			http.StatusBadRequest,
+			"vote.html",
+			gin.H{
+				"title": "Vote",
+				"csrfToken": middlewares.GetCSRFToken(c),
+				"votedErr": "Please select a candidate of this vote",
+				"voteCode": voteCode,
+				"voteTitle": VoteData.VoteTitle,
+				"voteDescription": VoteData.VoteDescription,
+				"publicBallot": VoteData.PublicBallot,
+				"candidates": candidates,
+			},
+		)
+		return
+	}
 	if err != nil {
 		logger.Error(
-			"VotePage - failed to increment candidate vote",
+			"VotePage - failed to record vote",
 			"error", err.Error(),
 			"Client IP", c.ClientIP(),
 			"Username", username,
@@ -926,20 +909,7 @@ func VotePage(c *gin.Context) {
 }
 
 func ViewVoteResultPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewVoteResultPage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-
-	username := middlewares.GetUserData(c)
+	username := middlewares.GetCurrentUser(c).Username
 	voteID, _ := strconv.Atoi(c.Param("voteID"))
 	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
@@ -1021,6 +991,7 @@ func ViewVoteResultPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Vote Result",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteID": voteID,
 		"voteData": voteData,
 		"candidates": candidates,
@@ -1035,35 +1006,68 @@ func ViewVoteResultPage(c *gin.Context) {
 	)
 }
 
-func ViewVoteHistoryPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
+// voteResultsHeartbeat keeps idle result streams open through proxies that
+// close quiet connections.
+const voteResultsHeartbeat = 25 * time.Second
+
+// StreamVoteResultsPage pushes the vote tally to the result page as
+// Server-Sent Events: a "results" event on connect and after every ballot.
+func StreamVoteResultsPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
 		logger.Warn(
-			"ViewVoteHistoryPage - User is not logged in",
+			"StreamVoteResultsPage - User is not a valid vote moderator",
 			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
+			"Username", username,
 		)
+		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
-	username := middlewares.GetUserData(c)
-	userID, err := repositories.GetUserIdByUsername(username)
+
+	results, err := utils.GetLiveResults(uint(voteID))
 	if err != nil {
 		logger.Error(
-			"ViewVoteHistoryPage - failed to get user ID by username",
+			"StreamVoteResultsPage - failed to get results",
 			"error", err.Error(),
 			"Client IP", c.ClientIP(),
 			"Username", username,
 		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/home-page/",
-		)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
 	}
+
+	updates, unsubscribe := utils.SubscribeVoteResults(uint(voteID))
+	defer unsubscribe()
+	heartbeat := time.NewTicker(voteResultsHeartbeat)
+	defer heartbeat.Stop()
+
+	logger.Info(
+		"StreamVoteResultsPage - streaming vote results",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Vote ID", voteID,
+	)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("results", results)
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case payload := <-updates:
+			c.SSEvent("results", json.RawMessage(payload))
+		case <-heartbeat.C:
+			io.WriteString(w, ": heartbeat\n\n")
+		}
+		return true
+	})
+}
+
+func ViewVoteHistoryPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	userID := int(middlewares.GetCurrentUser(c).ID)
 	voteHistories, err := repositories.GetVoteHistoriesByUserID(uint(userID))
 	if err != nil {
 		logger.Error(
@@ -1088,6 +1092,7 @@ func ViewVoteHistoryPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Vote History",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"isExist": len(voteHistories) > 0,
 		"voteHistories": voteHistories,
 	}
@@ -1099,35 +1104,8 @@ func ViewVoteHistoryPage(c *gin.Context) {
 }
 
 func ViewVoteHistoryDetailPage(c *gin.Context) {
-	if !middlewares.IsLogged(c) {
-		logger.Warn(
-			"ViewVoteHistoryDetailPage - User is not logged in",
-			"Client IP", c.ClientIP(),
-			"action", "redirecting to login page",
-		)
-		c.Redirect(
-			http.StatusFound,
-			"/electivote/login-page/",
-		)
-		return
-	}
-	username := middlewares.GetUserData(c)
-	userID, err := repositories.GetUserIdByUsername(username)
-	if err != nil {
-		logger.Error(
-			"ViewVoteHistoryDetailPage - failed to get user ID by username",
-			"error", err.Error(),
-			"Client IP", c.ClientIP(),
-			"Username", username,
-		)
-		utils.RenderError(
-			c,
-			http.StatusInternalServerError,
-			err.Error(),
-			"/electivote/vote-history-page/",
-		)
-		return
-	}
+	username := middlewares.GetCurrentUser(c).Username
+	userID := int(middlewares.GetCurrentUser(c).ID)
 	voteHistoryID, _ := strconv.Atoi(c.Param("voteHistoryID"))
 	voteHistory, err := repositories.GetVoteHistoryByVoteHistoryID(uint(voteHistoryID))
 	if err != nil {
@@ -1170,6 +1148,7 @@ func ViewVoteHistoryDetailPage(c *gin.Context) {
 	)
 	context := gin.H {
 		"title": "Vote History Detail",
+		"csrfToken": middlewares.GetCSRFToken(c),
 		"voteHistory": voteHistory,
 		"isWinnerExist": isWinnerExist,
 	}
@@ -1178,4 +1157,104 @@ func ViewVoteHistoryDetailPage(c *gin.Context) {
 		"voteHistoryDetail.html",
 		context,
 	)
-}
\ No newline at end of file
+}
+
+func getManagedOrganizationsData(username string) ([]models.Organization, []models.VoterRoll, error) {
+	organizations, err := repositories.GetManagedOrganizationsByUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	voterRolls := []models.VoterRoll{}
+	for _, organization := range organizations {
+		organizationVoterRolls, err := repositories.GetVoterRollsByOrganizationID(organization.OrganizationID)
+		if err != nil {
+			return nil, nil, err
+		}
+		voterRolls = append(voterRolls, organizationVoterRolls...)
+	}
+	return organizations, voterRolls, nil
+}
+
+// archiveAndDeleteVote stores the vote, its winning candidate and a snapshot
+// of the final results in the moderator's vote history before deleting the
+// vote, then removes the pictures no one else uses, queues the vote.closed
+// and result.published webhooks and emails the results to the voters.
+func archiveAndDeleteVote(voteData models.Vote) error {
+	moderatorName, err := repositories.GetModeratorNameByModeratorID(voteData.ModeratorID)
+	if err != nil {
+		return err
+	}
+
+	candidateWinner, err := repositories.GetCandidateWinner(voteData.VoteID)
+	if err != nil {
+		logger.Warn(
+			"archiveAndDeleteVote - vote has no candidate winner",
+			"error", err.Error(),
+			"Vote ID", voteData.VoteID,
+		)
+	}
+
+	results, err := utils.GetLiveResults(voteData.VoteID)
+	if err != nil {
+		return err
+	}
+
+	resultsExport, err := utils.BuildVoteResultsExport(voteData)
+	if err != nil {
+		return err
+	}
+
+	end := models.CustomTime{Time: time.Now()}
+	resultsSnapshot, err := utils.CloseResultsExport(resultsExport, end.Time)
+	if err != nil {
+		return err
+	}
+	voteHistory := factories.VoteHistoryFactory(voteData.ModeratorID, candidateWinner.TotalVotes, moderatorName, voteData.VoteTitle, voteData.VoteDescription, candidateWinner.CandidateName, candidateWinner.CandidatePicture, voteData.Start, end)
+	voteHistory.ResultsSnapshot = resultsSnapshot
+	err = repositories.CreateVoteHistory(voteHistory)
+	if err != nil {
+		return err
+	}
+	candidates, err := repositories.GetCandidatesByVoteID(voteData.VoteID)
+	if err != nil {
+		return err
+	}
+	recipients, err := utils.GetVoteResultRecipients(voteData)
+	if err != nil {
+		return err
+	}
+	err = repositories.DeleteVote(voteData.VoteID)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		utils.RemoveUnusedImages(candidate.CandidatePicture)
+	}
+	utils.QueueVoteClosedWebhooks(voteData, results, end.Time)
+	signedExport, err := utils.GetVoteHistoryResultsExport(*voteHistory)
+	if err != nil {
+		logger.Error(
+			"archiveAndDeleteVote - failed to load results for emails",
+			"error", err.Error(),
+			"Vote ID", voteData.VoteID,
+		)
+		return nil
+	}
+	go utils.QueueVoteResultEmails(recipients, signedExport)
+	return nil
+}
+
+// castBallot records a ballot from the vote page or the API. It refuses a
+// second ballot of the same user with repositories.ErrAlreadyVoted and a
+// candidate of another vote with repositories.ErrCandidateNotInVote.
+func castBallot(voteID, userID, candidateID uint) error {
+	votedTime := models.CustomTime{Time: time.Now()}
+	votedRecord := factories.VoteRecordFactory(voteID, userID, candidateID, votedTime)
+	err := repositories.CastBallot(votedRecord)
+	if err != nil {
+		return err
+	}
+	go utils.PublishVoteResults(voteID)
+	go utils.QueueBallotCastWebhooks(voteID)
+	return nil
+}
@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var errVoteSettingsNotManaged = errors.New("You no longer manage the organization or voter roll of this vote")
+
+func ViewDuplicateVotePage(c *gin.Context) {
+	voteData, candidates, ok := getDuplicatedVoteData(c, "ViewDuplicateVotePage")
+	if !ok {
+		return
+	}
+	logger.Info(
+		"ViewDuplicateVotePage - rendering duplicate vote page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	renderDuplicateVotePage(c, http.StatusOK, voteData, candidates, gin.H{
+		"voteTitle":     voteData.VoteTitle,
+		"voteDesc":      voteData.VoteDescription,
+		"templateName":  voteData.VoteTitle,
+		"templateTitle": voteData.VoteTitle,
+		"templateDesc":  voteData.VoteDescription,
+	})
+}
+
+func DuplicateVotePage(c *gin.Context) {
+	voteData, candidates, ok := getDuplicatedVoteData(c, "DuplicateVotePage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	voteTitle := c.PostForm("voteTitle")
+	voteDesc := c.PostForm("voteDesc")
+
+	now := time.Now()
+	expandedTitle := utils.ExpandVoteTemplate(voteTitle, now)
+	voteTitleErr := validateVoteTemplateTitle(expandedTitle)
+	if voteTitleErr != "" {
+		logger.Warn(
+			"DuplicateVotePage - invalid vote title",
+			"Vote Title Inputted", voteTitle,
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		renderDuplicateVotePage(c, http.StatusBadRequest, voteData, candidates, gin.H{
+			"voteTitleErr":  voteTitleErr,
+			"voteTitle":     voteTitle,
+			"voteDesc":      voteDesc,
+			"templateName":  voteData.VoteTitle,
+			"templateTitle": voteData.VoteTitle,
+			"templateDesc":  voteData.VoteDescription,
+		})
+		return
+	}
+
+	blueprint := voteData
+	blueprint.VoteTitle = expandedTitle
+	blueprint.VoteDescription = utils.ExpandVoteTemplate(voteDesc, now)
+	vote, err := createVoteFromBlueprint(c, blueprint, candidates)
+	if err != nil {
+		logger.Error(
+			"DuplicateVotePage - failed to duplicate vote",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			voteBlueprintErrorStatus(err),
+			err.Error(),
+			"/electivote/duplicate-vote-page/"+strconv.Itoa(int(voteData.VoteID)),
+		)
+		return
+	}
+
+	logger.Info(
+		"DuplicateVotePage - vote duplicated",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Source Vote ID", voteData.VoteID,
+		"Vote ID", vote.VoteID,
+		"action", "redirecting to manage vote page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-vote-page/"+strconv.Itoa(int(vote.VoteID)),
+	)
+}
+
+func SaveVoteTemplatePage(c *gin.Context) {
+	voteData, candidates, ok := getDuplicatedVoteData(c, "SaveVoteTemplatePage")
+	if !ok {
+		return
+	}
+	currentUser := middlewares.GetCurrentUser(c)
+	templateName := c.PostForm("templateName")
+	templateTitle := c.PostForm("templateTitle")
+	templateDesc := c.PostForm("templateDesc")
+
+	templateNameErr := ""
+	if utf8.RuneCountInString(templateName) < 3 || utf8.RuneCountInString(templateName) > 255 {
+		templateNameErr = "Template name must be between 3 and 255 characters"
+	}
+	templateTitleErr := validateVoteTemplateTitle(utils.ExpandVoteTemplate(templateTitle, time.Now()))
+	if utf8.RuneCountInString(templateTitle) > 255 {
+		templateTitleErr = "Vote title must be at most 255 characters"
+	}
+	if templateNameErr != "" || templateTitleErr != "" {
+		logger.Warn(
+			"SaveVoteTemplatePage - invalid template input",
+			"Template Name Inputted", templateName,
+			"Template Title Inputted", templateTitle,
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderDuplicateVotePage(c, http.StatusBadRequest, voteData, candidates, gin.H{
+			"voteTitle":        voteData.VoteTitle,
+			"voteDesc":         voteData.VoteDescription,
+			"templateNameErr":  templateNameErr,
+			"templateTitleErr": templateTitleErr,
+			"templateName":     templateName,
+			"templateTitle":    templateTitle,
+			"templateDesc":     templateDesc,
+		})
+		return
+	}
+
+	blueprint := voteData
+	blueprint.VoteTitle = templateTitle
+	blueprint.VoteDescription = templateDesc
+	voteTemplate := factories.VoteTemplateFactory(currentUser.ID, templateName, blueprint, models.CustomTime{Time: time.Now()})
+	copiedPictures := []string{}
+	for _, candidate := range candidates {
+		templateCandidate := factories.VoteTemplateCandidateFactory(candidate)
+		picture, err := utils.CopyCandidatePicture(candidate.CandidatePicture)
+		if err != nil {
+			utils.RemoveUnusedImages(copiedPictures...)
+			logger.Error(
+				"SaveVoteTemplatePage - failed to copy candidate picture",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", currentUser.Username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/duplicate-vote-page/"+strconv.Itoa(int(voteData.VoteID)),
+			)
+			return
+		}
+		copiedPictures = append(copiedPictures, picture)
+		templateCandidate.CandidatePicture = picture
+		voteTemplate.Candidates = append(voteTemplate.Candidates, templateCandidate)
+	}
+
+	_, err := repositories.CreateVoteTemplate(voteTemplate)
+	if err != nil {
+		utils.RemoveUnusedImages(copiedPictures...)
+		logger.Error(
+			"SaveVoteTemplatePage - failed to create vote template",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/duplicate-vote-page/"+strconv.Itoa(int(voteData.VoteID)),
+		)
+		return
+	}
+
+	logger.Info(
+		"SaveVoteTemplatePage - vote template saved",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Vote ID", voteData.VoteID,
+		"action", "redirecting to vote templates page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/vote-templates-page/",
+	)
+}
+
+func ViewVoteTemplatesPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	voteTemplates, err := repositories.GetVoteTemplatesByModeratorID(currentUser.ID)
+	if err != nil {
+		logger.Error(
+			"ViewVoteTemplatesPage - failed to get vote templates",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-vote-page/",
+		)
+		return
+	}
+
+	now := time.Now()
+	voteTitlePreviews := map[uint]string{}
+	for _, voteTemplate := range voteTemplates {
+		voteTitlePreviews[voteTemplate.VoteTemplateID] = utils.ExpandVoteTemplate(voteTemplate.VoteTitle, now)
+	}
+
+	logger.Info(
+		"ViewVoteTemplatesPage - rendering vote templates page",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+	)
+	context := gin.H{
+		"title":             "Vote Templates",
+		"csrfToken":         middlewares.GetCSRFToken(c),
+		"voteTemplates":     voteTemplates,
+		"voteTitlePreviews": voteTitlePreviews,
+		"placeholders":      utils.VoteTemplatePlaceholders,
+	}
+	c.HTML(
+		http.StatusOK,
+		"voteTemplates.html",
+		context,
+	)
+}
+
+func UseVoteTemplatePage(c *gin.Context) {
+	voteTemplate, ok := getOwnedVoteTemplate(c, "UseVoteTemplatePage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+
+	now := time.Now()
+	blueprint := models.Vote{
+		VoteTitle:       utils.ExpandVoteTemplate(voteTemplate.VoteTitle, now),
+		VoteDescription: utils.ExpandVoteTemplate(voteTemplate.VoteDescription, now),
+		OrganizationID:  voteTemplate.OrganizationID,
+		VoterRollID:     voteTemplate.VoterRollID,
+		PublicBallot:    voteTemplate.PublicBallot,
+	}
+	voteTitleErr := validateVoteTemplateTitle(blueprint.VoteTitle)
+	if voteTitleErr != "" {
+		logger.Warn(
+			"UseVoteTemplatePage - invalid vote title",
+			"Vote Title", blueprint.VoteTitle,
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusBadRequest,
+			voteTitleErr,
+			"/electivote/vote-templates-page/",
+		)
+		return
+	}
+	candidates := make([]models.Candidate, 0, len(voteTemplate.Candidates))
+	for _, templateCandidate := range voteTemplate.Candidates {
+		candidates = append(candidates, factories.CandidateFactory(templateCandidate.CandidateName, templateCandidate.CandidateDescription, templateCandidate.CandidatePicture, 0))
+	}
+
+	vote, err := createVoteFromBlueprint(c, blueprint, candidates)
+	if err != nil {
+		logger.Error(
+			"UseVoteTemplatePage - failed to create vote from template",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			voteBlueprintErrorStatus(err),
+			err.Error(),
+			"/electivote/vote-templates-page/",
+		)
+		return
+	}
+
+	logger.Info(
+		"UseVoteTemplatePage - vote created from template",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Vote Template ID", voteTemplate.VoteTemplateID,
+		"Vote ID", vote.VoteID,
+		"action", "redirecting to manage vote page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-vote-page/"+strconv.Itoa(int(vote.VoteID)),
+	)
+}
+
+func DeleteVoteTemplatePage(c *gin.Context) {
+	voteTemplate, ok := getOwnedVoteTemplate(c, "DeleteVoteTemplatePage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+
+	err := repositories.DeleteVoteTemplate(voteTemplate.VoteTemplateID)
+	if err != nil {
+		logger.Error(
+			"DeleteVoteTemplatePage - failed to delete vote template",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/vote-templates-page/",
+		)
+		return
+	}
+	pictures := []string{}
+	for _, templateCandidate := range voteTemplate.Candidates {
+		pictures = append(pictures, templateCandidate.CandidatePicture)
+	}
+	utils.RemoveUnusedImages(pictures...)
+
+	logger.Info(
+		"DeleteVoteTemplatePage - vote template deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Vote Template ID", voteTemplate.VoteTemplateID,
+		"action", "redirecting to vote templates page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/vote-templates-page/",
+	)
+}
+
+// createVoteFromBlueprint creates a fresh vote with a new vote code from the
+// settings of an existing vote or template and copies its candidates along
+// with their pictures.
+func createVoteFromBlueprint(c *gin.Context, blueprint models.Vote, candidates []models.Candidate) (models.Vote, error) {
+	currentUser := middlewares.GetCurrentUser(c)
+	if blueprint.OrganizationID != nil && !repositories.IsValidOrganizationManager(currentUser.Username, *blueprint.OrganizationID) {
+		return models.Vote{}, errVoteSettingsNotManaged
+	}
+	if blueprint.VoterRollID != nil && (blueprint.OrganizationID == nil || !repositories.IsValidOrganizationVoterRoll(*blueprint.OrganizationID, *blueprint.VoterRollID)) {
+		return models.Vote{}, errVoteSettingsNotManaged
+	}
+
+	newVote := factories.StartVoteFactory(blueprint.VoteTitle, blueprint.VoteDescription, utils.GenerateVoteCode(), currentUser.ID, models.CustomTime{Time: time.Now()})
+	newVote.OrganizationID = blueprint.OrganizationID
+	newVote.VoterRollID = blueprint.VoterRollID
+	newVote.PublicBallot = blueprint.PublicBallot
+
+	newCandidates := make([]models.Candidate, 0, len(candidates))
+	copiedPictures := []string{}
+	for _, candidate := range candidates {
+		picture, err := utils.CopyCandidatePicture(candidate.CandidatePicture)
+		if err != nil {
+			utils.RemoveUnusedImages(copiedPictures...)
+			return models.Vote{}, err
+		}
+		copiedPictures = append(copiedPictures, picture)
+		newCandidates = append(newCandidates, factories.CandidateFactory(candidate.CandidateName, candidate.CandidateDescription, picture, 0))
+	}
+
+	vote, err := repositories.CreateVoteWithCandidates(newVote, newCandidates)
+	if err != nil {
+		utils.RemoveUnusedImages(copiedPictures...)
+		return models.Vote{}, err
+	}
+	utils.QueueVoteCreatedWebhooks(vote)
+	go utils.QueueVoteInvitations(vote)
+	return vote, nil
+}
+
+func voteBlueprintErrorStatus(err error) int {
+	if errors.Is(err, errVoteSettingsNotManaged) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func validateVoteTemplateTitle(voteTitle string) string {
+	if len(voteTitle) < 5 {
+		return "Vote title must be at least 5 characters"
+	}
+	if utf8.RuneCountInString(voteTitle) > 255 {
+		return "Vote title must be at most 255 characters"
+	}
+	return ""
+}
+
+func getDuplicatedVoteData(c *gin.Context, source string) (models.Vote, []models.Candidate, bool) {
+	username := middlewares.GetCurrentUser(c).Username
+	voteID, _ := strconv.Atoi(c.Param("voteID"))
+	if !repositories.IsValidVoteModerator(username, uint(voteID)) {
+		logger.Warn(
+			source+" - User is not a valid vote moderator",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to home page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/home-page/",
+		)
+		return models.Vote{}, nil, false
+	}
+
+	voteData, err := repositories.GetVoteDataByVoteID(uint(voteID))
+	var candidates []models.Candidate
+	if err == nil {
+		candidates, err = repositories.GetCandidatesByVoteID(uint(voteID))
+	}
+	if err != nil {
+		logger.Error(
+			source+" - failed to get vote data",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-vote-page/",
+		)
+		return models.Vote{}, nil, false
+	}
+	return voteData, candidates, true
+}
+
+func getOwnedVoteTemplate(c *gin.Context, source string) (models.VoteTemplate, bool) {
+	currentUser := middlewares.GetCurrentUser(c)
+	voteTemplateID, _ := strconv.Atoi(c.Param("voteTemplateID"))
+	voteTemplate, err := repositories.GetVoteTemplateByID(uint(voteTemplateID))
+	if err != nil || voteTemplate.ModeratorID != currentUser.ID {
+		logger.Warn(
+			source+" - User does not own the vote template",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"action", "redirecting to vote templates page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/vote-templates-page/",
+		)
+		return models.VoteTemplate{}, false
+	}
+	return voteTemplate, true
+}
+
+func renderDuplicateVotePage(c *gin.Context, statusCode int, voteData models.Vote, candidates []models.Candidate, extra gin.H) {
+	context := gin.H{
+		"title":        "Duplicate Vote",
+		"csrfToken":    middlewares.GetCSRFToken(c),
+		"voteData":     voteData,
+		"candidates":   candidates,
+		"placeholders": utils.VoteTemplatePlaceholders,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"duplicateVote.html",
+		context,
+	)
+}
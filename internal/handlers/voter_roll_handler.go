@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateVoterRollPage(c *gin.Context) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) {
+		logger.Warn(
+			"CreateVoterRollPage - User is not a valid organization manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return
+	}
+
+	voterRollName := c.PostForm("voterRollName")
+	if len(voterRollName) < 3 || len(voterRollName) > 255 {
+		logger.Warn(
+			"CreateVoterRollPage - voter roll name must be between 3 and 255 characters",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Voter Roll Name Inputted", voterRollName,
+		)
+		renderManageOrganizationPage(c, http.StatusBadRequest, uint(organizationID), gin.H{
+			"voterRollNameErr": "Voter roll name must be between 3 and 255 characters",
+			"voterRollName":    voterRollName,
+		})
+		return
+	}
+
+	newVoterRoll := factories.VoterRollFactory(voterRollName, uint(organizationID))
+	voterRoll, err := repositories.CreateVoterRoll(newVoterRoll)
+	if err != nil {
+		logger.Error(
+			"CreateVoterRollPage - failed to create voter roll",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"CreateVoterRollPage - voter roll created",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Voter Roll ID", voterRoll.VoterRollID,
+		"action", "redirecting to manage voter roll page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-voter-roll-page/"+strconv.Itoa(organizationID)+"/"+strconv.Itoa(int(voterRoll.VoterRollID)),
+	)
+}
+
+func isValidVoterRollManager(c *gin.Context, source string) (int, int, bool) {
+	username := middlewares.GetCurrentUser(c).Username
+	organizationID, _ := strconv.Atoi(c.Param("organizationID"))
+	voterRollID, _ := strconv.Atoi(c.Param("voterRollID"))
+	if !repositories.IsValidOrganizationManager(username, uint(organizationID)) || !repositories.IsValidOrganizationVoterRoll(uint(organizationID), uint(voterRollID)) {
+		logger.Warn(
+			source+" - User is not a valid voter roll manager",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"action", "redirecting to organizations page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/organizations-page/",
+		)
+		return 0, 0, false
+	}
+	return organizationID, voterRollID, true
+}
+
+func renderManageVoterRollPage(c *gin.Context, statusCode int, organizationID, voterRollID uint, extra gin.H) {
+	username := middlewares.GetCurrentUser(c).Username
+	var voterRoll models.VoterRoll
+	var entries []models.VoterRollEntry
+	var members []models.OrganizationMember
+	var voterRollErr, entriesErr, membersErr error
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		voterRoll, voterRollErr = repositories.GetVoterRollByVoterRollID(voterRollID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		entries, entriesErr = repositories.GetVoterRollEntriesByVoterRollID(voterRollID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		members, membersErr = repositories.GetOrganizationMembersByOrganizationID(organizationID)
+	}()
+
+	wg.Wait()
+
+	for _, err := range []error{voterRollErr, entriesErr, membersErr} {
+		if err != nil {
+			logger.Error(
+				"renderManageVoterRollPage - failed to get voter roll data",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+				"Voter Roll ID", voterRollID,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/manage-organization-page/"+strconv.Itoa(int(organizationID)),
+			)
+			return
+		}
+	}
+
+	enrolledUserIDs := map[uint]bool{}
+	for _, entry := range entries {
+		enrolledUserIDs[entry.UserId] = true
+	}
+	availableMembers := []models.OrganizationMember{}
+	for _, member := range members {
+		if !enrolledUserIDs[member.UserId] {
+			availableMembers = append(availableMembers, member)
+		}
+	}
+
+	context := gin.H{
+		"title":            "Manage Voter Roll",
+		"csrfToken":        middlewares.GetCSRFToken(c),
+		"organizationID":   organizationID,
+		"voterRoll":        voterRoll,
+		"entries":          entries,
+		"availableMembers": availableMembers,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"manageVoterRoll.html",
+		context,
+	)
+}
+
+func ViewManageVoterRollPage(c *gin.Context) {
+	organizationID, voterRollID, ok := isValidVoterRollManager(c, "ViewManageVoterRollPage")
+	if !ok {
+		return
+	}
+
+	logger.Info(
+		"ViewManageVoterRollPage - rendering manage voter roll page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+		"Voter Roll ID", voterRollID,
+	)
+	renderManageVoterRollPage(c, http.StatusOK, uint(organizationID), uint(voterRollID), nil)
+}
+
+func ManageVoterRollPage(c *gin.Context) {
+	organizationID, voterRollID, ok := isValidVoterRollManager(c, "ManageVoterRollPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	source := "/electivote/manage-voter-roll-page/" + strconv.Itoa(organizationID) + "/" + strconv.Itoa(voterRollID)
+
+	members, err := repositories.GetOrganizationMembersByOrganizationID(uint(organizationID))
+	if err != nil {
+		logger.Error(
+			"ManageVoterRollPage - failed to get organization members",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			source,
+		)
+		return
+	}
+
+	selectedUserIDs := map[uint]bool{}
+	if c.PostForm("allMembers") == "on" {
+		for _, member := range members {
+			selectedUserIDs[member.UserId] = true
+		}
+	} else {
+		for _, memberUserID := range c.PostFormArray("memberUserIDs") {
+			userID, err := strconv.Atoi(memberUserID)
+			if err != nil {
+				continue
+			}
+			for _, member := range members {
+				if member.UserId == uint(userID) {
+					selectedUserIDs[member.UserId] = true
+				}
+			}
+		}
+	}
+
+	if len(selectedUserIDs) == 0 {
+		logger.Warn(
+			"ManageVoterRollPage - no members selected",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		renderManageVoterRollPage(c, http.StatusBadRequest, uint(organizationID), uint(voterRollID), gin.H{
+			"entryErr": "Please select at least one member",
+		})
+		return
+	}
+
+	for userID := range selectedUserIDs {
+		if repositories.IsInVoterRoll(userID, uint(voterRollID)) {
+			continue
+		}
+		newEntry := factories.VoterRollEntryFactory(uint(voterRollID), userID)
+		_, err = repositories.AddVoterRollEntry(newEntry)
+		if err != nil {
+			logger.Error(
+				"ManageVoterRollPage - failed to add voter roll entry",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				source,
+			)
+			return
+		}
+	}
+
+	logger.Info(
+		"ManageVoterRollPage - voter roll entries added",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Voter Roll ID", voterRollID,
+		"Total Added", len(selectedUserIDs),
+		"action", "redirecting to manage voter roll page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		source,
+	)
+}
+
+func DeleteVoterRollEntryPage(c *gin.Context) {
+	organizationID, voterRollID, ok := isValidVoterRollManager(c, "DeleteVoterRollEntryPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	entryID, _ := strconv.Atoi(c.Param("entryID"))
+	source := "/electivote/manage-voter-roll-page/" + strconv.Itoa(organizationID) + "/" + strconv.Itoa(voterRollID)
+
+	entry, err := repositories.GetVoterRollEntryByEntryID(uint(entryID))
+	if err != nil || entry.VoterRollId != uint(voterRollID) {
+		logger.Warn(
+			"DeleteVoterRollEntryPage - voter roll entry not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Entry ID", entryID,
+		)
+		c.Redirect(
+			http.StatusFound,
+			source,
+		)
+		return
+	}
+
+	err = repositories.DeleteVoterRollEntry(uint(entryID))
+	if err != nil {
+		logger.Error(
+			"DeleteVoterRollEntryPage - failed to delete voter roll entry",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			source,
+		)
+		return
+	}
+
+	logger.Info(
+		"DeleteVoterRollEntryPage - voter roll entry deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Entry ID", entryID,
+		"action", "redirecting to manage voter roll page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		source,
+	)
+}
+
+func DeleteVoterRollPage(c *gin.Context) {
+	organizationID, voterRollID, ok := isValidVoterRollManager(c, "DeleteVoterRollPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+
+	err := repositories.DeleteVoterRoll(uint(voterRollID))
+	if err != nil {
+		logger.Error(
+			"DeleteVoterRollPage - failed to delete voter roll",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(
+			c,
+			http.StatusInternalServerError,
+			err.Error(),
+			"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+		)
+		return
+	}
+
+	logger.Info(
+		"DeleteVoterRollPage - voter roll deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Voter Roll ID", voterRollID,
+		"action", "redirecting to manage organization page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/manage-organization-page/"+strconv.Itoa(organizationID),
+	)
+}
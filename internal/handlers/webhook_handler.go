@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const webhookDeliveriesPageSize = 50
+
+func ViewWebhooksPage(c *gin.Context) {
+	logger.Info(
+		"ViewWebhooksPage - rendering webhooks page",
+		"Client IP", c.ClientIP(),
+		"Username", middlewares.GetCurrentUser(c).Username,
+	)
+	renderWebhooksPage(c, http.StatusOK, nil)
+}
+
+func CreateWebhookPage(c *gin.Context) {
+	currentUser := middlewares.GetCurrentUser(c)
+	webhookURL := strings.TrimSpace(c.PostForm("url"))
+	scopeType, scopeIDValue, _ := strings.Cut(c.PostForm("scope"), ":")
+	scopeID, _ := strconv.Atoi(scopeIDValue)
+
+	events := []string{}
+	for _, event := range c.PostFormArray("events") {
+		if utils.IsValidWebhookEvent(event) && !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	urlErr := utils.ValidateWebhookURL(webhookURL)
+	eventsErr := ""
+	if len(events) == 0 {
+		eventsErr = "Select at least one event"
+	}
+
+	scopeErr := ""
+	endpointVoteID, endpointOrganizationID := uint(0), uint(0)
+	voteTitle := ""
+	switch scopeType {
+	case "vote":
+		if !repositories.IsValidVoteModerator(currentUser.Username, uint(scopeID)) {
+			scopeErr = "You are not allowed to add webhooks to this vote"
+			break
+		}
+		vote, err := repositories.GetVoteDataByVoteID(uint(scopeID))
+		if err != nil {
+			scopeErr = "Vote not found"
+			break
+		}
+		endpointVoteID, voteTitle = vote.VoteID, vote.VoteTitle
+	case "organization":
+		if !repositories.IsValidOrganizationManager(currentUser.Username, uint(scopeID)) {
+			scopeErr = "You are not allowed to add webhooks to this organization"
+			break
+		}
+		endpointOrganizationID = uint(scopeID)
+	default:
+		scopeErr = "Select a vote or an organization"
+	}
+
+	if urlErr != "" || eventsErr != "" || scopeErr != "" {
+		logger.Warn(
+			"CreateWebhookPage - invalid webhook input",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		renderWebhooksPage(c, http.StatusBadRequest, gin.H{
+			"urlErr":    urlErr,
+			"eventsErr": eventsErr,
+			"scopeErr":  scopeErr,
+			"url":       webhookURL,
+		})
+		return
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		logger.Error(
+			"CreateWebhookPage - failed to generate secret",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, "Internal Server Error", "/electivote/webhooks-page/")
+		return
+	}
+
+	endpoint := factories.WebhookEndpointFactory(currentUser.ID, webhookURL, secret, events, models.CustomTime{Time: time.Now()})
+	if endpointVoteID != 0 {
+		endpoint.VoteID = &endpointVoteID
+		endpoint.VoteTitle = voteTitle
+	}
+	if endpointOrganizationID != 0 {
+		endpoint.OrganizationID = &endpointOrganizationID
+	}
+	endpoint, err = repositories.CreateWebhookEndpoint(endpoint)
+	if err != nil {
+		logger.Error(
+			"CreateWebhookPage - failed to create webhook",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, err.Error(), "/electivote/webhooks-page/")
+		return
+	}
+
+	logger.Info(
+		"CreateWebhookPage - webhook created",
+		"Client IP", c.ClientIP(),
+		"Username", currentUser.Username,
+		"Webhook Endpoint ID", endpoint.WebhookEndpointID,
+		"action", "redirecting to webhooks page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/webhooks-page/",
+	)
+}
+
+func DeleteWebhookPage(c *gin.Context) {
+	endpoint, ok := getOwnedWebhookEndpoint(c, "DeleteWebhookPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	err := repositories.DeleteWebhookEndpoint(endpoint.WebhookEndpointID)
+	if err != nil {
+		logger.Error(
+			"DeleteWebhookPage - failed to delete webhook",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, err.Error(), "/electivote/webhooks-page/")
+		return
+	}
+
+	logger.Info(
+		"DeleteWebhookPage - webhook deleted",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Webhook Endpoint ID", endpoint.WebhookEndpointID,
+		"action", "redirecting to webhooks page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		"/electivote/webhooks-page/",
+	)
+}
+
+func ViewWebhookDeliveriesPage(c *gin.Context) {
+	endpoint, ok := getOwnedWebhookEndpoint(c, "ViewWebhookDeliveriesPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	deliveries, err := repositories.GetWebhookDeliveriesByEndpointID(endpoint.WebhookEndpointID, webhookDeliveriesPageSize)
+	if err != nil {
+		logger.Error(
+			"ViewWebhookDeliveriesPage - failed to get deliveries",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, err.Error(), "/electivote/webhooks-page/")
+		return
+	}
+
+	logger.Info(
+		"ViewWebhookDeliveriesPage - rendering webhook deliveries page",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Webhook Endpoint ID", endpoint.WebhookEndpointID,
+	)
+	c.HTML(
+		http.StatusOK,
+		"webhookDeliveries.html",
+		gin.H{
+			"title":      "Webhook Deliveries",
+			"csrfToken":  middlewares.GetCSRFToken(c),
+			"endpoint":   endpoint,
+			"deliveries": deliveries,
+		},
+	)
+}
+
+func ReplayWebhookDeliveryPage(c *gin.Context) {
+	endpoint, ok := getOwnedWebhookEndpoint(c, "ReplayWebhookDeliveryPage")
+	if !ok {
+		return
+	}
+	username := middlewares.GetCurrentUser(c).Username
+	source := "/electivote/webhook-deliveries-page/" + strconv.Itoa(int(endpoint.WebhookEndpointID)) + "/"
+	webhookDeliveryID, _ := strconv.Atoi(c.Param("webhookDeliveryID"))
+	delivery, err := repositories.GetWebhookDeliveryByID(uint(webhookDeliveryID))
+	if err != nil || delivery.WebhookEndpointID != endpoint.WebhookEndpointID {
+		logger.Warn(
+			"ReplayWebhookDeliveryPage - delivery not found",
+			"Client IP", c.ClientIP(),
+			"Username", username,
+			"Webhook Delivery ID", webhookDeliveryID,
+		)
+		utils.RenderError(c, http.StatusNotFound, "Delivery not found", source)
+		return
+	}
+
+	replay, err := utils.ReplayWebhookDelivery(delivery)
+	if err != nil {
+		logger.Error(
+			"ReplayWebhookDeliveryPage - failed to replay delivery",
+			"error", err.Error(),
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		utils.RenderError(c, http.StatusInternalServerError, err.Error(), source)
+		return
+	}
+
+	logger.Info(
+		"ReplayWebhookDeliveryPage - delivery replayed",
+		"Client IP", c.ClientIP(),
+		"Username", username,
+		"Webhook Delivery ID", delivery.WebhookDeliveryID,
+		"Replay Delivery ID", replay.WebhookDeliveryID,
+		"action", "redirecting to webhook deliveries page",
+	)
+	c.Redirect(
+		http.StatusFound,
+		source,
+	)
+}
+
+func getOwnedWebhookEndpoint(c *gin.Context, source string) (models.WebhookEndpoint, bool) {
+	currentUser := middlewares.GetCurrentUser(c)
+	webhookEndpointID, _ := strconv.Atoi(c.Param("webhookEndpointID"))
+	endpoint, err := repositories.GetWebhookEndpointByID(uint(webhookEndpointID))
+	if err != nil || endpoint.ModeratorID != currentUser.ID {
+		logger.Warn(
+			source+" - User does not own the webhook",
+			"Client IP", c.ClientIP(),
+			"Username", currentUser.Username,
+			"action", "redirecting to webhooks page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/webhooks-page/",
+		)
+		return models.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+func renderWebhooksPage(c *gin.Context, statusCode int, extra gin.H) {
+	currentUser := middlewares.GetCurrentUser(c)
+	endpoints, endpointsErr := repositories.GetWebhookEndpointsByModeratorID(currentUser.ID)
+	votes, votesErr := repositories.GetVotesDataByUsername(currentUser.Username)
+	organizations, organizationsErr := repositories.GetManagedOrganizationsByUsername(currentUser.Username)
+	for _, err := range []error{endpointsErr, votesErr, organizationsErr} {
+		if err != nil {
+			logger.Error(
+				"renderWebhooksPage - failed to load webhooks page data",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", currentUser.Username,
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				err.Error(),
+				"/electivote/home-page/",
+			)
+			return
+		}
+	}
+
+	context := gin.H{
+		"title":         "Webhooks",
+		"csrfToken":     middlewares.GetCSRFToken(c),
+		"endpoints":     endpoints,
+		"votes":         votes,
+		"organizations": organizations,
+		"webhookEvents": utils.WebhookEvents,
+	}
+	for key, value := range extra {
+		context[key] = value
+	}
+	c.HTML(
+		statusCode,
+		"webhooks.html",
+		context,
+	)
+}
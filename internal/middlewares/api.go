@@ -0,0 +1,158 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiScopesKey = "apiScopes"
+
+	// personalAccessTokenTouchInterval limits how often last-used tracking
+	// writes to the database for a busy token.
+	personalAccessTokenTouchInterval = time.Minute
+)
+
+// APIAuthRequired authenticates /api/v1 requests and stores the user like
+// AuthRequired. An "Authorization: Bearer <token>" header may carry either a
+// personal access token or a token from /auth/login; without the header the
+// browser session or remember cookie is used, and unsafe methods must then
+// send the session CSRF token in the X-CSRF-Token header.
+func APIAuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			authenticateAPISession(c)
+			return
+		}
+
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		tokenString = strings.TrimSpace(tokenString)
+		if !found || tokenString == "" {
+			utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Missing bearer token", nil)
+			return
+		}
+		if strings.HasPrefix(tokenString, utils.PersonalAccessTokenPrefix) {
+			authenticatePersonalAccessToken(c, tokenString)
+			return
+		}
+
+		username, fingerprint, err := utils.ParseAPIToken(tokenString)
+		if err != nil {
+			LogSecurityEvent(c, "api_token_rejected", "", "Path", c.Request.URL.Path, "error", err.Error())
+			utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token", nil)
+			return
+		}
+
+		user, err := repositories.GetUserByUsername(username)
+		if err != nil || user.Suspended || !utils.IsCurrentAPIToken(user, fingerprint) {
+			LogSecurityEvent(c, "api_token_rejected", username, "Path", c.Request.URL.Path)
+			utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token", nil)
+			return
+		}
+		c.Set(currentUserKey, user)
+		c.Next()
+	}
+}
+
+func authenticatePersonalAccessToken(c *gin.Context, tokenString string) {
+	token, err := repositories.GetPersonalAccessTokenByHash(utils.HashPersonalAccessToken(tokenString))
+	if err != nil {
+		LogSecurityEvent(c, "api_token_rejected", "", "Path", c.Request.URL.Path, "error", "unknown personal access token")
+		utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token", nil)
+		return
+	}
+	now := time.Now()
+	if token.User.Suspended || now.After(token.ExpiresTime.Time) {
+		LogSecurityEvent(c, "api_token_rejected", token.User.Username, "Path", c.Request.URL.Path, "Token Prefix", token.TokenPrefix)
+		utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token", nil)
+		return
+	}
+
+	if now.Sub(token.LastUsedTime.Time) > personalAccessTokenTouchInterval {
+		err = repositories.UpdatePersonalAccessTokenLastUsed(token.PersonalAccessTokenID, models.CustomTime{Time: now}, c.ClientIP())
+		if err != nil {
+			logger.Error(
+				"authenticatePersonalAccessToken - failed to update last used time",
+				"error", err.Error(),
+				"Client IP", c.ClientIP(),
+				"Username", token.User.Username,
+			)
+		}
+	}
+	c.Set(currentUserKey, token.User)
+	c.Set(apiScopesKey, token.Scopes)
+	c.Next()
+}
+
+func authenticateAPISession(c *gin.Context) {
+	username := GetUserData(c)
+	if username == "" {
+		utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Missing bearer token", nil)
+		return
+	}
+	user, err := repositories.GetUserByUsername(username)
+	if err != nil || user.Suspended {
+		LogSecurityEvent(c, "api_session_rejected", username, "Path", c.Request.URL.Path)
+		utils.RenderAPIError(c, http.StatusUnauthorized, "unauthorized", "Session is no longer valid", nil)
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		token, _ := sessions.Default(c).Get(csrfSessionKey).(string)
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(CSRFHeader)), []byte(token)) != 1 {
+			LogSecurityEvent(c, "csrf_rejected", username, "Path", c.Request.URL.Path)
+			utils.RenderAPIError(c, http.StatusForbidden, "csrf_rejected", "Missing or invalid "+CSRFHeader+" header", nil)
+			return
+		}
+	}
+	c.Set(currentUserKey, user)
+	c.Next()
+}
+
+// RequireAPIScope restricts personal access tokens to the scopes they were
+// granted. Session and login tokens act with the user's full permissions.
+func RequireAPIScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, restricted := c.Get(apiScopesKey)
+		if !restricted || utils.HasAPIScope(granted.(string), scope) {
+			c.Next()
+			return
+		}
+		logger.Warn(
+			"RequireAPIScope - token is missing scope",
+			"Client IP", c.ClientIP(),
+			"Username", GetCurrentUser(c).Username,
+			"Path", c.Request.URL.Path,
+			"Scope", scope,
+		)
+		utils.RenderAPIError(c, http.StatusForbidden, "insufficient_scope", "This token is missing the "+scope+" scope", nil)
+	}
+}
+
+func APIRequireModeratorTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetCurrentUser(c)
+		if user.TwoFactorEnabled || !IsTwoFactorRequired(user) {
+			c.Next()
+			return
+		}
+		logger.Warn(
+			"APIRequireModeratorTwoFactor - two-factor authentication is required",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+			"Path", c.Request.URL.Path,
+		)
+		utils.RenderAPIError(c, http.StatusForbidden, "two_factor_required", "Two-factor authentication must be enabled to manage votes", nil)
+	}
+}
@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "currentUser"
+
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := GetUserData(c)
+		if username == "" {
+			logger.Warn(
+				"AuthRequired - User is not logged in",
+				"Client IP", c.ClientIP(),
+				"Path", c.Request.URL.Path,
+				"action", "redirecting to login page",
+			)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/login-page/",
+			)
+			c.Abort()
+			return
+		}
+
+		user, err := repositories.GetUserByUsername(username)
+		if err != nil || user.Suspended {
+			logger.Warn(
+				"AuthRequired - User is not found or suspended",
+				"Client IP", c.ClientIP(),
+				"Username", username,
+				"Path", c.Request.URL.Path,
+				"action", "clearing session and redirecting to login page",
+			)
+			DeleteSession(c)
+			DeleteCookie(c)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/login-page/",
+			)
+			c.Abort()
+			return
+		}
+		c.Set(currentUserKey, user)
+		c.Next()
+	}
+}
+
+func GuestOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsLogged(c) {
+			logger.Warn(
+				"GuestOnly - User already logged in",
+				"Client IP", c.ClientIP(),
+				"Path", c.Request.URL.Path,
+				"action", "redirecting to home page",
+			)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/home-page/",
+			)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetCurrentUser(c)
+		if user.Role != role {
+			logger.Warn(
+				"RequireRole - User is not authorized",
+				"Client IP", c.ClientIP(),
+				"Username", user.Username,
+				"Required Role", role,
+				"Path", c.Request.URL.Path,
+				"action", "redirecting to home page",
+			)
+			c.Redirect(
+				http.StatusFound,
+				"/electivote/home-page/",
+			)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func GetCurrentUser(c *gin.Context) models.User {
+	value, exists := c.Get(currentUserKey)
+	if !exists {
+		return models.User{}
+	}
+	user, ok := value.(models.User)
+	if !ok {
+		return models.User{}
+	}
+	return user
+}
+
+func IsAdmin(c *gin.Context) bool {
+	return GetCurrentUser(c).Role == "admin"
+}
+
+// IsTwoFactorRequired applies the system-wide moderator policy first, then any
+// organization the user manages that opted into requiring 2FA.
+func IsTwoFactorRequired(user models.User) bool {
+	if os.Getenv("REQUIRE_MODERATOR_TWO_FACTOR") == "true" {
+		return true
+	}
+	return repositories.IsTwoFactorRequiredByManagedOrganization(user.ID)
+}
+
+func RequireModeratorTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetCurrentUser(c)
+		if user.TwoFactorEnabled || !IsTwoFactorRequired(user) {
+			c.Next()
+			return
+		}
+		logger.Warn(
+			"RequireModeratorTwoFactor - two-factor authentication is required",
+			"Client IP", c.ClientIP(),
+			"Username", user.Username,
+			"Path", c.Request.URL.Path,
+			"action", "redirecting to two-factor setup page",
+		)
+		c.Redirect(
+			http.StatusFound,
+			"/electivote/two-factor-setup-page/",
+		)
+		c.Abort()
+	}
+}
@@ -1,11 +1,15 @@
 package middlewares
 
 import (
+	"crypto/subtle"
 	"log/slog"
 	"os"
 	"time"
 
 	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
 	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -13,75 +17,199 @@ import (
 var cookieKey string = os.Getenv("COOKIE_KEY")
 var logger *slog.Logger = config.SetUpLogger()
 
+const rememberedUserKey = "rememberedUser"
+
+func setRememberCookie(c *gin.Context, value string, maxAge int) {
+	c.SetCookie(
+		cookieKey,
+		value,
+		maxAge,
+		"/",
+		"localhost",
+		false,
+		true,
+	)
+}
+
 func SetCookies(c *gin.Context, username string) {
-	token, err := utils.GenerateSecureToken(username)
+	userID, err := repositories.GetUserIdByUsername(username)
 	if err != nil {
 		logger.Error(
-			"SetCookies - error generating secure token",
+			"SetCookies - error getting user ID",
 			"error", err,
+			"Username", username,
 		)
 		return
 	}
-	expiration := time.Now().Add(7 * 24 * time.Hour)
-	c.SetCookie(
-		cookieKey,
-		token,
-		int(expiration.Unix()),
-		"/",
-		"localhost",
-		false,
-		true,
+	selector, validator, err := utils.GenerateRememberToken()
+	if err != nil {
+		logger.Error(
+			"SetCookies - error generating remember token",
+			"error", err,
+		)
+		return
+	}
+
+	if err := repositories.DeleteExpiredRememberTokens(); err != nil {
+		logger.Error(
+			"SetCookies - error deleting expired remember tokens",
+			"error", err,
+		)
+	}
+
+	now := time.Now()
+	newToken := factories.RememberTokenFactory(
+		uint(userID),
+		selector,
+		utils.HashRememberToken(validator),
+		c.Request.UserAgent(),
+		c.ClientIP(),
+		models.CustomTime{Time: now},
+		models.CustomTime{Time: now.Add(utils.RememberMeDuration)},
 	)
+	_, err = repositories.CreateRememberToken(newToken)
+	if err != nil {
+		logger.Error(
+			"SetCookies - error saving remember token",
+			"error", err,
+			"Username", username,
+		)
+		return
+	}
+	setRememberCookie(c, selector+":"+validator, int(utils.RememberMeDuration.Seconds()))
 }
 
+// GetCookies rotates the token on first use, so the result is cached for the rest of the request.
 func GetCookies(c *gin.Context) string {
+	if cached, exists := c.Get(rememberedUserKey); exists {
+		return cached.(string)
+	}
+	username := validateRememberToken(c)
+	c.Set(rememberedUserKey, username)
+	return username
+}
+
+func validateRememberToken(c *gin.Context) string {
 	cookie, err := c.Cookie(cookieKey)
+	if err != nil || cookie == "" {
+		return ""
+	}
+	selector, validator, err := utils.SplitRememberToken(cookie)
+	if err != nil {
+		logger.Warn(
+			"GetCookies - malformed remember token",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+		setRememberCookie(c, "", -1)
+		return ""
+	}
+
+	token, err := repositories.GetRememberTokenBySelector(selector)
+	if err != nil {
+		logger.Warn(
+			"GetCookies - remember token not found",
+			"Client IP", c.ClientIP(),
+		)
+		setRememberCookie(c, "", -1)
+		return ""
+	}
+
+	// The validator replaced by a rotation moments ago belongs to a request
+	// sent in parallel with the one that rotated it, which set the new cookie.
+	now := time.Now()
+	tokenHash := utils.HashRememberToken(validator)
+	if token.PreviousHash != "" &&
+		subtle.ConstantTimeCompare([]byte(token.PreviousHash), []byte(tokenHash)) == 1 &&
+		now.Sub(token.LastUsedTime.Time) < utils.RememberTokenGracePeriod {
+		SetSession(c, token.User.Username)
+		return token.User.Username
+	}
+
+	// A known selector with the wrong validator means the token was stolen
+	// and already used, so every remembered login of the user is dropped.
+	if subtle.ConstantTimeCompare([]byte(token.TokenHash), []byte(tokenHash)) != 1 {
+		logger.Warn(
+			"GetCookies - remember token hash mismatch, revoking remembered logins",
+			"Client IP", c.ClientIP(),
+			"Username", token.User.Username,
+		)
+		if err := repositories.DeleteRememberTokensByUserID(token.UserId); err != nil {
+			logger.Error(
+				"GetCookies - error deleting remember tokens",
+				"error", err,
+				"Client IP", c.ClientIP(),
+				"Username", token.User.Username,
+			)
+		}
+		setRememberCookie(c, "", -1)
+		return ""
+	}
+
+	if !token.ExpiresTime.After(now) {
+		logger.Info(
+			"GetCookies - remember token expired",
+			"Client IP", c.ClientIP(),
+			"Username", token.User.Username,
+		)
+		repositories.DeleteRememberToken(token.RememberTokenID)
+		setRememberCookie(c, "", -1)
+		return ""
+	}
+
+	_, newValidator, err := utils.GenerateRememberToken()
 	if err != nil {
 		logger.Error(
-			"GetCookies - error getting cookies",
+			"GetCookies - error generating remember token",
 			"error", err,
 			"Client IP", c.ClientIP(),
 		)
 		return ""
-	} 
-	resultCookie, err := utils.ExtractUsername(cookie)
+	}
+	rotated, err := repositories.RotateRememberToken(
+		token.RememberTokenID,
+		token.TokenHash,
+		utils.HashRememberToken(newValidator),
+		models.CustomTime{Time: now},
+	)
 	if err != nil {
 		logger.Error(
-			"GetCookies - error extracting username from cookies",
+			"GetCookies - error rotating remember token",
 			"error", err,
 			"Client IP", c.ClientIP(),
 		)
 		return ""
 	}
-	return resultCookie
+	// When a parallel request rotated first, its response carries the new
+	// cookie and this one keeps the cookie it came with.
+	if rotated {
+		setRememberCookie(c, selector+":"+newValidator, int(token.ExpiresTime.Sub(now).Seconds()))
+	}
+	// The session carries the login from here on, so the token is rotated once
+	// per remembered login instead of on every request.
+	SetSession(c, token.User.Username)
+	return token.User.Username
 }
 
 func IsLogged(c *gin.Context) bool {
-	if GetSession(c) != "" {
-		return true
-	} else if GetCookies(c) != "" {
-		return true
-	}
-	return false
+	return GetUserData(c) != ""
 }
 
 func DeleteCookie(c *gin.Context) {
-	c.SetCookie(
-		cookieKey,
-		"",
-		-1,
-		"/",
-		"localhost",
-		false,
-		true,
-	)
+	if cookie, err := c.Cookie(cookieKey); err == nil {
+		if selector, _, err := utils.SplitRememberToken(cookie); err == nil {
+			if token, err := repositories.GetRememberTokenBySelector(selector); err == nil {
+				repositories.DeleteRememberToken(token.RememberTokenID)
+			}
+		}
+	}
+	c.Set(rememberedUserKey, "")
+	setRememberCookie(c, "", -1)
 }
 
 func GetUserData(c *gin.Context) string {
-	if GetSession(c) != "" {
-		return GetSession(c)
-	} else if GetCookies(c) != "" {
-		return GetCookies(c)
+	if username := GetSession(c); username != "" {
+		return username
 	}
-	return ""
-}
\ No newline at end of file
+	return GetCookies(c)
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	csrfSessionKey   = "csrfToken"
+	csrfContextKey   = "csrfToken"
+	CSRFFormField    = "csrf_token"
+	CSRFHeader       = "X-CSRF-Token"
+	ConfirmFormField = "confirmation_token"
+)
+
+// CSRFProtection keeps one synchronizer token per session and rejects unsafe
+// requests that do not echo it back. Paths in exemptPaths (e.g. signed webhooks)
+// are let through.
+func CSRFProtection(exemptPaths ...string) gin.HandlerFunc {
+	exempt := map[string]bool{}
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+	return func(c *gin.Context) {
+		token, err := getOrCreateCSRFToken(c)
+		if err != nil {
+			logger.Error(
+				"CSRFProtection - error creating CSRF token",
+				"error", err,
+				"Client IP", c.ClientIP(),
+			)
+			utils.RenderError(
+				c,
+				http.StatusInternalServerError,
+				"Internal Server Error",
+				"/electivote/home-page/",
+			)
+			c.Abort()
+			return
+		}
+		c.Set(csrfContextKey, token)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		submitted := c.GetHeader(CSRFHeader)
+		if submitted == "" {
+			submitted = c.PostForm(CSRFFormField)
+		}
+		if subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+			LogSecurityEvent(c, "csrf_rejected", GetUserData(c), "Path", c.Request.URL.Path)
+			utils.RenderError(
+				c,
+				http.StatusForbidden,
+				"Your form has expired, please go back and try again",
+				"/electivote/home-page/",
+			)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func getOrCreateCSRFToken(c *gin.Context) (string, error) {
+	session := sessions.Default(c)
+	if token, ok := session.Get(csrfSessionKey).(string); ok && token != "" {
+		return token, nil
+	}
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	session.Set(csrfSessionKey, token)
+	err = session.Save()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func GetCSRFToken(c *gin.Context) string {
+	return c.GetString(csrfContextKey)
+}
+
+// GetConfirmationToken binds a destructive action (e.g. "delete-vote:12") to
+// the current session so the confirm page has to be visited before the action.
+func GetConfirmationToken(c *gin.Context, action string) string {
+	h := hmac.New(sha256.New, []byte(GetCSRFToken(c)))
+	h.Write([]byte(action))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func IsValidConfirmationToken(c *gin.Context, action string) bool {
+	expected := GetConfirmationToken(c, action)
+	return subtle.ConstantTimeCompare([]byte(c.PostForm(ConfirmFormField)), []byte(expected)) == 1
+}
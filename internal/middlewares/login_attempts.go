@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	loginFailureWindow      = 15 * time.Minute
+	loginBackoffThreshold   = 3
+	loginMaxBackoff         = 5 * time.Minute
+	accountLockoutThreshold = 10
+	accountLockoutDuration  = 30 * time.Minute
+	ipLockoutThreshold      = 50
+	ipLockoutDuration       = 30 * time.Minute
+)
+
+var securityLogger = logger.With("category", "security")
+
+func LogSecurityEvent(c *gin.Context, event, username string, attrs ...any) {
+	args := []any{
+		"event", event,
+		"Username", username,
+		"Client IP", c.ClientIP(),
+		"User Agent", c.Request.UserAgent(),
+	}
+	securityLogger.Warn("SecurityEvent - "+event, append(args, attrs...)...)
+}
+
+func accountFailureKey(username string) string { return "login_failures:user:" + username }
+func ipFailureKey(ip string) string            { return "login_failures:ip:" + ip }
+func accountBackoffKey(username string) string { return "login_backoff:user:" + username }
+func accountLockoutKey(username string) string { return "login_lockout:user:" + username }
+func ipLockoutKey(ip string) string            { return "login_lockout:ip:" + ip }
+
+// GetLoginWait reports how long the client must wait before another login
+// attempt, taking the largest of the account/IP lockouts and the backoff delay.
+func GetLoginWait(c *gin.Context, username string) time.Duration {
+	client := config.GetRedisClient()
+	ctx := c.Request.Context()
+	wait := time.Duration(0)
+	keys := []string{ipLockoutKey(c.ClientIP())}
+	if username != "" {
+		keys = append(keys, accountLockoutKey(username), accountBackoffKey(username))
+	}
+	for _, key := range keys {
+		ttl, err := client.TTL(ctx, key).Result()
+		if err != nil {
+			logger.Error(
+				"GetLoginWait - error reading login throttle",
+				"error", err,
+				"Client IP", c.ClientIP(),
+			)
+			continue
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait
+}
+
+func RecordFailedLogin(c *gin.Context, username, reason string) {
+	client := config.GetRedisClient()
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	ipFailures, err := incrementWithWindow(ctx, client, ipFailureKey(ip))
+	if err != nil {
+		logger.Error(
+			"RecordFailedLogin - error counting IP failures",
+			"error", err,
+			"Client IP", ip,
+		)
+	}
+	if ipFailures >= ipLockoutThreshold {
+		client.Set(ctx, ipLockoutKey(ip), ipFailures, ipLockoutDuration)
+		LogSecurityEvent(c, "ip_locked", username, "Failures", ipFailures)
+	}
+
+	if username == "" {
+		LogSecurityEvent(c, "login_failed", username, "Reason", reason, "IP Failures", ipFailures)
+		return
+	}
+
+	accountFailures, err := incrementWithWindow(ctx, client, accountFailureKey(username))
+	if err != nil {
+		logger.Error(
+			"RecordFailedLogin - error counting account failures",
+			"error", err,
+			"Client IP", ip,
+		)
+	}
+	LogSecurityEvent(c, "login_failed", username, "Reason", reason, "Account Failures", accountFailures, "IP Failures", ipFailures)
+
+	if accountFailures >= accountLockoutThreshold {
+		locked, err := client.SetNX(ctx, accountLockoutKey(username), accountFailures, accountLockoutDuration).Result()
+		if err == nil && locked {
+			LogSecurityEvent(c, "account_locked", username, "Failures", accountFailures, "Duration", accountLockoutDuration.String())
+			sendLockoutEmail(username)
+		}
+		return
+	}
+
+	if accountFailures >= loginBackoffThreshold {
+		backoff := time.Duration(math.Pow(2, float64(accountFailures-loginBackoffThreshold))) * time.Second
+		if backoff > loginMaxBackoff {
+			backoff = loginMaxBackoff
+		}
+		client.Set(ctx, accountBackoffKey(username), accountFailures, backoff)
+	}
+}
+
+func ResetFailedLogins(c *gin.Context, username string) {
+	err := config.GetRedisClient().Del(
+		c.Request.Context(),
+		accountFailureKey(username),
+		accountBackoffKey(username),
+	).Err()
+	if err != nil {
+		logger.Error(
+			"ResetFailedLogins - error clearing login failures",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+	LogSecurityEvent(c, "login_succeeded", username)
+}
+
+func IsAccountLocked(c *gin.Context, username string) bool {
+	exists, err := config.GetRedisClient().Exists(c.Request.Context(), accountLockoutKey(username)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logger.Error(
+			"IsAccountLocked - error reading account lockout",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+		return false
+	}
+	return exists > 0
+}
+
+func UnlockAccount(c *gin.Context, username string) error {
+	err := config.GetRedisClient().Del(
+		c.Request.Context(),
+		accountFailureKey(username),
+		accountBackoffKey(username),
+		accountLockoutKey(username),
+	).Err()
+	if err != nil {
+		return err
+	}
+	LogSecurityEvent(c, "account_unlocked", username, "Unlocked By", GetCurrentUser(c).Username)
+	return nil
+}
+
+func incrementWithWindow(ctx context.Context, client *redis.Client, key string) (int64, error) {
+	count, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		client.Expire(ctx, key, loginFailureWindow)
+	}
+	return count, nil
+}
+
+func sendLockoutEmail(username string) {
+	user, err := repositories.GetUserByUsername(username)
+	if err != nil {
+		logger.Error(
+			"sendLockoutEmail - error getting user",
+			"error", err,
+			"Username", username,
+		)
+		return
+	}
+	err = utils.QueueTemplateEmail(user.Email, user.Locale, "account_locked", map[string]interface{}{
+		"username":       username,
+		"lockoutMinutes": int(accountLockoutDuration.Minutes()),
+	})
+	if err != nil {
+		logger.Error(
+			"sendLockoutEmail - error queueing lockout email",
+			"error", err,
+			"Username", username,
+		)
+	}
+}
@@ -4,16 +4,73 @@ import (
 	"os"
 	"time"
 
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	gorillaSessions "github.com/gorilla/sessions"
 )
 
 var sessionKey string = os.Getenv("SESSION_KEY")
 
+// SetSession signs the user in on this session. The session always gets a
+// new ID, so an ID planted before login cannot be used to ride the login.
 func SetSession(c *gin.Context, value string) {
 	session := sessions.Default(c)
+	discardSessionID(c)
 	session.Set(sessionKey, value)
-	session.Save()
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetSession - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+		return
+	}
+	err := config.GetSessionStore().TrackUserSession(c.Request.Context(), value, session.ID())
+	if err != nil {
+		logger.Error(
+			"SetSession - error tracking user session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+			"Username", value,
+		)
+	}
+}
+
+// RegenerateSession moves the signed in session to a new ID, for when the
+// user gains privileges such as enabling two-factor authentication.
+func RegenerateSession(c *gin.Context) {
+	if username, ok := sessions.Default(c).Get(sessionKey).(string); ok && username != "" {
+		SetSession(c, username)
+	}
+}
+
+// discardSessionID drops the current session from Redis and clears its ID, so
+// the next save stores the values under a freshly generated one.
+func discardSessionID(c *gin.Context) {
+	underlying, ok := sessions.Default(c).(interface {
+		Session() *gorillaSessions.Session
+	})
+	if !ok {
+		return
+	}
+	session := underlying.Session()
+	if session.ID == "" {
+		return
+	}
+	store := config.GetSessionStore()
+	if username, ok := session.Values[sessionKey].(string); ok {
+		store.UntrackUserSession(c.Request.Context(), username, session.ID)
+	}
+	if err := store.DeleteSession(c.Request.Context(), session.ID); err != nil {
+		logger.Error(
+			"discardSessionID - error deleting session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+	session.ID = ""
 }
 
 func GetSession(c *gin.Context) string {
@@ -31,10 +88,45 @@ func GetSession(c *gin.Context) string {
 
 func DeleteSession(c *gin.Context) {
 	session := sessions.Default(c)
+	if username, ok := session.Get(sessionKey).(string); ok {
+		config.GetSessionStore().UntrackUserSession(c.Request.Context(), username, session.ID())
+	}
 	session.Delete(sessionKey)
 	session.Save()
 }
 
+// RevokeAllSessions signs the user out everywhere: web sessions, remembered
+// logins and the API tokens issued to them.
+func RevokeAllSessions(c *gin.Context, username string) error {
+	err := config.GetSessionStore().RevokeUserSessions(c.Request.Context(), username)
+	if err != nil {
+		return err
+	}
+	userID, err := repositories.GetUserIdByUsername(username)
+	if err != nil {
+		return err
+	}
+	err = repositories.BumpSessionGeneration(uint(userID))
+	if err != nil {
+		return err
+	}
+	return repositories.DeleteRememberTokensByUserID(uint(userID))
+}
+
+func CountActiveSessions(c *gin.Context, username string) int {
+	total, err := config.GetSessionStore().CountUserSessions(c.Request.Context(), username)
+	if err != nil {
+		logger.Error(
+			"CountActiveSessions - error counting user sessions",
+			"error", err,
+			"Client IP", c.ClientIP(),
+			"Username", username,
+		)
+		return 0
+	}
+	return total
+}
+
 func SetRegisterSession(c *gin.Context, username, email, password, otp string) {
 	session := sessions.Default(c)
 	session.Set("username", username)
@@ -58,4 +150,138 @@ func DeleteRegisterSession(c *gin.Context) {
 	session.Delete("password")
 	session.Delete("otp")
 	session.Save()
-}
\ No newline at end of file
+}
+
+func SetTwoFactorSession(c *gin.Context, username string, remember bool) {
+	session := sessions.Default(c)
+	session.Set("twoFactorUsername", username)
+	session.Set("twoFactorRemember", remember)
+	session.Set("twoFactorCreatedAt", time.Now().Unix())
+	session.Set("twoFactorAttempts", 0)
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetTwoFactorSession - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+}
+
+func IncrementTwoFactorAttempts(c *gin.Context) int {
+	session := sessions.Default(c)
+	attempts, _ := session.Get("twoFactorAttempts").(int)
+	attempts++
+	session.Set("twoFactorAttempts", attempts)
+	session.Save()
+	return attempts
+}
+
+func DeleteTwoFactorSession(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Delete("twoFactorUsername")
+	session.Delete("twoFactorRemember")
+	session.Delete("twoFactorCreatedAt")
+	session.Delete("twoFactorAttempts")
+	session.Save()
+}
+
+func SetPendingTwoFactorSecret(c *gin.Context, secret string) {
+	session := sessions.Default(c)
+	session.Set("pendingTwoFactorSecret", secret)
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetPendingTwoFactorSecret - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+}
+
+func DeletePendingTwoFactorSecret(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Delete("pendingTwoFactorSecret")
+	session.Save()
+}
+
+func SetOIDCSession(c *gin.Context, provider, state, nonce, verifier string) {
+	session := sessions.Default(c)
+	session.Set("oidcProvider", provider)
+	session.Set("oidcState", state)
+	session.Set("oidcNonce", nonce)
+	session.Set("oidcVerifier", verifier)
+	session.Set("oidcCreatedAt", time.Now().Unix())
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetOIDCSession - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+}
+
+func DeleteOIDCSession(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Delete("oidcProvider")
+	session.Delete("oidcState")
+	session.Delete("oidcNonce")
+	session.Delete("oidcVerifier")
+	session.Delete("oidcCreatedAt")
+	session.Save()
+}
+
+func SetChangeEmailSession(c *gin.Context, email, otp string) {
+	session := sessions.Default(c)
+	session.Set("changeEmail", email)
+	session.Set("changeEmailOtp", otp)
+	session.Set("changeEmailCreatedAt", time.Now().Unix())
+	session.Set("changeEmailAttempts", 0)
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetChangeEmailSession - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+}
+
+func IncrementChangeEmailAttempts(c *gin.Context) int {
+	session := sessions.Default(c)
+	attempts, _ := session.Get("changeEmailAttempts").(int)
+	attempts++
+	session.Set("changeEmailAttempts", attempts)
+	session.Save()
+	return attempts
+}
+
+func DeleteChangeEmailSession(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Delete("changeEmail")
+	session.Delete("changeEmailOtp")
+	session.Delete("changeEmailCreatedAt")
+	session.Delete("changeEmailAttempts")
+	session.Save()
+}
+
+// SetNewAPITokenFlash keeps a freshly created personal access token in the
+// session so the profile page can show it exactly once.
+func SetNewAPITokenFlash(c *gin.Context, token string) {
+	session := sessions.Default(c)
+	session.Set("newAPIToken", token)
+	if err := session.Save(); err != nil {
+		logger.Error(
+			"SetNewAPITokenFlash - error saving session",
+			"error", err,
+			"Client IP", c.ClientIP(),
+		)
+	}
+}
+
+func PopNewAPITokenFlash(c *gin.Context) string {
+	session := sessions.Default(c)
+	token, _ := session.Get("newAPIToken").(string)
+	if token != "" {
+		session.Delete("newAPIToken")
+		session.Save()
+	}
+	return token
+}
@@ -5,6 +5,7 @@ type Candidate struct {
 	CandidateName        string `gorm:"type:varchar(255);not null"`
 	CandidateDescription string `gorm:"type:text;default:NULL"`
 	TotalVotes           uint   `gorm:"type:int;default:0"`
+	// CandidatePicture is the blob store key of the picture.
 	CandidatePicture     string `gorm:"type:varchar(255);default:NULL"`
 	VoteId               uint
 	Vote                 Vote   `gorm:"foreignKey:VoteId;constraint:OnDelete:CASCADE;"`
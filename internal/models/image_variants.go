@@ -0,0 +1,12 @@
+package models
+
+// ImageVariants records the resized copies written for a stored image, so
+// pages can link them without reading the blob store.
+type ImageVariants struct {
+	ImageName string `gorm:"primary_key;type:varchar(255)"`
+	Width     int    `gorm:"not null"`
+	Height    int    `gorm:"not null"`
+	Thumbnail bool   `gorm:"default:false"`
+	// Widths lists the widths of the width variants, comma separated.
+	Widths string `gorm:"type:varchar(64);not null;default:''"`
+}
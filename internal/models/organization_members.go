@@ -0,0 +1,11 @@
+package models
+
+type OrganizationMember struct {
+	OrganizationMemberID uint `gorm:"primary_key"`
+	OrganizationId       uint
+	Organization         Organization `gorm:"foreignKey:OrganizationId;constraint:OnDelete:CASCADE;"`
+	UserId               uint
+	User                 User       `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+	MemberRole           string     `gorm:"type:enum('owner', 'admin', 'member');not null"`
+	JoinedTime           CustomTime `gorm:"type:datetime;default:NULL"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type Organization struct {
+	OrganizationID   uint   `gorm:"primary_key"`
+	OrganizationName string `gorm:"type:varchar(255);not null"`
+	OrganizationLogo string `gorm:"type:varchar(255);default:NULL"`
+	OwnerID          uint
+	User             User       `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE;"`
+	CreatedTime      CustomTime `gorm:"type:datetime;default:NULL"`
+	RequireTwoFactor bool       `gorm:"default:false"`
+}
+
+type OrganizationVotes struct {
+	Organization Organization
+	Votes        []Vote
+}
@@ -0,0 +1,15 @@
+package models
+
+type OutboundEmail struct {
+	OutboundEmailID uint       `gorm:"primary_key"`
+	Recipient       string     `gorm:"type:varchar(255);not null"`
+	Subject         string     `gorm:"type:varchar(255);not null"`
+	Body            string     `gorm:"type:mediumtext;not null"`
+	TextBody        string     `gorm:"type:mediumtext;default:NULL"`
+	Status          string     `gorm:"type:enum('pending', 'sending', 'sent', 'dead');default:'pending';not null;index"`
+	Attempts        uint       `gorm:"type:int;default:0"`
+	LastError       string     `gorm:"type:text;default:NULL"`
+	NextAttemptTime CustomTime `gorm:"type:datetime;default:NULL;index"`
+	CreatedTime     CustomTime `gorm:"type:datetime;default:NULL"`
+	SentTime        CustomTime `gorm:"type:datetime;default:NULL"`
+}
@@ -0,0 +1,15 @@
+package models
+
+type PersonalAccessToken struct {
+	PersonalAccessTokenID uint `gorm:"primary_key"`
+	UserId                uint
+	User                  User       `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+	Name                  string     `gorm:"type:varchar(100);not null"`
+	TokenPrefix           string     `gorm:"type:varchar(16);not null"`
+	TokenHash             string     `gorm:"unique;type:varchar(64);not null"`
+	Scopes                string     `gorm:"type:varchar(255);not null"`
+	CreatedTime           CustomTime `gorm:"type:datetime;default:NULL"`
+	ExpiresTime           CustomTime `gorm:"type:datetime;default:NULL"`
+	LastUsedTime          CustomTime `gorm:"type:datetime;default:NULL"`
+	LastUsedIP            string     `gorm:"type:varchar(64);default:NULL"`
+}
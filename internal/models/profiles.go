@@ -8,6 +8,7 @@ import (
 
 type Profile struct {
 	ProfileID   uint     `gorm:"primary_key"`
+	// Picture is the blob store key of the profile picture.
 	Picture     string   `gorm:"type:varchar(255)"`
 	FirstName   string   `gorm:"type:varchar(255)"`
 	LastName    string   `gorm:"type:varchar(255)"`
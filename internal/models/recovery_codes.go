@@ -0,0 +1,9 @@
+package models
+
+type RecoveryCode struct {
+	RecoveryCodeID uint `gorm:"primary_key"`
+	UserId         uint
+	User           User       `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+	CodeHash       string     `gorm:"type:varchar(64);not null;index"`
+	UsedTime       CustomTime `gorm:"type:datetime;default:NULL"`
+}
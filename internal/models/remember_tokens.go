@@ -0,0 +1,15 @@
+package models
+
+type RememberToken struct {
+	RememberTokenID uint `gorm:"primary_key"`
+	UserId          uint
+	User            User       `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+	Selector        string     `gorm:"unique;type:varchar(64);not null"`
+	TokenHash       string     `gorm:"type:varchar(64);not null"`
+	PreviousHash    string     `gorm:"type:varchar(64);default:NULL"`
+	UserAgent       string     `gorm:"type:varchar(255);default:NULL"`
+	ClientIP        string     `gorm:"type:varchar(64);default:NULL"`
+	CreatedTime     CustomTime `gorm:"type:datetime;default:NULL"`
+	LastUsedTime    CustomTime `gorm:"type:datetime;default:NULL"`
+	ExpiresTime     CustomTime `gorm:"type:datetime;default:NULL"`
+}
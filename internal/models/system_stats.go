@@ -0,0 +1,15 @@
+package models
+
+type SystemStats struct {
+	TotalUsers         int64
+	TotalAdmins        int64
+	SuspendedUsers     int64
+	TotalOrganizations int64
+	ActiveVotes        int64
+	FinishedVotes      int64
+	TotalBallots       int64
+	TotalFeedbacks     int64
+	AverageRating      float64
+	TotalSupports      int64
+	TotalDonations     float64
+}
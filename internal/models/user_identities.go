@@ -0,0 +1,12 @@
+package models
+
+type UserIdentity struct {
+	UserIdentityID uint `gorm:"primary_key"`
+	UserId         uint
+	User           User       `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+	Provider       string     `gorm:"uniqueIndex:idx_provider_subject;type:varchar(64);not null"`
+	Subject        string     `gorm:"uniqueIndex:idx_provider_subject;type:varchar(255);not null"`
+	Email          string     `gorm:"type:varchar(255);default:NULL"`
+	CreatedTime    CustomTime `gorm:"type:datetime;default:NULL"`
+	LastLoginTime  CustomTime `gorm:"type:datetime;default:NULL"`
+}
@@ -1,9 +1,19 @@
 package models
 
 type User struct {
-	ID       uint   `gorm:"primary_key"`
-	Username string `gorm:"unique;type:varchar(255);not null"`
-	Password string `gorm:"type:varchar(255);not null"`
-	Email    string `gorm:"unique;type:varchar(255);not null"`
-	Role     string `gorm:"type:enum('admin', 'user');not null"`
+	ID                    uint   `gorm:"primary_key"`
+	Username              string `gorm:"unique;type:varchar(255);not null"`
+	Password              string `gorm:"type:varchar(255);not null"`
+	Email                 string `gorm:"unique;type:varchar(255);not null"`
+	Role                  string `gorm:"type:enum('admin', 'user');not null"`
+	Suspended             bool   `gorm:"default:false"`
+	PasswordResetRequired bool   `gorm:"default:false"`
+	TwoFactorEnabled      bool   `gorm:"default:false"`
+	TwoFactorSecret       string `gorm:"type:varchar(64);default:NULL"`
+	// TOTPLastStep is the time step of the last accepted TOTP code.
+	TOTPLastStep          uint64 `gorm:"not null;default:0"`
+	DeletionScheduledTime CustomTime `gorm:"type:datetime;default:NULL"`
+	DeletedTime           CustomTime `gorm:"type:datetime;default:NULL"`
+	Locale                string `gorm:"type:varchar(8);default:'en'"`
+	SessionGeneration     uint   `gorm:"not null;default:0"`
 }
\ No newline at end of file
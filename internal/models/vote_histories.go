@@ -11,4 +11,5 @@ type VoteHistory struct {
 	TotalVotes uint `gorm:"type:int;default:0"`
 	Start         	CustomTime `gorm:"type:datetime;default:NULL"`
 	End           	CustomTime `gorm:"type:datetime;default:NULL"`
+	ResultsSnapshot string `gorm:"type:mediumtext;default:NULL"`
 }
\ No newline at end of file
@@ -2,9 +2,9 @@ package models
 
 type VoteRecord struct {
 	VoteRecordID uint `gorm:"primary_key"`
-	VoteId       uint
+	VoteId       uint `gorm:"uniqueIndex:idx_vote_records_vote_user"`
 	Vote         Vote `gorm:"foreignKey:VoteId;constraint:OnDelete:CASCADE;"`
-	UserId 	 uint
+	UserId 	 uint `gorm:"uniqueIndex:idx_vote_records_vote_user"`
 	User         User `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
 	CandidateId  uint
 	Candidate    Candidate `gorm:"foreignKey:CandidateId;constraint:OnDelete:CASCADE;"`
@@ -0,0 +1,28 @@
+package models
+
+// VoteTemplate is a saved blueprint for recurring votes. The title and
+// description may contain placeholders such as {month} that are filled in
+// each time a vote is created from it.
+type VoteTemplate struct {
+	VoteTemplateID  uint `gorm:"primary_key"`
+	ModeratorID     uint
+	User            User   `gorm:"foreignKey:ModeratorID;constraint:OnDelete:CASCADE;"`
+	TemplateName    string `gorm:"type:varchar(255);not null"`
+	VoteTitle       string `gorm:"type:varchar(255);not null"`
+	VoteDescription string `gorm:"type:text;default:NULL"`
+	OrganizationID  *uint
+	Organization    Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:SET NULL;"`
+	VoterRollID     *uint
+	VoterRoll       VoterRoll               `gorm:"foreignKey:VoterRollID;constraint:OnDelete:SET NULL;"`
+	PublicBallot    bool                    `gorm:"default:false"`
+	CreatedTime     CustomTime              `gorm:"type:datetime;default:NULL"`
+	Candidates      []VoteTemplateCandidate `gorm:"foreignKey:VoteTemplateID;constraint:OnDelete:CASCADE;"`
+}
+
+type VoteTemplateCandidate struct {
+	VoteTemplateCandidateID uint `gorm:"primary_key"`
+	VoteTemplateID          uint
+	CandidateName           string `gorm:"type:varchar(255);not null"`
+	CandidateDescription    string `gorm:"type:text;default:NULL"`
+	CandidatePicture        string `gorm:"type:varchar(255);default:NULL"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type VoterRoll struct {
+	VoterRollID    uint   `gorm:"primary_key"`
+	VoterRollName  string `gorm:"type:varchar(255);not null"`
+	OrganizationId uint
+	Organization   Organization `gorm:"foreignKey:OrganizationId;constraint:OnDelete:CASCADE;"`
+}
+
+type VoterRollEntry struct {
+	VoterRollEntryID uint `gorm:"primary_key"`
+	VoterRollId      uint
+	VoterRoll        VoterRoll `gorm:"foreignKey:VoterRollId;constraint:OnDelete:CASCADE;"`
+	UserId           uint
+	User             User `gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;"`
+}
@@ -41,4 +41,11 @@ type Vote struct {
 	ModeratorID     uint
 	User            User                  `gorm:"foreignKey:ModeratorID;constraint:OnDelete:CASCADE;"`
 	Start           CustomTime `gorm:"type:datetime;default:NULL"`
+	OrganizationID  *uint
+	Organization    Organization          `gorm:"foreignKey:OrganizationID;constraint:OnDelete:SET NULL;"`
+	VoterRollID     *uint
+	VoterRoll       VoterRoll             `gorm:"foreignKey:VoterRollID;constraint:OnDelete:SET NULL;"`
+	PublicBallot    bool                  `gorm:"default:false"`
+	// ReminderSentTime is set once the reminder emails have been queued.
+	ReminderSentTime CustomTime `gorm:"type:datetime;default:NULL"`
 }
\ No newline at end of file
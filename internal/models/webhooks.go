@@ -0,0 +1,35 @@
+package models
+
+// WebhookEndpoint is scoped to a single vote or to every vote of an
+// organization. Vote scoped endpoints are deactivated once the vote closes,
+// so VoteID is a plain column rather than a foreign key and the delivery log
+// outlives the vote.
+type WebhookEndpoint struct {
+	WebhookEndpointID uint `gorm:"primary_key"`
+	ModeratorID       uint
+	User              User         `gorm:"foreignKey:ModeratorID;constraint:OnDelete:CASCADE;"`
+	VoteID            *uint        `gorm:"index"`
+	VoteTitle         string       `gorm:"type:varchar(255);default:NULL"`
+	OrganizationID    *uint
+	Organization      Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE;"`
+	URL               string       `gorm:"type:varchar(2048);not null"`
+	Secret            string       `gorm:"type:varchar(64);not null"`
+	Events            string       `gorm:"type:varchar(255);not null"`
+	Active            bool         `gorm:"default:true"`
+	CreatedTime       CustomTime   `gorm:"type:datetime;default:NULL"`
+}
+
+type WebhookDelivery struct {
+	WebhookDeliveryID uint `gorm:"primary_key"`
+	WebhookEndpointID uint
+	WebhookEndpoint   WebhookEndpoint `gorm:"foreignKey:WebhookEndpointID;constraint:OnDelete:CASCADE;"`
+	Event             string          `gorm:"type:varchar(50);not null"`
+	Payload           string          `gorm:"type:mediumtext;not null"`
+	Status            string          `gorm:"type:enum('pending', 'sending', 'delivered', 'dead');default:'pending';not null;index"`
+	Attempts          uint            `gorm:"type:int;default:0"`
+	ResponseStatus    int             `gorm:"type:int;default:0"`
+	LastError         string          `gorm:"type:text;default:NULL"`
+	NextAttemptTime   CustomTime      `gorm:"type:datetime;default:NULL;index"`
+	CreatedTime       CustomTime      `gorm:"type:datetime;default:NULL"`
+	DeliveredTime     CustomTime      `gorm:"type:datetime;default:NULL"`
+}
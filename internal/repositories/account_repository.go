@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
+)
+
+func GetFeedbacksByUserID(userID uint) ([]models.Feedback, error) {
+	feedbacks := []models.Feedback{}
+	err := db.DB.Where("user_id = ?", userID).Order("feedback_id").Find(&feedbacks).Error
+	if err != nil {
+		return feedbacks, err
+	}
+	return feedbacks, nil
+}
+
+func GetVoteRecordsByUserID(userID uint) ([]models.VoteRecord, error) {
+	voteRecords := []models.VoteRecord{}
+	err := db.DB.Preload("Vote").Preload("Candidate").Where("user_id = ?", userID).Order("vote_record_id").Find(&voteRecords).Error
+	if err != nil {
+		return voteRecords, err
+	}
+	return voteRecords, nil
+}
+
+func ScheduleUserDeletion(userID uint, deletionTime time.Time) error {
+	return db.DB.Model(&models.User{}).Where("id = ?", userID).
+		Update("deletion_scheduled_time", models.CustomTime{Time: deletionTime}).Error
+}
+
+func CancelUserDeletion(userID uint) error {
+	return db.DB.Model(&models.User{}).Where("id = ?", userID).
+		Update("deletion_scheduled_time", gorm.Expr("NULL")).Error
+}
+
+func GetUsersDueForDeletion(now time.Time) ([]models.User, error) {
+	users := []models.User{}
+	err := db.DB.Where("deletion_scheduled_time IS NOT NULL AND deletion_scheduled_time <= ? AND deleted_time IS NULL", models.CustomTime{Time: now}).
+		Find(&users).Error
+	if err != nil {
+		return users, err
+	}
+	return users, nil
+}
+
+// AnonymizeUser removes the user's personal data but keeps the users row, so
+// vote records, moderated votes and owned organizations are not cascade-deleted
+// and past tallies stay intact.
+func AnonymizeUser(userID uint, passwordHashed string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		personalData := []interface{}{
+			&models.Profile{},
+			&models.Feedback{},
+			&models.RememberToken{},
+			&models.RecoveryCode{},
+			&models.UserIdentity{},
+			&models.PersonalAccessToken{},
+			&models.OrganizationMember{},
+			&models.VoterRollEntry{},
+		}
+		for _, model := range personalData {
+			err := tx.Where("user_id = ?", userID).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, model := range []interface{}{&models.WebhookEndpoint{}, &models.VoteTemplate{}} {
+			err := tx.Where("moderator_id = ?", userID).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":                fmt.Sprintf("deleted_user_%d", userID),
+			"email":                   fmt.Sprintf("deleted_user_%d@deleted.invalid", userID),
+			"password":                passwordHashed,
+			"role":                    "user",
+			"suspended":               true,
+			"password_reset_required": false,
+			"two_factor_enabled":      false,
+			"two_factor_secret":       gorm.Expr("NULL"),
+			"deletion_scheduled_time": gorm.Expr("NULL"),
+			"deleted_time":            models.CustomTime{Time: time.Now()},
+		}).Error
+	})
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/AndreanDjabbar/ElectiVote/internal/db"
 	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
 )
 
 func AddCandidate(newCandidate models.Candidate) (models.Candidate, error) {
@@ -10,12 +11,42 @@ func AddCandidate(newCandidate models.Candidate) (models.Candidate, error) {
 	return newCandidate, err
 }
 
+// AddCandidates inserts every candidate or none of them.
+func AddCandidates(newCandidates []models.Candidate) ([]models.Candidate, error) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range newCandidates {
+			err := tx.Create(&newCandidates[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return newCandidates, err
+}
+
 func GetCandidatesByVoteID(voteID uint) ([]models.Candidate, error) {
 	var candidates []models.Candidate
 	err := db.DB.Where("vote_id = ?", voteID).Find(&candidates).Error
 	return candidates, err
 }
 
+// GetCandidatesByModeratorID returns the candidates of every vote the user
+// moderates, with their vote.
+func GetCandidatesByModeratorID(moderatorID uint) ([]models.Candidate, error) {
+	var candidates []models.Candidate
+	moderatedVotes := db.DB.
+		Model(&models.Vote{}).
+		Select("vote_id").
+		Where("moderator_id = ?", moderatorID)
+	err := db.DB.
+		Preload("Vote").
+		Where("vote_id IN (?)", moderatedVotes).
+		Order("vote_id, candidate_id").
+		Find(&candidates).Error
+	return candidates, err
+}
+
 func GetCandidateWinner(voteID uint) (models.Candidate, error) {
 	candidate := models.Candidate{}
 	err := db.DB.Where("vote_id = ?", voteID).Order("total_votes desc").First(&candidate).Error
@@ -35,22 +66,11 @@ func GetVoteIDByCandidateID(candidateID uint) (uint, error) {
 }
 
 func IsValidCandidateModerator(username string, candidateID uint) bool {
-	userID, err := GetUserIdByUsername(username)
-	if err != nil {
-		return false
-	}
-	userVoteID, err := GetVoteIDByUserID(uint(userID))
-	if err != nil {
-		return false
-	}
 	candidateVoteID, err := GetVoteIDByCandidateID(candidateID)
-	if err != nil {
+	if err != nil || candidateVoteID == 0 {
 		return false
 	}
-	if userVoteID == candidateVoteID {
-		return true
-	}
-	return false
+	return IsValidVoteModerator(username, candidateVoteID)
 }
 
 func UpdateCandidate(candidateID uint, candidate models.Candidate) (models.Candidate, error) {
@@ -61,15 +81,4 @@ func UpdateCandidate(candidateID uint, candidate models.Candidate) (models.Candi
 func DeleteCandidate(candidateID uint) error {
 	err := db.DB.Where("candidate_id = ?", candidateID).Delete(&models.Candidate{}).Error
 	return err
-}
-
-func IncrementCandidateVote(candidateID uint) error {
-	candidate := models.Candidate{}
-	err := db.DB.Where("candidate_id = ?", candidateID).Find(&candidate).Error
-	if err != nil {
-		return err
-	}
-	candidate.TotalVotes += 1
-	err = db.DB.Model(&models.Candidate{}).Where("candidate_id = ?", candidateID).Updates(&candidate).Error
-	return err
 }
\ No newline at end of file
@@ -11,4 +11,13 @@ func CreateFeedback(feedback models.Feedback) (models.Feedback, error) {
 		return feedback, err
 	}
 	return feedback, nil
-}
\ No newline at end of file
+}
+
+func GetAllFeedbacks() ([]models.Feedback, error) {
+	feedbacks := []models.Feedback{}
+	err := db.DB.Preload("User").Order("feedback_id desc").Find(&feedbacks).Error
+	if err != nil {
+		return feedbacks, err
+	}
+	return feedbacks, nil
+}
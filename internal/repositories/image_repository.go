@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
+)
+
+// imageReferences are the columns that hold blob store keys of images.
+var imageReferences = []struct {
+	model  interface{}
+	column string
+}{
+	{&models.Profile{}, "picture"},
+	{&models.Candidate{}, "candidate_picture"},
+	{&models.Organization{}, "organization_logo"},
+	{&models.VoteHistory{}, "candidate_winner_picture"},
+	{&models.VoteTemplateCandidate{}, "candidate_picture"},
+}
+
+// IsImageReferenced reports whether any record still points at a stored
+// image, so that shared, content addressed files are only deleted once
+// nothing uses them.
+func IsImageReferenced(name string) (bool, error) {
+	for _, reference := range imageReferences {
+		var count int64
+		err := db.DB.Model(reference.model).Where(reference.column+" = ?", name).Count(&count).Error
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetImageReferences returns every distinct image value stored on a record,
+// including empty ones.
+func GetImageReferences() ([]string, error) {
+	seen := map[string]bool{}
+	values := []string{}
+	for _, reference := range imageReferences {
+		columnValues := []string{}
+		err := db.DB.Model(reference.model).Distinct().Where(reference.column+" IS NOT NULL").Pluck(reference.column, &columnValues).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range columnValues {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// RenameImageReference points every record that uses the image value from at
+// the value to instead.
+func RenameImageReference(from, to string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, reference := range imageReferences {
+			err := tx.Model(reference.model).Where(reference.column+" = ?", from).Update(reference.column, to).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveImageVariants records the variants of an image, replacing what was
+// recorded for it before.
+func SaveImageVariants(variants models.ImageVariants) error {
+	return db.DB.Save(&variants).Error
+}
+
+func GetAllImageVariants() ([]models.ImageVariants, error) {
+	variants := []models.ImageVariants{}
+	err := db.DB.Find(&variants).Error
+	if err != nil {
+		return variants, err
+	}
+	return variants, nil
+}
+
+func HasImageVariants(name string) (bool, error) {
+	var count int64
+	err := db.DB.Model(&models.ImageVariants{}).Where("image_name = ?", name).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func DeleteImageVariants(name string) error {
+	return db.DB.Where("image_name = ?", name).Delete(&models.ImageVariants{}).Error
+}
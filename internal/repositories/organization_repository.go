@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateOrganization(organization models.Organization) (models.Organization, error) {
+	err := db.DB.Create(&organization).Error
+	if err != nil {
+		return organization, err
+	}
+	return organization, nil
+}
+
+func GetOrganizationByOrganizationID(organizationID uint) (models.Organization, error) {
+	organization := models.Organization{}
+	err := db.DB.Where("organization_id = ?", organizationID).First(&organization).Error
+	if err != nil {
+		return organization, err
+	}
+	return organization, nil
+}
+
+func GetOrganizationsByUsername(username string) ([]models.Organization, error) {
+	userID, err := GetUserIdByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	organizations := []models.Organization{}
+	err = db.DB.
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.organization_id").
+		Where("organization_members.user_id = ?", uint(userID)).
+		Find(&organizations).Error
+	if err != nil {
+		return organizations, err
+	}
+	return organizations, nil
+}
+
+func GetManagedOrganizationsByUsername(username string) ([]models.Organization, error) {
+	userID, err := GetUserIdByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	organizations := []models.Organization{}
+	err = db.DB.
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.organization_id").
+		Where("organization_members.user_id = ? AND organization_members.member_role IN ?", uint(userID), []string{"owner", "admin"}).
+		Find(&organizations).Error
+	if err != nil {
+		return organizations, err
+	}
+	return organizations, nil
+}
+
+func UpdateOrganization(organizationID uint, organization models.Organization) (models.Organization, error) {
+	err := db.DB.Model(&models.Organization{}).Where("organization_id = ?", organizationID).Updates(organization).Error
+	if err != nil {
+		return organization, err
+	}
+	return organization, nil
+}
+
+func SetOrganizationRequireTwoFactor(organizationID uint, requireTwoFactor bool) error {
+	err := db.DB.Model(&models.Organization{}).Where("organization_id = ?", organizationID).Update("require_two_factor", requireTwoFactor).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func IsTwoFactorRequiredByManagedOrganization(userID uint) bool {
+	var count int64
+	err := db.DB.Model(&models.Organization{}).
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.organization_id").
+		Where("organization_members.user_id = ? AND organization_members.member_role IN ? AND organizations.require_two_factor = ?", userID, []string{"owner", "admin"}, true).
+		Count(&count).Error
+	if err != nil {
+		return false
+	}
+	return count > 0
+}
+
+func DeleteOrganization(organizationID uint) error {
+	err := db.DB.Where("organization_id = ?", organizationID).Delete(&models.Organization{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func AddOrganizationMember(member models.OrganizationMember) (models.OrganizationMember, error) {
+	err := db.DB.Create(&member).Error
+	if err != nil {
+		return member, err
+	}
+	return member, nil
+}
+
+func GetOrganizationMembersByOrganizationID(organizationID uint) ([]models.OrganizationMember, error) {
+	members := []models.OrganizationMember{}
+	err := db.DB.Preload("User").Where("organization_id = ?", organizationID).Find(&members).Error
+	if err != nil {
+		return members, err
+	}
+	return members, nil
+}
+
+func GetOrganizationMemberByMemberID(memberID uint) (models.OrganizationMember, error) {
+	member := models.OrganizationMember{}
+	err := db.DB.Where("organization_member_id = ?", memberID).First(&member).Error
+	if err != nil {
+		return member, err
+	}
+	return member, nil
+}
+
+func GetOrganizationMemberRole(userID, organizationID uint) (string, error) {
+	member := models.OrganizationMember{}
+	err := db.DB.Where("user_id = ? AND organization_id = ?", userID, organizationID).First(&member).Error
+	if err != nil {
+		return "", err
+	}
+	return member.MemberRole, nil
+}
+
+func IsOrganizationMember(userID, organizationID uint) bool {
+	_, err := GetOrganizationMemberRole(userID, organizationID)
+	return err == nil
+}
+
+func IsValidOrganizationManager(username string, organizationID uint) bool {
+	userID, err := GetUserIdByUsername(username)
+	if err != nil {
+		return false
+	}
+	role, err := GetOrganizationMemberRole(uint(userID), organizationID)
+	if err != nil {
+		return false
+	}
+	return role == "owner" || role == "admin"
+}
+
+func UpdateOrganizationMemberRole(memberID uint, memberRole string) error {
+	err := db.DB.Model(&models.OrganizationMember{}).Where("organization_member_id = ?", memberID).Update("member_role", memberRole).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteOrganizationMember(memberID uint) error {
+	err := db.DB.Where("organization_member_id = ?", memberID).Delete(&models.OrganizationMember{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateOutboundEmail(email models.OutboundEmail) (models.OutboundEmail, error) {
+	err := db.DB.Create(&email).Error
+	if err != nil {
+		return email, err
+	}
+	return email, nil
+}
+
+// GetDueOutboundEmailIDs returns pending emails whose retry time has passed,
+// plus "sending" emails whose worker died before finishing.
+func GetDueOutboundEmailIDs(now time.Time, staleBefore time.Time, limit int) ([]uint, error) {
+	ids := []uint{}
+	err := db.DB.Model(&models.OutboundEmail{}).
+		Where("(status = 'pending' AND next_attempt_time <= ?) OR (status = 'sending' AND next_attempt_time <= ?)",
+			models.CustomTime{Time: now}, models.CustomTime{Time: staleBefore}).
+		Order("next_attempt_time").
+		Limit(limit).
+		Pluck("outbound_email_id", &ids).Error
+	if err != nil {
+		return ids, err
+	}
+	return ids, nil
+}
+
+// ClaimOutboundEmail marks the email as being sent and reports whether this
+// caller won it, so several workers can poll the same table safely.
+func ClaimOutboundEmail(outboundEmailID uint, now time.Time, staleBefore time.Time) (models.OutboundEmail, bool, error) {
+	email := models.OutboundEmail{}
+	result := db.DB.Model(&models.OutboundEmail{}).
+		Where("outbound_email_id = ? AND ((status = 'pending' AND next_attempt_time <= ?) OR (status = 'sending' AND next_attempt_time <= ?))",
+			outboundEmailID, models.CustomTime{Time: now}, models.CustomTime{Time: staleBefore}).
+		Updates(map[string]interface{}{
+			"status":            "sending",
+			"next_attempt_time": models.CustomTime{Time: now},
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return email, false, result.Error
+	}
+	err := db.DB.Where("outbound_email_id = ?", outboundEmailID).First(&email).Error
+	if err != nil {
+		return email, false, err
+	}
+	return email, true, nil
+}
+
+func MarkOutboundEmailSent(outboundEmailID uint, attempts uint, sentTime time.Time) error {
+	return db.DB.Model(&models.OutboundEmail{}).
+		Where("outbound_email_id = ?", outboundEmailID).
+		Updates(map[string]interface{}{
+			"status":     "sent",
+			"attempts":   attempts,
+			"last_error": "",
+			"sent_time":  models.CustomTime{Time: sentTime},
+		}).Error
+}
+
+func MarkOutboundEmailFailed(outboundEmailID uint, attempts uint, lastError string, status string, nextAttemptTime time.Time) error {
+	return db.DB.Model(&models.OutboundEmail{}).
+		Where("outbound_email_id = ?", outboundEmailID).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"attempts":          attempts,
+			"last_error":        lastError,
+			"next_attempt_time": models.CustomTime{Time: nextAttemptTime},
+		}).Error
+}
+
+func RequeueOutboundEmail(outboundEmailID uint, now time.Time) error {
+	return db.DB.Model(&models.OutboundEmail{}).
+		Where("outbound_email_id = ? AND status = 'dead'", outboundEmailID).
+		Updates(map[string]interface{}{
+			"status":            "pending",
+			"attempts":          0,
+			"next_attempt_time": models.CustomTime{Time: now},
+		}).Error
+}
+
+func GetOutboundEmails(status string, limit int) ([]models.OutboundEmail, error) {
+	emails := []models.OutboundEmail{}
+	tx := db.DB.Omit("body").Order("outbound_email_id desc").Limit(limit)
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	err := tx.Find(&emails).Error
+	if err != nil {
+		return emails, err
+	}
+	return emails, nil
+}
+
+func CountOutboundEmailsByStatus() (map[string]int64, error) {
+	rows := []struct {
+		Status string
+		Total  int64
+	}{}
+	err := db.DB.Model(&models.OutboundEmail{}).Select("status, count(*) as total").Group("status").Scan(&rows).Error
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Status] = row.Total
+	}
+	return counts, err
+}
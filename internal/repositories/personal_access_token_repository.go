@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreatePersonalAccessToken(token models.PersonalAccessToken) (models.PersonalAccessToken, error) {
+	err := db.DB.Create(&token).Error
+	if err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func GetPersonalAccessTokensByUserID(userID uint) ([]models.PersonalAccessToken, error) {
+	tokens := []models.PersonalAccessToken{}
+	err := db.DB.
+		Where("user_id = ?", userID).
+		Order("personal_access_token_id desc").
+		Find(&tokens).Error
+	if err != nil {
+		return tokens, err
+	}
+	return tokens, nil
+}
+
+func GetPersonalAccessTokenByHash(tokenHash string) (models.PersonalAccessToken, error) {
+	token := models.PersonalAccessToken{}
+	err := db.DB.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func UpdatePersonalAccessTokenLastUsed(personalAccessTokenID uint, lastUsedTime models.CustomTime, clientIP string) error {
+	return db.DB.Model(&models.PersonalAccessToken{}).
+		Where("personal_access_token_id = ?", personalAccessTokenID).
+		Updates(map[string]interface{}{
+			"last_used_time": lastUsedTime,
+			"last_used_ip":   clientIP,
+		}).Error
+}
+
+// DeletePersonalAccessToken only removes the token when it belongs to userID.
+func DeletePersonalAccessToken(userID, personalAccessTokenID uint) (bool, error) {
+	result := db.DB.
+		Where("user_id = ? AND personal_access_token_id = ?", userID, personalAccessTokenID).
+		Delete(&models.PersonalAccessToken{})
+	return result.RowsAffected > 0, result.Error
+}
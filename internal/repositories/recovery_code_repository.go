@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReplaceRecoveryCodes discards every previous code so only the latest batch is usable.
+func ReplaceRecoveryCodes(userID uint, codes []models.RecoveryCode) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks a matching unused code as spent and reports whether one was found.
+func UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := db.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_time IS NULL", userID, codeHash).
+		Update("used_time", models.CustomTime{Time: time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := db.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_time IS NULL", userID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func DeleteRecoveryCodesByUserID(userID uint) error {
+	return db.DB.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
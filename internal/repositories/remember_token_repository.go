@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateRememberToken(token models.RememberToken) (models.RememberToken, error) {
+	err := db.DB.Create(&token).Error
+	if err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func GetRememberTokenBySelector(selector string) (models.RememberToken, error) {
+	token := models.RememberToken{}
+	err := db.DB.Preload("User").Where("selector = ?", selector).First(&token).Error
+	if err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+func GetRememberTokensByUserID(userID uint) ([]models.RememberToken, error) {
+	tokens := []models.RememberToken{}
+	err := db.DB.
+		Where("user_id = ? AND expires_time > ?", userID, time.Now()).
+		Order("last_used_time desc").
+		Find(&tokens).Error
+	if err != nil {
+		return tokens, err
+	}
+	return tokens, nil
+}
+
+// RotateRememberToken replaces the hash, keeping the selector so a reused old
+// validator is still recognised, and the creation time. It only applies while
+// the hash is still currentHash; false means another request rotated first.
+func RotateRememberToken(rememberTokenID uint, currentHash, tokenHash string, lastUsedTime models.CustomTime) (bool, error) {
+	result := db.DB.Model(&models.RememberToken{}).
+		Where("remember_token_id = ? AND token_hash = ?", rememberTokenID, currentHash).
+		Updates(map[string]interface{}{
+			"token_hash":     tokenHash,
+			"previous_hash":  currentHash,
+			"last_used_time": lastUsedTime,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func DeleteRememberToken(rememberTokenID uint) error {
+	return db.DB.Where("remember_token_id = ?", rememberTokenID).Delete(&models.RememberToken{}).Error
+}
+
+func DeleteRememberTokensByUserID(userID uint) error {
+	return db.DB.Where("user_id = ?", userID).Delete(&models.RememberToken{}).Error
+}
+
+func DeleteExpiredRememberTokens() error {
+	return db.DB.Where("expires_time <= ?", time.Now()).Delete(&models.RememberToken{}).Error
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func GetSystemStats() (models.SystemStats, error) {
+	stats := models.SystemStats{}
+	counts := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+		dest  *int64
+	}{
+		{&models.User{}, "", nil, &stats.TotalUsers},
+		{&models.User{}, "role = ?", []interface{}{"admin"}, &stats.TotalAdmins},
+		{&models.User{}, "suspended = ?", []interface{}{true}, &stats.SuspendedUsers},
+		{&models.Organization{}, "", nil, &stats.TotalOrganizations},
+		{&models.Vote{}, "", nil, &stats.ActiveVotes},
+		{&models.VoteHistory{}, "", nil, &stats.FinishedVotes},
+		{&models.VoteRecord{}, "", nil, &stats.TotalBallots},
+		{&models.Feedback{}, "", nil, &stats.TotalFeedbacks},
+		{&models.Support{}, "", nil, &stats.TotalSupports},
+	}
+	for _, count := range counts {
+		tx := db.DB.Model(count.model)
+		if count.where != "" {
+			tx = tx.Where(count.where, count.args...)
+		}
+		err := tx.Count(count.dest).Error
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	err := db.DB.Model(&models.Feedback{}).Select("COALESCE(AVG(feedback_rate), 0)").Scan(&stats.AverageRating).Error
+	if err != nil {
+		return stats, err
+	}
+	err = db.DB.Model(&models.Support{}).Select("COALESCE(SUM(amount), 0)").Scan(&stats.TotalDonations).Error
+	if err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
@@ -11,4 +11,13 @@ func SaveSupport(support models.Support) error {
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func GetAllSupports() ([]models.Support, error) {
+	supports := []models.Support{}
+	err := db.DB.Order("support_id desc").Find(&supports).Error
+	if err != nil {
+		return supports, err
+	}
+	return supports, nil
+}
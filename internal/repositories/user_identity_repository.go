@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateUserIdentity(identity models.UserIdentity) (models.UserIdentity, error) {
+	err := db.DB.Create(&identity).Error
+	if err != nil {
+		return identity, err
+	}
+	return identity, nil
+}
+
+func GetUserIdentity(provider, subject string) (models.UserIdentity, error) {
+	identity := models.UserIdentity{}
+	err := db.DB.Preload("User").Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return identity, err
+	}
+	return identity, nil
+}
+
+func UpdateUserIdentityLogin(userIdentityID uint, email string, lastLoginTime models.CustomTime) error {
+	return db.DB.Model(&models.UserIdentity{}).
+		Where("user_identity_id = ?", userIdentityID).
+		Updates(map[string]interface{}{
+			"email":           email,
+			"last_login_time": lastLoginTime,
+		}).Error
+}
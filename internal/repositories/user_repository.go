@@ -4,6 +4,7 @@ import (
 	"github.com/AndreanDjabbar/ElectiVote/internal/db"
 	"github.com/AndreanDjabbar/ElectiVote/internal/models"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func RegisterUser(newUser models.User) (models.User, error) {
@@ -81,4 +82,103 @@ func UpdatePasswordByEmail(email, passwordHashed string) (models.User, error) {
 		return user, err
 	}
 	return user, nil
-}
\ No newline at end of file
+}
+
+func GetUserByUserID(userID uint) (models.User, error) {
+	var user models.User
+	err := db.DB.Where("id = ?", userID).First(&user).Error
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+func SearchUsers(query string) ([]models.User, error) {
+	users := []models.User{}
+	tx := db.DB.Order("id")
+	if query != "" {
+		pattern := "%" + query + "%"
+		tx = tx.Where("username LIKE ? OR email LIKE ?", pattern, pattern)
+	}
+	err := tx.Find(&users).Error
+	if err != nil {
+		return users, err
+	}
+	return users, nil
+}
+
+func SetUserSuspended(userID uint, suspended bool) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("suspended", suspended).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func SetPasswordResetRequired(email string, required bool) error {
+	err := db.DB.Model(&models.User{}).Where("email = ?", email).Update("password_reset_required", required).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func EnableTwoFactor(userID uint, secret string) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_enabled": true,
+		"two_factor_secret":  secret,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ConsumeTOTPStep records the time step of an accepted TOTP code. It reports
+// false when that step or a later one was already used.
+func ConsumeTOTPStep(userID uint, step uint64) (bool, error) {
+	result := db.DB.
+		Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func DisableTwoFactor(userID uint) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_enabled": false,
+		"two_factor_secret":  nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return DeleteRecoveryCodesByUserID(userID)
+}
+
+func UpdateUserEmail(userID uint, email string) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("email", email).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateUserLocale(userID uint, locale string) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("locale", locale).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// BumpSessionGeneration invalidates the API tokens issued to the user so far.
+func BumpSessionGeneration(userID uint) error {
+	err := db.DB.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("session_generation", gorm.Expr("session_generation + 1")).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
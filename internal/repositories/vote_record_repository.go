@@ -1,16 +1,42 @@
 package repositories
 
 import (
+	"errors"
+
 	"github.com/AndreanDjabbar/ElectiVote/internal/db"
 	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
 )
 
-func CreateVoteRecord(voteRecord models.VoteRecord) (models.VoteRecord, error) {
-	err := db.DB.Create(&voteRecord).Error
-	if err != nil {
-		return voteRecord, err
-	}
-	return voteRecord, nil
+const mysqlDuplicateEntry = 1062
+
+var (
+	ErrAlreadyVoted       = errors.New("user already voted in this vote")
+	ErrCandidateNotInVote = errors.New("candidate does not belong to this vote")
+)
+
+// CastBallot stores the ballot and counts it in one transaction. The unique
+// index on (vote_id, user_id) settles ballots of the same user that race each
+// other, so only one of them is ever counted.
+func CastBallot(voteRecord models.VoteRecord) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Candidate{}).
+			Where("candidate_id = ? AND vote_id = ?", voteRecord.CandidateId, voteRecord.VoteId).
+			UpdateColumn("total_votes", gorm.Expr("total_votes + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCandidateNotInVote
+		}
+		err := tx.Create(&voteRecord).Error
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return ErrAlreadyVoted
+		}
+		return err
+	})
 }
 
 func IsVoted(userID uint, voteCode string) (bool) {
@@ -21,4 +47,18 @@ func IsVoted(userID uint, voteCode string) (bool) {
 	voteRecord := models.VoteRecord{}
 	err = db.DB.Where("user_id = ? AND vote_id = ?", userID, voteID).First(&voteRecord).Error
 	return err == nil
-}
\ No newline at end of file
+}
+
+func GetVoteRecordsByVoteID(voteID uint) ([]models.VoteRecord, error) {
+	voteRecords := []models.VoteRecord{}
+	err := db.DB.
+		Preload("User").
+		Preload("Candidate").
+		Where("vote_id = ?", voteID).
+		Order("voted_time, vote_record_id").
+		Find(&voteRecords).Error
+	if err != nil {
+		return voteRecords, err
+	}
+	return voteRecords, nil
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/AndreanDjabbar/ElectiVote/internal/db"
 	"github.com/AndreanDjabbar/ElectiVote/internal/models"
 )
@@ -24,8 +26,17 @@ func GetVotesDataByUsername(username string) ([]models.Vote, error) {
 	if err != nil {
 		return nil, err
 	}
+	managedOrganizations := db.DB.
+		Model(&models.OrganizationMember{}).
+		Select("organization_id").
+		Where("user_id = ? AND member_role IN ?", uint(userID), []string{"owner", "admin"})
 	votes := []models.Vote{}
-	err = db.DB.Where("moderator_id = ?", uint(userID)).Find(&votes).Error
+	err = db.DB.
+		Preload("Organization").
+		Where("moderator_id = ?", uint(userID)).
+		Or("organization_id IN (?)", managedOrganizations).
+		Order("organization_id, vote_id").
+		Find(&votes).Error
 	if err != nil {
 		return votes, err
 	}
@@ -73,13 +84,16 @@ func IsValidVoteModerator(username string, voteID uint) bool {
 	if err != nil {
 		return false
 	}
-	moderatorID, err := GetModeratorIDByVoteID(voteID)
-	if err != nil {
+	vote, err := GetVoteDataByVoteID(voteID)
+	if err != nil || vote.VoteID == 0 {
 		return false
 	}
-	if uint(userID) == moderatorID {
+	if uint(userID) == vote.ModeratorID {
 		return true
 	}
+	if vote.OrganizationID != nil {
+		return IsValidOrganizationManager(username, *vote.OrganizationID)
+	}
 	return false
 }
 
@@ -91,6 +105,14 @@ func UpdateVote(voteID uint, vote models.Vote) (models.Vote, error) {
 	return vote, nil	
 }
 
+func UpdateVoteVoterRoll(voteID uint, voterRollID *uint) error {
+	err := db.DB.Model(&models.Vote{}).Where("vote_id = ?", voteID).Update("voter_roll_id", voterRollID).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func DeleteVote(voteID uint) error {
 	vote := models.Vote{}
 	err := db.DB.Where("vote_id = ?", voteID).Delete(&vote).Error
@@ -116,4 +138,41 @@ func GetVoteIDByVoteCode(voteCode string) (uint, error) {
 		return 0, err
 	}
 	return vote.VoteID, nil
-}
\ No newline at end of file
+}
+
+func GetAllVotes() ([]models.Vote, error) {
+	votes := []models.Vote{}
+	err := db.DB.Preload("User").Preload("Organization").Order("vote_id desc").Find(&votes).Error
+	if err != nil {
+		return votes, err
+	}
+	return votes, nil
+}
+
+// GetVotesDueForReminder returns the votes addressed to a voter roll or an
+// organization that started between from and to and have had no reminder.
+func GetVotesDueForReminder(from, to time.Time) ([]models.Vote, error) {
+	votes := []models.Vote{}
+	err := db.DB.
+		Where("reminder_sent_time IS NULL").
+		Where("start > ? AND start <= ?", models.CustomTime{Time: from}, models.CustomTime{Time: to}).
+		Where("voter_roll_id IS NOT NULL OR organization_id IS NOT NULL").
+		Find(&votes).Error
+	if err != nil {
+		return votes, err
+	}
+	return votes, nil
+}
+
+// ClaimVoteReminder marks the reminder of a vote as sent. It reports false
+// when another worker claimed it first.
+func ClaimVoteReminder(voteID uint, sentTime models.CustomTime) (bool, error) {
+	result := db.DB.
+		Model(&models.Vote{}).
+		Where("vote_id = ? AND reminder_sent_time IS NULL", voteID).
+		Update("reminder_sent_time", sentTime)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
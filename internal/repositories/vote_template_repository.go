@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateVoteTemplate stores the template together with its candidates.
+func CreateVoteTemplate(voteTemplate models.VoteTemplate) (models.VoteTemplate, error) {
+	err := db.DB.Create(&voteTemplate).Error
+	if err != nil {
+		return voteTemplate, err
+	}
+	return voteTemplate, nil
+}
+
+func GetVoteTemplatesByModeratorID(moderatorID uint) ([]models.VoteTemplate, error) {
+	voteTemplates := []models.VoteTemplate{}
+	err := db.DB.
+		Preload("Candidates").
+		Preload("Organization").
+		Where("moderator_id = ?", moderatorID).
+		Order("vote_template_id desc").
+		Find(&voteTemplates).Error
+	if err != nil {
+		return voteTemplates, err
+	}
+	return voteTemplates, nil
+}
+
+func GetVoteTemplateByID(voteTemplateID uint) (models.VoteTemplate, error) {
+	voteTemplate := models.VoteTemplate{}
+	err := db.DB.Preload("Candidates").Where("vote_template_id = ?", voteTemplateID).First(&voteTemplate).Error
+	if err != nil {
+		return voteTemplate, err
+	}
+	return voteTemplate, nil
+}
+
+func DeleteVoteTemplate(voteTemplateID uint) error {
+	return db.DB.Where("vote_template_id = ?", voteTemplateID).Delete(&models.VoteTemplate{}).Error
+}
+
+// CreateVoteWithCandidates inserts a vote and its candidates in one
+// transaction, so a copied vote is never left without its candidates.
+func CreateVoteWithCandidates(vote models.Vote, candidates []models.Candidate) (models.Vote, error) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&vote).Error
+		if err != nil {
+			return err
+		}
+		for i := range candidates {
+			candidates[i].VoteId = vote.VoteID
+			err = tx.Create(&candidates[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return vote, err
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateVoterRoll(voterRoll models.VoterRoll) (models.VoterRoll, error) {
+	err := db.DB.Create(&voterRoll).Error
+	if err != nil {
+		return voterRoll, err
+	}
+	return voterRoll, nil
+}
+
+func GetVoterRollsByOrganizationID(organizationID uint) ([]models.VoterRoll, error) {
+	voterRolls := []models.VoterRoll{}
+	err := db.DB.Where("organization_id = ?", organizationID).Find(&voterRolls).Error
+	if err != nil {
+		return voterRolls, err
+	}
+	return voterRolls, nil
+}
+
+func GetVoterRollByVoterRollID(voterRollID uint) (models.VoterRoll, error) {
+	voterRoll := models.VoterRoll{}
+	err := db.DB.Where("voter_roll_id = ?", voterRollID).First(&voterRoll).Error
+	if err != nil {
+		return voterRoll, err
+	}
+	return voterRoll, nil
+}
+
+func IsValidOrganizationVoterRoll(organizationID, voterRollID uint) bool {
+	voterRoll, err := GetVoterRollByVoterRollID(voterRollID)
+	if err != nil {
+		return false
+	}
+	return voterRoll.OrganizationId == organizationID
+}
+
+func DeleteVoterRoll(voterRollID uint) error {
+	err := db.DB.Where("voter_roll_id = ?", voterRollID).Delete(&models.VoterRoll{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func AddVoterRollEntry(entry models.VoterRollEntry) (models.VoterRollEntry, error) {
+	err := db.DB.Create(&entry).Error
+	if err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+func GetVoterRollEntriesByVoterRollID(voterRollID uint) ([]models.VoterRollEntry, error) {
+	entries := []models.VoterRollEntry{}
+	err := db.DB.Preload("User").Where("voter_roll_id = ?", voterRollID).Find(&entries).Error
+	if err != nil {
+		return entries, err
+	}
+	return entries, nil
+}
+
+func GetVoterRollEntryByEntryID(entryID uint) (models.VoterRollEntry, error) {
+	entry := models.VoterRollEntry{}
+	err := db.DB.Where("voter_roll_entry_id = ?", entryID).First(&entry).Error
+	if err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+func DeleteVoterRollEntry(entryID uint) error {
+	err := db.DB.Where("voter_roll_entry_id = ?", entryID).Delete(&models.VoterRollEntry{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func IsInVoterRoll(userID, voterRollID uint) bool {
+	entry := models.VoterRollEntry{}
+	err := db.DB.Where("user_id = ? AND voter_roll_id = ?", userID, voterRollID).First(&entry).Error
+	return err == nil
+}
+
+func IsEligibleVoter(userID uint, vote models.Vote) bool {
+	if vote.VoterRollID == nil {
+		return true
+	}
+	return IsInVoterRoll(userID, *vote.VoterRollID)
+}
+
+func CountVoterRollEntries(voterRollID uint) (int64, error) {
+	var total int64
+	err := db.DB.Model(&models.VoterRollEntry{}).Where("voter_roll_id = ?", voterRollID).Count(&total).Error
+	return total, err
+}
+
+// GetInvitedVoters returns the active users a vote is addressed to: the
+// entries of its voter roll, or else the members of its organization. Votes
+// joined by code alone address no one.
+func GetInvitedVoters(vote models.Vote) ([]models.User, error) {
+	users := []models.User{}
+	var invited interface{}
+	switch {
+	case vote.VoterRollID != nil:
+		invited = db.DB.
+			Model(&models.VoterRollEntry{}).
+			Select("user_id").
+			Where("voter_roll_id = ?", *vote.VoterRollID)
+	case vote.OrganizationID != nil:
+		invited = db.DB.
+			Model(&models.OrganizationMember{}).
+			Select("user_id").
+			Where("organization_id = ?", *vote.OrganizationID)
+	default:
+		return users, nil
+	}
+	err := db.DB.
+		Where("id IN (?)", invited).
+		Where("suspended = ? AND deleted_time IS NULL", false).
+		Find(&users).Error
+	if err != nil {
+		return users, err
+	}
+	return users, nil
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/db"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+)
+
+func CreateWebhookEndpoint(endpoint models.WebhookEndpoint) (models.WebhookEndpoint, error) {
+	err := db.DB.Create(&endpoint).Error
+	if err != nil {
+		return endpoint, err
+	}
+	return endpoint, nil
+}
+
+func GetWebhookEndpointsByModeratorID(moderatorID uint) ([]models.WebhookEndpoint, error) {
+	endpoints := []models.WebhookEndpoint{}
+	err := db.DB.
+		Preload("Organization").
+		Where("moderator_id = ?", moderatorID).
+		Order("webhook_endpoint_id desc").
+		Find(&endpoints).Error
+	if err != nil {
+		return endpoints, err
+	}
+	return endpoints, nil
+}
+
+func GetWebhookEndpointByID(webhookEndpointID uint) (models.WebhookEndpoint, error) {
+	endpoint := models.WebhookEndpoint{}
+	err := db.DB.Preload("Organization").Where("webhook_endpoint_id = ?", webhookEndpointID).First(&endpoint).Error
+	if err != nil {
+		return endpoint, err
+	}
+	return endpoint, nil
+}
+
+// GetActiveWebhookEndpointsForVote returns the endpoints registered for the
+// vote itself or for the organization it belongs to.
+func GetActiveWebhookEndpointsForVote(voteID uint, organizationID *uint) ([]models.WebhookEndpoint, error) {
+	endpoints := []models.WebhookEndpoint{}
+	tx := db.DB.Where("active = ?", true)
+	if organizationID != nil {
+		tx = tx.Where("vote_id = ? OR organization_id = ?", voteID, *organizationID)
+	} else {
+		tx = tx.Where("vote_id = ?", voteID)
+	}
+	err := tx.Find(&endpoints).Error
+	if err != nil {
+		return endpoints, err
+	}
+	return endpoints, nil
+}
+
+func DeactivateVoteWebhookEndpoints(voteID uint) error {
+	return db.DB.Model(&models.WebhookEndpoint{}).
+		Where("vote_id = ?", voteID).
+		Update("active", false).Error
+}
+
+func DeleteWebhookEndpoint(webhookEndpointID uint) error {
+	return db.DB.Where("webhook_endpoint_id = ?", webhookEndpointID).Delete(&models.WebhookEndpoint{}).Error
+}
+
+func CreateWebhookDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	err := db.DB.Create(&delivery).Error
+	if err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func GetWebhookDeliveryByID(webhookDeliveryID uint) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{}
+	err := db.DB.Where("webhook_delivery_id = ?", webhookDeliveryID).First(&delivery).Error
+	if err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func GetWebhookDeliveriesByEndpointID(webhookEndpointID uint, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := db.DB.
+		Where("webhook_endpoint_id = ?", webhookEndpointID).
+		Order("webhook_delivery_id desc").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return deliveries, err
+	}
+	return deliveries, nil
+}
+
+// GetDueWebhookDeliveryIDs returns pending deliveries whose retry time has
+// passed, plus "sending" deliveries whose worker died before finishing.
+func GetDueWebhookDeliveryIDs(now time.Time, staleBefore time.Time, limit int) ([]uint, error) {
+	ids := []uint{}
+	err := db.DB.Model(&models.WebhookDelivery{}).
+		Where("(status = 'pending' AND next_attempt_time <= ?) OR (status = 'sending' AND next_attempt_time <= ?)",
+			models.CustomTime{Time: now}, models.CustomTime{Time: staleBefore}).
+		Order("next_attempt_time").
+		Limit(limit).
+		Pluck("webhook_delivery_id", &ids).Error
+	if err != nil {
+		return ids, err
+	}
+	return ids, nil
+}
+
+// ClaimWebhookDelivery marks the delivery as being sent and reports whether
+// this caller won it, so several workers can poll the same table safely.
+func ClaimWebhookDelivery(webhookDeliveryID uint, now time.Time, staleBefore time.Time) (models.WebhookDelivery, bool, error) {
+	delivery := models.WebhookDelivery{}
+	result := db.DB.Model(&models.WebhookDelivery{}).
+		Where("webhook_delivery_id = ? AND ((status = 'pending' AND next_attempt_time <= ?) OR (status = 'sending' AND next_attempt_time <= ?))",
+			webhookDeliveryID, models.CustomTime{Time: now}, models.CustomTime{Time: staleBefore}).
+		Updates(map[string]interface{}{
+			"status":            "sending",
+			"next_attempt_time": models.CustomTime{Time: now},
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return delivery, false, result.Error
+	}
+	err := db.DB.Preload("WebhookEndpoint").Where("webhook_delivery_id = ?", webhookDeliveryID).First(&delivery).Error
+	if err != nil {
+		return delivery, false, err
+	}
+	return delivery, true, nil
+}
+
+func MarkWebhookDeliveryDelivered(webhookDeliveryID uint, attempts uint, responseStatus int, deliveredTime time.Time) error {
+	return db.DB.Model(&models.WebhookDelivery{}).
+		Where("webhook_delivery_id = ?", webhookDeliveryID).
+		Updates(map[string]interface{}{
+			"status":          "delivered",
+			"attempts":        attempts,
+			"response_status": responseStatus,
+			"last_error":      "",
+			"delivered_time":  models.CustomTime{Time: deliveredTime},
+		}).Error
+}
+
+func MarkWebhookDeliveryFailed(webhookDeliveryID uint, attempts uint, responseStatus int, lastError string, status string, nextAttemptTime time.Time) error {
+	return db.DB.Model(&models.WebhookDelivery{}).
+		Where("webhook_delivery_id = ?", webhookDeliveryID).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"attempts":          attempts,
+			"response_status":   responseStatus,
+			"last_error":        lastError,
+			"next_attempt_time": models.CustomTime{Time: nextAttemptTime},
+		}).Error
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/AndreanDjabbar/ElectiVote/internal/handlers"
+	"github.com/AndreanDjabbar/ElectiVote/internal/middlewares"
+	"github.com/AndreanDjabbar/ElectiVote/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,73 +24,199 @@ func MainRootHandler(c *gin.Context) {
 }
 
 func SetUpRoutes(router *gin.Engine) {
-	mainRouter := router.Group("/electivote")
+	mainRouter := router.Group("/electivote", middlewares.CSRFProtection("/electivote/webhook/saweria"))
 	{
 		router.GET("/", RootHandler)
+		router.GET("/images/*key", handlers.ServeImage)
 		mainRouter.GET("/", MainRootHandler)
 	}
+	guestRouter := mainRouter.Group("", middlewares.GuestOnly())
+	{
+		guestRouter.GET("login-page/", handlers.ViewLoginPage)
+		guestRouter.POST("login-page/", handlers.LoginPage)
+		guestRouter.GET("register-page/", handlers.ViewRegisterPage)
+		guestRouter.POST("register-page/", handlers.RegisterPage)
+	}
+	{
+		guestRouter.GET("forgot-password-page/", handlers.ViewForgotPasswordPage)
+		guestRouter.POST("forgot-password-page/", handlers.ForgotPasswordPage)
+		guestRouter.GET("reset-password-page/:token/", handlers.ViewResetPasswordPage)
+		guestRouter.POST("reset-password-page/:token/", handlers.ResetPasswordPage)
+	}
+	{
+		guestRouter.GET("email-verification-page/", handlers.ViewVerifyEmailPage)
+		guestRouter.POST("email-verification-page/", handlers.VerifyEmailPage)
+	}
+	{
+		guestRouter.GET("two-factor-page/", handlers.ViewTwoFactorLoginPage)
+		guestRouter.POST("two-factor-page/", handlers.TwoFactorLoginPage)
+	}
+	{
+		guestRouter.GET("oidc/:provider/", handlers.OIDCLoginPage)
+		guestRouter.GET("oidc/:provider/callback/", handlers.OIDCCallbackPage)
+	}
 	{
-		mainRouter.GET("login-page/", handlers.ViewLoginPage)
-		mainRouter.POST("login-page/", handlers.LoginPage)
-		mainRouter.GET("register-page/", handlers.ViewRegisterPage)
-		mainRouter.POST("register-page/", handlers.RegisterPage)
 		mainRouter.GET("logout/", handlers.LogoutPage)
-		mainRouter.GET("home-page/", handlers.ViewHomePage)
+		mainRouter.POST("webhook/saweria", handlers.SaweriaWebhook)
+		mainRouter.GET("verify-results/:voteHistoryID/:signature/", handlers.VerifyResultsPage)
 	}
+	authRouter := mainRouter.Group("", middlewares.AuthRequired())
 	{
-		mainRouter.GET("forgot-password-page/", handlers.ViewForgotPasswordPage)
-		mainRouter.POST("forgot-password-page/", handlers.ForgotPasswordPage)
-		mainRouter.GET("reset-password-page/:token/", handlers.ViewResetPasswordPage)
-		mainRouter.POST("reset-password-page/:token/", handlers.ResetPasswordPage)
+		authRouter.GET("home-page/", handlers.ViewHomePage)
+		authRouter.POST("logout-all/", handlers.LogoutAllDevicesPage)
 	}
 	{
-		mainRouter.GET("profile-page/", handlers.ViewProfilePage)
-		mainRouter.GET("edit-profile-page/", handlers.ViewEditProfilePage)
-		mainRouter.POST("edit-profile-page/", handlers.EditProfilePage)
+		authRouter.GET("profile-page/", handlers.ViewProfilePage)
+		authRouter.GET("edit-profile-page/", handlers.ViewEditProfilePage)
+		authRouter.POST("edit-profile-page/", handlers.EditProfilePage)
+		authRouter.GET("change-password-page/", handlers.ViewChangePasswordPage)
+		authRouter.POST("change-password-page/", handlers.ChangePasswordPage)
+		authRouter.GET("change-email-page/", handlers.ViewChangeEmailPage)
+		authRouter.POST("change-email-page/", handlers.ChangeEmailPage)
+		authRouter.GET("change-email-verification-page/", handlers.ViewChangeEmailVerificationPage)
+		authRouter.POST("change-email-verification-page/", handlers.ChangeEmailVerificationPage)
+		authRouter.GET("export-data/", handlers.ExportAccountDataPage)
+		authRouter.GET("delete-account-page/", handlers.ViewDeleteAccountPage)
+		authRouter.POST("delete-account-page/", handlers.DeleteAccountPage)
+		authRouter.POST("cancel-account-deletion/", handlers.CancelAccountDeletionPage)
+		authRouter.POST("email-language/", handlers.UpdateEmailLanguagePage)
+		authRouter.POST("revoke-remember-token/:rememberTokenID/", handlers.RevokeRememberTokenPage)
+		authRouter.POST("api-tokens/", handlers.CreatePersonalAccessTokenPage)
+		authRouter.POST("revoke-api-token/:personalAccessTokenID/", handlers.RevokePersonalAccessTokenPage)
 	}
 	{
-		mainRouter.GET("create-vote-page/", handlers.ViewCreateVotePage)
-		mainRouter.POST("create-vote-page/", handlers.CreateVotePage)
-		mainRouter.GET("manage-vote-page/", handlers.ViewManageVotesPage)
-		mainRouter.GET("manage-vote-page/:voteID/", handlers.ViewManageVotePage)
-		mainRouter.POST("manage-vote-page/:voteID/", handlers.ManageVotePage)
-		mainRouter.GET("delete-vote-page/:voteID/", handlers.ViewDeleteVotePage)
-		mainRouter.GET("delete-vote/:voteID/", handlers.DeleteVotePage)
+		authRouter.GET("two-factor-setup-page/", handlers.ViewTwoFactorSetupPage)
+		authRouter.POST("two-factor-setup-page/", handlers.TwoFactorSetupPage)
+		authRouter.POST("two-factor-recovery-codes/", handlers.RegenerateRecoveryCodesPage)
+		authRouter.POST("two-factor-disable/", handlers.DisableTwoFactorPage)
 	}
 	{
-		mainRouter.GET("add-candidate-page/:voteID/", handlers.ViewAddCandidatePage)
-		mainRouter.POST("add-candidate-page/:voteID/", handlers.AddCandidatePage)
-		mainRouter.GET("manage-candidate-page/:voteID/:candidateID/", handlers.ViewManageCandidatePage)
-		mainRouter.POST("manage-candidate-page/:voteID/:candidateID/", handlers.ManageCandidatePage)
-		mainRouter.GET("delete-candidate-page/:voteID/:candidateID/", handlers.ViewDeleteCandidatePage)
-		mainRouter.GET("delete-candidate/:voteID/:candidateID/", handlers.DeleteCandidatePage)
+		authRouter.GET("organizations-page/", handlers.ViewOrganizationsPage)
+		authRouter.GET("create-organization-page/", handlers.ViewCreateOrganizationPage)
+		authRouter.POST("create-organization-page/", handlers.CreateOrganizationPage)
 	}
+	moderatorRouter := authRouter.Group("", middlewares.RequireModeratorTwoFactor())
 	{
-		mainRouter.GET("join-vote-page/", handlers.ViewJoinVotePage)
-		mainRouter.POST("join-vote-page/", handlers.JoinVotePage)
-		mainRouter.GET("vote-page/:voteCode/", handlers.ViewVotePage)
-		mainRouter.POST("vote-page/:voteCode/", handlers.VotePage)
-		mainRouter.GET("vote-result-page/:voteID/", handlers.ViewVoteResultPage)
+		moderatorRouter.GET("create-vote-page/", handlers.ViewCreateVotePage)
+		moderatorRouter.POST("create-vote-page/", handlers.CreateVotePage)
+		moderatorRouter.GET("manage-vote-page/", handlers.ViewManageVotesPage)
+		moderatorRouter.GET("manage-vote-page/:voteID/", handlers.ViewManageVotePage)
+		moderatorRouter.POST("manage-vote-page/:voteID/", handlers.ManageVotePage)
+		moderatorRouter.GET("delete-vote-page/:voteID/", handlers.ViewDeleteVotePage)
+		moderatorRouter.POST("delete-vote/:voteID/", handlers.DeleteVotePage)
+		moderatorRouter.GET("duplicate-vote-page/:voteID/", handlers.ViewDuplicateVotePage)
+		moderatorRouter.POST("duplicate-vote-page/:voteID/", handlers.DuplicateVotePage)
+		moderatorRouter.POST("save-vote-template/:voteID/", handlers.SaveVoteTemplatePage)
+		moderatorRouter.GET("vote-templates-page/", handlers.ViewVoteTemplatesPage)
+		moderatorRouter.POST("use-vote-template/:voteTemplateID/", handlers.UseVoteTemplatePage)
+		moderatorRouter.POST("delete-vote-template/:voteTemplateID/", handlers.DeleteVoteTemplatePage)
 	}
 	{
-		mainRouter.GET("email-verification-page/", handlers.ViewVerifyEmailPage)
-		mainRouter.POST("email-verification-page/", handlers.VerifyEmailPage)
+		moderatorRouter.GET("manage-organization-page/:organizationID/", handlers.ViewManageOrganizationPage)
+		moderatorRouter.POST("manage-organization-page/:organizationID/", handlers.ManageOrganizationPage)
+		moderatorRouter.POST("organization-member/:organizationID/", handlers.AddOrganizationMemberPage)
+		moderatorRouter.POST("organization-member/:organizationID/:memberID/", handlers.UpdateOrganizationMemberPage)
+		moderatorRouter.POST("delete-organization-member/:organizationID/:memberID/", handlers.RemoveOrganizationMemberPage)
+		moderatorRouter.POST("create-voter-roll/:organizationID/", handlers.CreateVoterRollPage)
+		moderatorRouter.GET("manage-voter-roll-page/:organizationID/:voterRollID/", handlers.ViewManageVoterRollPage)
+		moderatorRouter.POST("manage-voter-roll-page/:organizationID/:voterRollID/", handlers.ManageVoterRollPage)
+		moderatorRouter.POST("delete-voter-roll-entry/:organizationID/:voterRollID/:entryID/", handlers.DeleteVoterRollEntryPage)
+		moderatorRouter.POST("delete-voter-roll/:organizationID/:voterRollID/", handlers.DeleteVoterRollPage)
 	}
 	{
-		mainRouter.GET("vote-history-page/", handlers.ViewVoteHistoryPage)
-		mainRouter.GET("vote-history-page/:voteHistoryID/", handlers.ViewVoteHistoryDetailPage)
+		moderatorRouter.GET("webhooks-page/", handlers.ViewWebhooksPage)
+		moderatorRouter.POST("webhooks-page/", handlers.CreateWebhookPage)
+		moderatorRouter.POST("delete-webhook/:webhookEndpointID/", handlers.DeleteWebhookPage)
+		moderatorRouter.GET("webhook-deliveries-page/:webhookEndpointID/", handlers.ViewWebhookDeliveriesPage)
+		moderatorRouter.POST("replay-webhook-delivery/:webhookEndpointID/:webhookDeliveryID/", handlers.ReplayWebhookDeliveryPage)
 	}
 	{
-		mainRouter.GET("about-us-page/", handlers.ViewAboutUsPage)
+		moderatorRouter.GET("add-candidate-page/:voteID/", handlers.ViewAddCandidatePage)
+		moderatorRouter.POST("add-candidate-page/:voteID/", handlers.AddCandidatePage)
+		moderatorRouter.GET("import-candidates-page/:voteID/", handlers.ViewImportCandidatesPage)
+		moderatorRouter.POST("import-candidates-page/:voteID/", handlers.ImportCandidatesPage)
+		moderatorRouter.POST("confirm-import-candidates/:voteID/", handlers.ConfirmImportCandidatesPage)
+		moderatorRouter.POST("cancel-import-candidates/:voteID/", handlers.CancelImportCandidatesPage)
+		moderatorRouter.GET("manage-candidate-page/:voteID/:candidateID/", handlers.ViewManageCandidatePage)
+		moderatorRouter.POST("manage-candidate-page/:voteID/:candidateID/", handlers.ManageCandidatePage)
+		moderatorRouter.GET("delete-candidate-page/:voteID/:candidateID/", handlers.ViewDeleteCandidatePage)
+		moderatorRouter.POST("delete-candidate/:voteID/:candidateID/", handlers.DeleteCandidatePage)
 	}
 	{
-		mainRouter.GET("feedback-page/", handlers.ViewGiveFeedbackPage)
-		mainRouter.POST("feedback-page/", handlers.GiveFeedbackPage)
+		authRouter.GET("join-vote-page/", handlers.ViewJoinVotePage)
+		authRouter.POST("join-vote-page/", handlers.JoinVotePage)
+		authRouter.GET("vote-page/:voteCode/", handlers.ViewVotePage)
+		authRouter.POST("vote-page/:voteCode/", handlers.VotePage)
+		authRouter.GET("vote-result-page/:voteID/", handlers.ViewVoteResultPage)
+		authRouter.GET("vote-result-stream/:voteID/", handlers.StreamVoteResultsPage)
+		authRouter.GET("export-vote-result/:voteID/", handlers.ExportVoteResultPage)
 	}
 	{
-		mainRouter.GET("support-page/", handlers.ViewSupportPage)
-		mainRouter.GET("support-page/saweria/", handlers.ViewSaweriaPage)
-		mainRouter.POST("webhook/saweria", handlers.SaweriaWebhook)
-		mainRouter.GET("thanks-page/", handlers.ViewThanksPage)
+		authRouter.GET("vote-history-page/", handlers.ViewVoteHistoryPage)
+		authRouter.GET("vote-history-page/:voteHistoryID/", handlers.ViewVoteHistoryDetailPage)
+		authRouter.GET("export-vote-history/:voteHistoryID/", handlers.ExportVoteHistoryPage)
 	}
-}
\ No newline at end of file
+	{
+		authRouter.GET("about-us-page/", handlers.ViewAboutUsPage)
+	}
+	{
+		authRouter.GET("feedback-page/", handlers.ViewGiveFeedbackPage)
+		authRouter.POST("feedback-page/", handlers.GiveFeedbackPage)
+	}
+	{
+		authRouter.GET("support-page/", handlers.ViewSupportPage)
+		authRouter.GET("support-page/saweria/", handlers.ViewSaweriaPage)
+		authRouter.GET("thanks-page/", handlers.ViewThanksPage)
+	}
+	adminRouter := authRouter.Group("admin", middlewares.RequireRole("admin"), middlewares.RequireModeratorTwoFactor())
+	{
+		adminRouter.GET("dashboard-page/", handlers.ViewAdminDashboardPage)
+		adminRouter.GET("users-page/", handlers.ViewAdminUsersPage)
+		adminRouter.POST("suspend-user/:userID/", handlers.SuspendUserPage)
+		adminRouter.POST("force-password-reset/:userID/", handlers.ForcePasswordResetPage)
+		adminRouter.POST("revoke-sessions/:userID/", handlers.RevokeUserSessionsPage)
+		adminRouter.POST("unlock-user/:userID/", handlers.UnlockUserPage)
+		adminRouter.GET("votes-page/", handlers.ViewAdminVotesPage)
+		adminRouter.GET("feedbacks-page/", handlers.ViewAdminFeedbacksPage)
+		adminRouter.GET("supports-page/", handlers.ViewAdminSupportsPage)
+		adminRouter.GET("emails-page/", handlers.ViewAdminEmailsPage)
+		adminRouter.POST("requeue-email/:outboundEmailID/", handlers.RequeueEmailPage)
+		adminRouter.GET("email-templates-page/", handlers.ViewAdminEmailTemplatesPage)
+		adminRouter.GET("email-template-preview/:templateName/:locale/", handlers.PreviewEmailTemplatePage)
+	}
+}
+
+// SetUpAPIRoutes registers the versioned JSON API. It lives outside the
+// /electivote group: requests authenticate with a bearer token (a login token
+// or a personal access token) or with the session cookie, which APIAuthRequired
+// checks against the X-CSRF-Token header for unsafe methods.
+func SetUpAPIRoutes(router *gin.Engine) {
+	apiRouter := router.Group("/api/v1")
+	{
+		apiRouter.GET("openapi.json", handlers.APIOpenAPIDocument)
+		apiRouter.POST("auth/login", handlers.APILogin)
+	}
+	apiAuthRouter := apiRouter.Group("", middlewares.APIAuthRequired())
+	{
+		apiAuthRouter.GET("auth/me", handlers.APIGetCurrentUser)
+		apiAuthRouter.POST("votes/join", middlewares.RequireAPIScope(utils.ScopeBallotsCast), handlers.APIJoinVote)
+		apiAuthRouter.GET("ballots/:voteCode", middlewares.RequireAPIScope(utils.ScopeBallotsCast), handlers.APIGetBallot)
+		apiAuthRouter.POST("ballots/:voteCode", middlewares.RequireAPIScope(utils.ScopeBallotsCast), handlers.APICastBallot)
+		apiAuthRouter.GET("history", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIListVoteHistory)
+		apiAuthRouter.GET("history/:voteHistoryID", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIGetVoteHistory)
+	}
+	apiModeratorRouter := apiAuthRouter.Group("", middlewares.APIRequireModeratorTwoFactor())
+	{
+		apiModeratorRouter.GET("votes", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIListVotes)
+		apiModeratorRouter.POST("votes", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APICreateVote)
+		apiModeratorRouter.GET("votes/:voteID", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIGetVote)
+		apiModeratorRouter.PATCH("votes/:voteID", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APIUpdateVote)
+		apiModeratorRouter.DELETE("votes/:voteID", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APIDeleteVote)
+		apiModeratorRouter.GET("votes/:voteID/results", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIGetVoteResults)
+		apiModeratorRouter.GET("votes/:voteID/candidates", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIListCandidates)
+		apiModeratorRouter.POST("votes/:voteID/candidates", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APICreateCandidate)
+		apiModeratorRouter.GET("votes/:voteID/candidates/:candidateID", middlewares.RequireAPIScope(utils.ScopeVotesRead), handlers.APIGetCandidate)
+		apiModeratorRouter.PATCH("votes/:voteID/candidates/:candidateID", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APIUpdateCandidate)
+		apiModeratorRouter.DELETE("votes/:voteID/candidates/:candidateID", middlewares.RequireAPIScope(utils.ScopeVotesManage), handlers.APIDeleteCandidate)
+	}
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APITokenTTL        = 24 * time.Hour
+	defaultAPIPageSize = 20
+	maxAPIPageSize     = 100
+
+	PersonalAccessTokenPrefix = "ev_pat_"
+
+	ScopeVotesRead   = "votes:read"
+	ScopeVotesManage = "votes:manage"
+	ScopeBallotsCast = "ballots:cast"
+)
+
+// APIScopes lists the scopes a personal access token can be granted, in the
+// order the profile page shows them.
+var APIScopes = []struct {
+	Name  string
+	Label string
+}{
+	{ScopeVotesRead, "Read votes, results and history"},
+	{ScopeVotesManage, "Create, edit and delete votes and candidates"},
+	{ScopeBallotsCast, "Join votes and cast ballots"},
+}
+
+// PersonalAccessTokenExpiryDays are the lifetimes offered when creating a token.
+var PersonalAccessTokenExpiryDays = []int{7, 30, 90, 365}
+
+type APIErrorBody struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+type APIPage struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"perPage"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"totalPages"`
+}
+
+// RenderAPIError writes the error envelope shared by every /api/v1 endpoint:
+// {"error": {"code": "...", "message": "...", "fields": {...}}}.
+func RenderAPIError(c *gin.Context, statusCode int, code, message string, fields map[string]string) {
+	c.AbortWithStatusJSON(statusCode, gin.H{
+		"error": APIErrorBody{
+			Code:    code,
+			Message: message,
+			Fields:  fields,
+		},
+	})
+}
+
+func RenderAPIData(c *gin.Context, statusCode int, data interface{}) {
+	c.JSON(statusCode, gin.H{
+		"data": data,
+	})
+}
+
+func RenderAPIList(c *gin.Context, data interface{}, page APIPage) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"meta": page,
+	})
+}
+
+// GetAPIPage reads ?page= and ?perPage= with sane defaults and bounds.
+func GetAPIPage(c *gin.Context) APIPage {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.Query("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = defaultAPIPageSize
+	}
+	if perPage > maxAPIPageSize {
+		perPage = maxAPIPageSize
+	}
+	return APIPage{Page: page, PerPage: perPage}
+}
+
+// Paginate slices items to the requested page and fills in the totals.
+func Paginate[T any](items []T, page APIPage) ([]T, APIPage) {
+	page.Total = int64(len(items))
+	page.TotalPages = int(math.Ceil(float64(len(items)) / float64(page.PerPage)))
+	start := (page.Page - 1) * page.PerPage
+	if start >= len(items) {
+		return []T{}, page
+	}
+	end := start + page.PerPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], page
+}
+
+func apiTokenKey() []byte {
+	return []byte("api:" + os.Getenv("SECRET_KEY"))
+}
+
+// apiTokenFingerprint ties a token to the current password hash and session
+// generation, so that a password change, a reset or any revocation of the
+// user's sessions invalidates every token issued before it.
+func apiTokenFingerprint(user models.User) string {
+	material := user.Password
+	if user.SessionGeneration > 0 {
+		material += ":" + strconv.FormatUint(uint64(user.SessionGeneration), 10)
+	}
+	sum := sha256.Sum256([]byte(material))
+	return hex.EncodeToString(sum[:8])
+}
+
+func GenerateAPIToken(user models.User) (string, time.Time, error) {
+	expiresAt := time.Now().Add(APITokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.Username,
+		"typ": "api",
+		"pwd": apiTokenFingerprint(user),
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	tokenString, err := token.SignedString(apiTokenKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// ParseAPIToken verifies the token signature and expiry and returns the
+// username it was issued to along with its password fingerprint.
+func ParseAPIToken(tokenString string) (string, string, error) {
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return apiTokenKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", errors.New("invalid or expired token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", errors.New("invalid token claims")
+	}
+	tokenType, _ := claims["typ"].(string)
+	username, _ := claims["sub"].(string)
+	fingerprint, _ := claims["pwd"].(string)
+	if tokenType != "api" || username == "" {
+		return "", "", errors.New("invalid token claims")
+	}
+	return username, fingerprint, nil
+}
+
+func IsCurrentAPIToken(user models.User, fingerprint string) bool {
+	return fingerprint == apiTokenFingerprint(user)
+}
+
+func IsValidAPIScope(scope string) bool {
+	for _, apiScope := range APIScopes {
+		if apiScope.Name == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAPIScope reports whether the comma separated granted scopes allow scope.
+// Managing votes implies reading them.
+func HasAPIScope(granted, scope string) bool {
+	for _, grantedScope := range strings.Split(granted, ",") {
+		if grantedScope == scope || (grantedScope == ScopeVotesManage && scope == ScopeVotesRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// GeneratePersonalAccessToken returns a new "ev_pat_..." token together with
+// the SHA-256 hash that is stored in its place.
+func GeneratePersonalAccessToken() (string, string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", "", err
+	}
+	token := PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashPersonalAccessToken(token), nil
+}
+
+func HashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
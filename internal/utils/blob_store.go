@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+)
+
+// BlobStore keeps uploaded media under slash separated keys. Records store
+// the key, never a path or URL, so the backend can be swapped with
+// STORAGE_DRIVER and existing media moved with cmd/MigrateMedia.
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	// Open returns ErrBlobNotFound when there is no blob under the key.
+	Open(key string) (io.ReadCloser, error)
+	Exists(key string) (bool, error)
+	// Delete succeeds when the blob is already gone.
+	Delete(key string) error
+	List(prefix string) ([]string, error)
+	// URL is the address the browser loads the blob from. For private
+	// stores it is signed and expires.
+	URL(key string) string
+}
+
+// LocalBlobStore keeps blobs as files under Dir and serves them from
+// /images/. It only works for a single instance.
+type LocalBlobStore struct {
+	Dir     string
+	Private bool
+	URLTTL  time.Duration
+}
+
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+
+	blobStoreOnce sync.Once
+	blobStore     BlobStore
+)
+
+func validateBlobKey(key string) error {
+	if key == "" || len(key) > 1024 || strings.ContainsAny(key, "\\\x00") {
+		return ErrInvalidBlobKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidBlobKey
+		}
+	}
+	return nil
+}
+
+// IsImageKey reports whether key names a published image. Images live at the
+// top level of the store; nested keys hold staged files that are not served.
+func IsImageKey(key string) bool {
+	return validateBlobKey(key) == nil && !strings.Contains(key, "/") && !strings.HasPrefix(key, ".")
+}
+
+func blobURLSigningKey() []byte {
+	return []byte("images:" + os.Getenv("SECRET_KEY"))
+}
+
+// signedURLWindow returns when a signed URL is signed and when it expires.
+// Both are rounded to the TTL so that a page keeps linking the same URL, which
+// the browser can cache, for a whole window. A URL stays valid for between
+// one and two TTLs.
+func signedURLWindow(ttl time.Duration) (time.Time, time.Time) {
+	signed := time.Now().UTC().Truncate(ttl)
+	return signed, signed.Add(2 * ttl)
+}
+
+func blobURLSignature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, blobURLSigningKey())
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedImageURL is the /images/ URL of a private image, valid until about
+// twice the TTL from now.
+func SignedImageURL(key string, ttl time.Duration) string {
+	_, expires := signedURLWindow(ttl)
+	values := url.Values{}
+	values.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	values.Set("signature", blobURLSignature(key, expires.Unix()))
+	return "/images/" + url.PathEscape(key) + "?" + values.Encode()
+}
+
+// IsValidImageSignature checks the expires and signature query parameters of
+// a signed /images/ URL.
+func IsValidImageSignature(key, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(blobURLSignature(key, expiresAt)))
+}
+
+func (l *LocalBlobStore) Path(key string) (string, error) {
+	err := validateBlobKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(key)), nil
+}
+
+func (l *LocalBlobStore) Put(key string, data []byte, contentType string) error {
+	destinationPath, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(destinationPath), 0o755)
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(destinationPath), ".blob-*")
+	if err != nil {
+		return err
+	}
+	_, err = temporary.Write(data)
+	closeErr := temporary.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temporary.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(temporary.Name(), destinationPath)
+	}
+	if err != nil {
+		os.Remove(temporary.Name())
+	}
+	return err
+}
+
+func (l *LocalBlobStore) Open(key string) (io.ReadCloser, error) {
+	path, err := l.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (l *LocalBlobStore) Exists(key string) (bool, error) {
+	path, err := l.Path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *LocalBlobStore) Delete(key string) error {
+	path, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	// Nested keys leave their directories behind; removing one only succeeds
+	// once it is empty.
+	for directory := filepath.Dir(path); directory != filepath.Clean(l.Dir) && strings.HasPrefix(directory, filepath.Clean(l.Dir)); directory = filepath.Dir(directory) {
+		if os.Remove(directory) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (l *LocalBlobStore) List(prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.WalkDir(l.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == l.Dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != l.Dir {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(l.Dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (l *LocalBlobStore) URL(key string) string {
+	if l.Private {
+		return SignedImageURL(key, l.URLTTL)
+	}
+	return "/images/" + url.PathEscape(key)
+}
+
+func NewBlobStore(storageConfig config.StorageConfig) (BlobStore, error) {
+	switch storageConfig.Driver {
+	case "local":
+		return &LocalBlobStore{
+			Dir:     storageConfig.LocalDir,
+			Private: storageConfig.Private,
+			URLTTL:  storageConfig.URLTTL,
+		}, nil
+	case "s3":
+		if storageConfig.S3Bucket == "" {
+			return nil, fmt.Errorf("S3_BUCKET is not configured")
+		}
+		return &S3BlobStore{
+			Endpoint:        storageConfig.S3Endpoint,
+			Region:          storageConfig.S3Region,
+			Bucket:          storageConfig.S3Bucket,
+			AccessKeyID:     storageConfig.S3AccessKeyID,
+			SecretAccessKey: storageConfig.S3SecretAccessKey,
+			PathStyle:       storageConfig.S3PathStyle,
+			PublicURL:       storageConfig.S3PublicURL,
+			Private:         storageConfig.Private,
+			URLTTL:          storageConfig.URLTTL,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported storage driver: %s", storageConfig.Driver)
+}
+
+// GetBlobStore panics on an invalid storage configuration: falling back to
+// local disks would silently split the media of several instances.
+func GetBlobStore() BlobStore {
+	blobStoreOnce.Do(func() {
+		var err error
+		blobStore, err = NewBlobStore(config.GetStorageConfig())
+		if err != nil {
+			logger.Error(
+				"GetBlobStore - invalid storage configuration",
+				"error", err,
+			)
+			panic("invalid storage configuration: " + err.Error())
+		}
+	})
+	return blobStore
+}
+
+// ReadBlob returns the whole content of a blob.
+func ReadBlob(store BlobStore, key string) ([]byte, error) {
+	reader, err := store.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
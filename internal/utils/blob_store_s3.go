@@ -0,0 +1,355 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm          = "AWS4-HMAC-SHA256"
+	s3TimeFormat         = "20060102T150405Z"
+	s3DateFormat         = "20060102"
+	s3UnsignedPayload    = "UNSIGNED-PAYLOAD"
+	s3MaxPresignedExpiry = 7 * 24 * time.Hour
+)
+
+// S3BlobStore keeps blobs in a bucket of Amazon S3 or a compatible server such
+// as MinIO. Requests are signed with AWS Signature Version 4. Public stores
+// link objects directly, or through PublicURL such as a CDN; private stores
+// link presigned URLs.
+type S3BlobStore struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool
+	PublicURL       string
+	Private         bool
+	URLTTL          time.Duration
+	Client          *http.Client
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type s3ErrorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+var s3HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// awsURIEncode percent-encodes everything but the unreserved characters, as
+// SigV4 requires for the canonical path and query.
+func awsURIEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		character := value[i]
+		switch {
+		case 'A' <= character && character <= 'Z', 'a' <= character && character <= 'z', '0' <= character && character <= '9',
+			character == '-', character == '_', character == '.', character == '~':
+			builder.WriteByte(character)
+		case character == '/' && !encodeSlash:
+			builder.WriteByte(character)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", character)
+		}
+	}
+	return builder.String()
+}
+
+func s3CanonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		keyValues := append([]string(nil), values[key]...)
+		sort.Strings(keyValues)
+		for _, value := range keyValues {
+			pairs = append(pairs, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (s *S3BlobStore) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return s3HTTPClient
+}
+
+// url addresses an object, or the bucket itself when key is empty.
+func (s *S3BlobStore) url(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %s", s.Endpoint)
+	}
+	objectURL := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: "/" + key}
+	if s.PathStyle {
+		objectURL.Path = "/" + s.Bucket
+		if key != "" {
+			objectURL.Path += "/" + key
+		}
+	} else {
+		objectURL.Host = s.Bucket + "." + endpoint.Host
+	}
+	objectURL.RawPath = awsURIEncode(objectURL.Path, false)
+	return objectURL, nil
+}
+
+func (s *S3BlobStore) scope(signed time.Time) string {
+	return signed.Format(s3DateFormat) + "/" + s.Region + "/s3/aws4_request"
+}
+
+func (s *S3BlobStore) signature(signed time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		signed.Format(s3TimeFormat),
+		s.scope(signed),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), signed.Format(s3DateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3BlobStore) do(method, key string, query url.Values, body []byte, contentType string) (*http.Response, error) {
+	requestURL, err := s.url(key)
+	if err != nil {
+		return nil, err
+	}
+	requestURL.RawQuery = s3CanonicalQuery(query)
+	request, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	signed := time.Now().UTC()
+	payloadHash := sha256Hex(body)
+	request.Header.Set("X-Amz-Date", signed.Format(s3TimeFormat))
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		method,
+		requestURL.EscapedPath(),
+		requestURL.RawQuery,
+		"host:" + requestURL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + signed.Format(s3TimeFormat),
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm,
+		s.AccessKeyID,
+		s.scope(signed),
+		signedHeaders,
+		s.signature(signed, canonicalRequest),
+	))
+	return s.client().Do(request)
+}
+
+func s3Error(operation, key string, response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	errorResponse := s3ErrorResponse{}
+	if xml.Unmarshal(body, &errorResponse) == nil && errorResponse.Code != "" {
+		return fmt.Errorf("s3 %s %q: %s (%s)", operation, key, errorResponse.Message, errorResponse.Code)
+	}
+	return fmt.Errorf("s3 %s %q: unexpected status %d", operation, key, response.StatusCode)
+}
+
+func (s *S3BlobStore) Put(key string, data []byte, contentType string) error {
+	err := validateBlobKey(key)
+	if err != nil {
+		return err
+	}
+	response, err := s.do(http.MethodPut, key, nil, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return s3Error("put", key, response)
+	}
+	return nil
+}
+
+func (s *S3BlobStore) Open(key string) (io.ReadCloser, error) {
+	err := validateBlobKey(key)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.do(http.MethodGet, key, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusOK {
+		return response.Body, nil
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	return nil, s3Error("get", key, response)
+}
+
+func (s *S3BlobStore) Exists(key string) (bool, error) {
+	err := validateBlobKey(key)
+	if err != nil {
+		return false, err
+	}
+	response, err := s.do(http.MethodHead, key, nil, nil, "")
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("s3 head %q: unexpected status %d", key, response.StatusCode)
+}
+
+func (s *S3BlobStore) Delete(key string) error {
+	err := validateBlobKey(key)
+	if err != nil {
+		return err
+	}
+	response, err := s.do(http.MethodDelete, key, nil, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return s3Error("delete", key, response)
+}
+
+func (s *S3BlobStore) List(prefix string) ([]string, error) {
+	keys := []string{}
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		response, err := s.do(http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			err = s3Error("list", prefix, response)
+			response.Body.Close()
+			return nil, err
+		}
+		result := s3ListBucketResult{}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s *S3BlobStore) URL(key string) string {
+	if s.Private {
+		signed, expires := signedURLWindow(s.URLTTL)
+		presignedURL, err := s.presignedURL(key, signed, min(expires.Sub(signed), s3MaxPresignedExpiry))
+		if err != nil {
+			return ""
+		}
+		return presignedURL
+	}
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + awsURIEncode(key, false)
+	}
+	objectURL, err := s.url(key)
+	if err != nil {
+		return ""
+	}
+	return objectURL.String()
+}
+
+// presignedURL is a GET URL for the object that carries its own signature in
+// the query string.
+func (s *S3BlobStore) presignedURL(key string, signed time.Time, expiry time.Duration) (string, error) {
+	objectURL, err := s.url(key)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.AccessKeyID+"/"+s.scope(signed))
+	query.Set("X-Amz-Date", signed.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		s3CanonicalQuery(query),
+		"host:" + objectURL.Host,
+		"",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(signed, canonicalRequest))
+	objectURL.RawQuery = s3CanonicalQuery(query)
+	return objectURL.String(), nil
+}
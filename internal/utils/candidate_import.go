@@ -0,0 +1,456 @@
+package utils
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+)
+
+const (
+	candidateImportTTL       = 30 * time.Minute
+	candidateImportKeyPrefix = "candidate_import:"
+	candidateImportsPrefix   = "candidate-imports/"
+
+	MaxCandidateImportRows     = 500
+	MaxCandidateImportCSVSize  = 1 << 20
+	MaxCandidateImportZIPSize  = 50 << 20
+	maxCandidateImportPictures = 1000
+)
+
+var (
+	ErrCandidateImportNotFound = errors.New("candidate import not found or expired")
+
+	candidatePictureExtensions = []string{".jpg", ".jpeg", ".png", ".gif"}
+)
+
+type CandidateImportRow struct {
+	Line        int      `json:"line"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Picture     string   `json:"picture"`
+	StagedFile  string   `json:"stagedFile"`
+	Errors      []string `json:"errors"`
+}
+
+// CandidateImport is a validated CSV/ZIP upload waiting for the moderator to
+// confirm it. It lives in Redis while its pictures are staged in the blob
+// store, so any instance can publish them.
+type CandidateImport struct {
+	Token       string               `json:"token"`
+	VoteID      uint                 `json:"voteId"`
+	ModeratorID uint                 `json:"moderatorId"`
+	Rows        []CandidateImportRow `json:"rows"`
+	Errors      []string             `json:"errors"`
+	CreatedTime time.Time            `json:"createdTime"`
+}
+
+func (i CandidateImport) HasErrors() bool {
+	if len(i.Errors) > 0 {
+		return true
+	}
+	for _, row := range i.Rows {
+		if len(row.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// StagingPrefix is the blob key prefix of the staged pictures. Staged keys
+// are nested, so they are never served as images. The prefix starts with the
+// creation time so abandoned imports can be swept without reading Redis for
+// fresh ones.
+func (i CandidateImport) StagingPrefix() string {
+	return candidateImportsPrefix + strconv.FormatInt(i.CreatedTime.Unix(), 10) + "-" + i.Token + "/"
+}
+
+// ParseCandidateImport validates the CSV (columns name, description and an
+// optional picture) and stages the pictures from the optional ZIP. Pictures
+// are matched by the file name in the picture column, or else by a file
+// named after the candidate. existingNames are the vote's current
+// candidates, which may not be imported twice.
+func ParseCandidateImport(voteID, moderatorID uint, csvFile io.Reader, zipFile io.ReaderAt, zipSize int64, existingNames []string) (CandidateImport, error) {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		return CandidateImport{}, err
+	}
+	candidateImport := CandidateImport{
+		Token:       hex.EncodeToString(token),
+		VoteID:      voteID,
+		ModeratorID: moderatorID,
+		Rows:        []CandidateImportRow{},
+		Errors:      []string{},
+		CreatedTime: time.Now(),
+	}
+
+	pictures := map[string]*zip.File{}
+	if zipFile != nil {
+		pictures, err = readCandidatePictures(zipFile, zipSize)
+		if err != nil {
+			candidateImport.Errors = append(candidateImport.Errors, err.Error())
+			return candidateImport, nil
+		}
+	}
+
+	reader := csv.NewReader(csvFile)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		candidateImport.Errors = append(candidateImport.Errors, "The CSV file is empty or unreadable")
+		return candidateImport, nil
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		candidateImport.Errors = append(candidateImport.Errors, "The CSV header must contain a name column")
+		return candidateImport, nil
+	}
+
+	seenNames := map[string]int{}
+	for _, name := range existingNames {
+		seenNames[strings.ToLower(strings.TrimSpace(name))] = 0
+	}
+	usedPictures := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			candidateImport.Errors = append(candidateImport.Errors, fmt.Sprintf("Line %d: %s", line, err.Error()))
+			return candidateImport, nil
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(candidateImport.Rows) == MaxCandidateImportRows {
+			candidateImport.Errors = append(candidateImport.Errors, fmt.Sprintf("A single import is limited to %d candidates", MaxCandidateImportRows))
+			return candidateImport, nil
+		}
+
+		row := CandidateImportRow{
+			Line:        line,
+			Name:        strings.TrimSpace(csvField(record, columns, "name")),
+			Description: strings.TrimSpace(csvField(record, columns, "description")),
+			Picture:     strings.TrimSpace(csvField(record, columns, "picture")),
+			Errors:      []string{},
+		}
+		nameLength := utf8.RuneCountInString(row.Name)
+		if nameLength < 3 || nameLength > 255 {
+			row.Errors = append(row.Errors, "Candidate name must be between 3 and 255 characters")
+		}
+		if firstLine, ok := seenNames[strings.ToLower(row.Name)]; ok && row.Name != "" {
+			if firstLine == 0 {
+				row.Errors = append(row.Errors, "A candidate with this name already exists in the vote")
+			} else {
+				row.Errors = append(row.Errors, fmt.Sprintf("Duplicate of the candidate on line %d", firstLine))
+			}
+		} else {
+			seenNames[strings.ToLower(row.Name)] = line
+		}
+
+		picture, pictureKey := matchCandidatePicture(pictures, row)
+		switch {
+		case row.Picture != "" && zipFile == nil:
+			row.Errors = append(row.Errors, "A picture is listed but no ZIP of pictures was uploaded")
+		case row.Picture != "" && !IsValidCandidatePictureName(row.Picture):
+			row.Errors = append(row.Errors, "Pictures must be JPEG, PNG or GIF files")
+		case row.Picture != "" && picture == nil:
+			row.Errors = append(row.Errors, "Picture "+row.Picture+" was not found in the ZIP")
+		case picture != nil && usedPictures[pictureKey] != 0:
+			row.Errors = append(row.Errors, fmt.Sprintf("Picture is already used by the candidate on line %d", usedPictures[pictureKey]))
+		case picture != nil && picture.UncompressedSize64 > MaxImageUploadSize:
+			row.Errors = append(row.Errors, "Picture is larger than 5 MB")
+		case picture != nil:
+			usedPictures[pictureKey] = line
+			row.Picture = path.Base(picture.Name)
+			if len(row.Errors) == 0 {
+				row.StagedFile, err = stageCandidatePicture(candidateImport.StagingPrefix(), picture)
+				if err != nil {
+					row.Errors = append(row.Errors, "Picture could not be read: "+err.Error())
+				}
+			}
+		}
+		candidateImport.Rows = append(candidateImport.Rows, row)
+	}
+	if len(candidateImport.Rows) == 0 {
+		candidateImport.Errors = append(candidateImport.Errors, "The CSV file has no candidate rows")
+	}
+	return candidateImport, nil
+}
+
+func readCandidatePictures(zipFile io.ReaderAt, zipSize int64) (map[string]*zip.File, error) {
+	archive, err := zip.NewReader(zipFile, zipSize)
+	if err != nil {
+		return nil, errors.New("The pictures file is not a valid ZIP archive")
+	}
+	if len(archive.File) > maxCandidateImportPictures {
+		return nil, fmt.Errorf("The ZIP may contain at most %d files", maxCandidateImportPictures)
+	}
+	pictures := map[string]*zip.File{}
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if !IsValidCandidatePictureName(name) {
+			continue
+		}
+		pictures[strings.ToLower(name)] = file
+	}
+	return pictures, nil
+}
+
+func matchCandidatePicture(pictures map[string]*zip.File, row CandidateImportRow) (*zip.File, string) {
+	if row.Picture != "" {
+		key := strings.ToLower(path.Base(row.Picture))
+		return pictures[key], key
+	}
+	for _, extension := range candidatePictureExtensions {
+		key := strings.ToLower(row.Name) + extension
+		if picture, ok := pictures[key]; ok {
+			return picture, key
+		}
+	}
+	return nil, ""
+}
+
+// stageCandidatePicture runs a picture from the ZIP through the image
+// pipeline into the staging area, under the name it will be published with.
+func stageCandidatePicture(stagingPrefix string, picture *zip.File) (string, error) {
+	source, err := picture.Open()
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	// The declared size in the ZIP header is not trusted.
+	data, err := io.ReadAll(io.LimitReader(source, MaxImageUploadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxImageUploadSize {
+		return "", errImageTooLarge
+	}
+	encoded, extension, err := ProcessImage(data)
+	if err != nil {
+		return "", err
+	}
+	return putContentAddressedImage(GetBlobStore(), stagingPrefix, encoded, extension)
+}
+
+func IsValidCandidatePictureName(name string) bool {
+	extension := strings.ToLower(path.Ext(name))
+	for _, allowed := range candidatePictureExtensions {
+		if extension == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func csvField(record []string, columns map[string]int, column string) string {
+	index, ok := columns[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return record[index]
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func SaveCandidateImport(candidateImport CandidateImport) error {
+	payload, err := json.Marshal(candidateImport)
+	if err != nil {
+		return err
+	}
+	return config.GetRedisClient().Set(context.Background(), candidateImportKeyPrefix+candidateImport.Token, payload, candidateImportTTL).Err()
+}
+
+// GetCandidateImport loads a pending import and checks that it belongs to the
+// given vote and moderator.
+func GetCandidateImport(token string, voteID, moderatorID uint) (CandidateImport, error) {
+	candidateImport := CandidateImport{}
+	payload, err := config.GetRedisClient().Get(context.Background(), candidateImportKeyPrefix+token).Bytes()
+	if err != nil {
+		return candidateImport, ErrCandidateImportNotFound
+	}
+	err = json.Unmarshal(payload, &candidateImport)
+	if err != nil {
+		return candidateImport, err
+	}
+	if candidateImport.VoteID != voteID || candidateImport.ModeratorID != moderatorID {
+		return CandidateImport{}, ErrCandidateImportNotFound
+	}
+	return candidateImport, nil
+}
+
+// ClaimCandidateImport loads a pending import and removes it from Redis in
+// one step, so when a confirmation is submitted twice only one request gets
+// to insert the candidates.
+func ClaimCandidateImport(token string, voteID, moderatorID uint) (CandidateImport, error) {
+	candidateImport, err := GetCandidateImport(token, voteID, moderatorID)
+	if err != nil {
+		return candidateImport, err
+	}
+	err = config.GetRedisClient().GetDel(context.Background(), candidateImportKeyPrefix+token).Err()
+	if err != nil {
+		return CandidateImport{}, ErrCandidateImportNotFound
+	}
+	return candidateImport, nil
+}
+
+// DiscardCandidateImport forgets a pending import and removes its staged
+// pictures. Pictures that were already published have been moved away.
+func DiscardCandidateImport(candidateImport CandidateImport) error {
+	err := config.GetRedisClient().Del(context.Background(), candidateImportKeyPrefix+candidateImport.Token).Err()
+	if candidateImport.Token != "" {
+		store := GetBlobStore()
+		stagedKeys, listErr := store.List(candidateImport.StagingPrefix())
+		if listErr != nil {
+			logger.Warn(
+				"DiscardCandidateImport - failed to list staged pictures",
+				"error", listErr.Error(),
+				"Token", candidateImport.Token,
+			)
+		}
+		for _, key := range stagedKeys {
+			store.Delete(key)
+		}
+	}
+	return err
+}
+
+// SweepCandidateImports removes the staged pictures of imports that were
+// neither confirmed nor cancelled, e.g. when the moderator left the preview
+// page, once their Redis entry has expired.
+func SweepCandidateImports() {
+	store := GetBlobStore()
+	keys, err := store.List(candidateImportsPrefix)
+	if err != nil {
+		logger.Error(
+			"SweepCandidateImports - failed to list staged pictures",
+			"error", err.Error(),
+		)
+		return
+	}
+	abandoned := map[string]bool{}
+	removed := 0
+	for _, key := range keys {
+		staging, _, found := strings.Cut(strings.TrimPrefix(key, candidateImportsPrefix), "/")
+		if !found {
+			continue
+		}
+		isAbandoned, checked := abandoned[staging]
+		if !checked {
+			isAbandoned = isAbandonedCandidateImport(staging)
+			abandoned[staging] = isAbandoned
+		}
+		if !isAbandoned {
+			continue
+		}
+		err = store.Delete(key)
+		if err != nil {
+			logger.Warn(
+				"SweepCandidateImports - failed to remove staged picture",
+				"error", err.Error(),
+				"Key", key,
+			)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		logger.Info(
+			"SweepCandidateImports - removed abandoned staged pictures",
+			"Removed", removed,
+		)
+	}
+}
+
+// isAbandonedCandidateImport reports whether the "<created>-<token>" staging
+// directory is older than an import lives and no longer has a Redis entry.
+func isAbandonedCandidateImport(staging string) bool {
+	created, token, found := strings.Cut(staging, "-")
+	if found {
+		createdUnix, err := strconv.ParseInt(created, 10, 64)
+		if err != nil || time.Since(time.Unix(createdUnix, 0)) < candidateImportTTL {
+			return false
+		}
+	} else {
+		// Staged before the prefix carried the creation time.
+		token = staging
+	}
+	exists, err := config.GetRedisClient().Exists(context.Background(), candidateImportKeyPrefix+token).Result()
+	return err == nil && exists == 0
+}
+
+// RunCandidateImportSweeper calls SweepCandidateImports every interval.
+func RunCandidateImportSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		SweepCandidateImports()
+		<-ticker.C
+	}
+}
+
+// PublishCandidateImportPicture copies a staged picture to the published
+// images and returns the key to store on the candidate.
+func PublishCandidateImportPicture(candidateImport CandidateImport, row CandidateImportRow) (string, error) {
+	if row.StagedFile == "" {
+		return DefaultImage, nil
+	}
+	name := filepath.Base(row.StagedFile)
+	store := GetBlobStore()
+	exists, err := store.Exists(name)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return name, nil
+	}
+	data, err := ReadBlob(store, candidateImport.StagingPrefix()+name)
+	if err != nil {
+		return "", err
+	}
+	err = store.Put(name, data, imageContentType(name))
+	if err != nil {
+		return "", err
+	}
+	err = GenerateImageVariants(name)
+	if err != nil {
+		logger.Warn(
+			"PublishCandidateImportPicture - failed to write image variants",
+			"error", err.Error(),
+			"Image", name,
+		)
+	}
+	return name, nil
+}
@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	proofOfWorkTTL       = 10 * time.Minute
+	proofOfWorkKeyPrefix = "captcha_pow:"
+)
+
+var captchaHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// CaptchaVerifier checks the token a CAPTCHA widget posted with a form. A
+// non-nil error means the provider could not give an answer (unreachable or a
+// malformed reply), as opposed to rejecting the token.
+type CaptchaVerifier interface {
+	ResponseField() string
+	Verify(response, remoteIP string) (bool, error)
+}
+
+// SiteVerifyCaptchaVerifier implements the siteverify protocol shared by
+// reCAPTCHA, hCaptcha and Turnstile.
+type SiteVerifyCaptchaVerifier struct {
+	VerifyURL string
+	SecretKey string
+	Field     string
+}
+
+func NewReCAPTCHAVerifier(secretKey string) *SiteVerifyCaptchaVerifier {
+	return &SiteVerifyCaptchaVerifier{
+		VerifyURL: "https://www.google.com/recaptcha/api/siteverify",
+		SecretKey: secretKey,
+		Field:     "g-recaptcha-response",
+	}
+}
+
+func NewHCaptchaVerifier(secretKey string) *SiteVerifyCaptchaVerifier {
+	return &SiteVerifyCaptchaVerifier{
+		VerifyURL: "https://api.hcaptcha.com/siteverify",
+		SecretKey: secretKey,
+		Field:     "h-captcha-response",
+	}
+}
+
+func NewTurnstileVerifier(secretKey string) *SiteVerifyCaptchaVerifier {
+	return &SiteVerifyCaptchaVerifier{
+		VerifyURL: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		SecretKey: secretKey,
+		Field:     "cf-turnstile-response",
+	}
+}
+
+func (v *SiteVerifyCaptchaVerifier) ResponseField() string {
+	return v.Field
+}
+
+func (v *SiteVerifyCaptchaVerifier) Verify(response, remoteIP string) (bool, error) {
+	if response == "" {
+		return false, nil
+	}
+	resp, err := captchaHTTPClient.PostForm(v.VerifyURL, url.Values{
+		"secret":   {v.SecretKey},
+		"response": {response},
+		"remoteip": {remoteIP},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, v.VerifyURL)
+	}
+
+	result := struct {
+		Success    *bool    `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return false, err
+	}
+	if result.Success == nil {
+		return false, errors.New("malformed siteverify response")
+	}
+	return *result.Success, nil
+}
+
+// LocalCaptchaVerifier is an offline stand-in for development, accepting the
+// form when its "I am not a robot" checkbox is ticked.
+type LocalCaptchaVerifier struct{}
+
+func (LocalCaptchaVerifier) ResponseField() string {
+	return "captcha-local"
+}
+
+func (LocalCaptchaVerifier) Verify(response, remoteIP string) (bool, error) {
+	return response == "on", nil
+}
+
+// rejectingCaptchaVerifier stands in when the CAPTCHA is misconfigured, so
+// protected forms fail closed instead of accepting every post.
+type rejectingCaptchaVerifier struct{}
+
+func (rejectingCaptchaVerifier) ResponseField() string {
+	return ""
+}
+
+func (rejectingCaptchaVerifier) Verify(response, remoteIP string) (bool, error) {
+	return false, nil
+}
+
+func NewCaptchaVerifier(captchaConfig config.CaptchaConfig) (CaptchaVerifier, error) {
+	switch captchaConfig.Provider {
+	case "recaptcha", "hcaptcha", "turnstile":
+		if captchaConfig.SecretKey == "" {
+			return nil, fmt.Errorf("captcha provider %q has no secret key", captchaConfig.Provider)
+		}
+	case "":
+		return nil, errors.New("CAPTCHA_PROVIDER is not configured")
+	}
+	switch captchaConfig.Provider {
+	case "recaptcha":
+		return NewReCAPTCHAVerifier(captchaConfig.SecretKey), nil
+	case "hcaptcha":
+		return NewHCaptchaVerifier(captchaConfig.SecretKey), nil
+	case "turnstile":
+		return NewTurnstileVerifier(captchaConfig.SecretKey), nil
+	case "local":
+		return LocalCaptchaVerifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown captcha provider %q", captchaConfig.Provider)
+}
+
+var (
+	captchaVerifier     CaptchaVerifier
+	captchaVerifierOnce sync.Once
+)
+
+func GetCaptchaVerifier() CaptchaVerifier {
+	captchaVerifierOnce.Do(func() {
+		verifier, err := NewCaptchaVerifier(config.GetCaptchaConfig())
+		if err != nil {
+			logger.Error(
+				"GetCaptchaVerifier - invalid captcha configuration, rejecting all captcha protected forms",
+				"error", err.Error(),
+			)
+			verifier = rejectingCaptchaVerifier{}
+		}
+		captchaVerifier = verifier
+	})
+	return captchaVerifier
+}
+
+// CaptchaWidget carries what the templates need to render the configured
+// CAPTCHA together with a proof-of-work challenge for the fallback.
+type CaptchaWidget struct {
+	Provider      string
+	SiteKey       string
+	ScriptURL     string
+	WidgetClass   string
+	PowChallenge  string
+	PowDifficulty int
+}
+
+func NewCaptchaWidget() CaptchaWidget {
+	captchaConfig := config.GetCaptchaConfig()
+	widget := CaptchaWidget{
+		Provider: captchaConfig.Provider,
+		SiteKey:  captchaConfig.SiteKey,
+	}
+	switch captchaConfig.Provider {
+	case "recaptcha":
+		widget.ScriptURL = "https://www.google.com/recaptcha/api.js?hl=en"
+		widget.WidgetClass = "g-recaptcha"
+	case "hcaptcha":
+		widget.ScriptURL = "https://js.hcaptcha.com/1/api.js?hl=en"
+		widget.WidgetClass = "h-captcha"
+	case "turnstile":
+		widget.ScriptURL = "https://challenges.cloudflare.com/turnstile/v0/api.js"
+		widget.WidgetClass = "cf-turnstile"
+	}
+	if captchaConfig.Provider != "local" && captchaConfig.ProofOfWorkFallback {
+		widget.PowChallenge = GenerateProofOfWorkChallenge(captchaConfig.ProofOfWorkBits)
+		widget.PowDifficulty = captchaConfig.ProofOfWorkBits
+	}
+	return widget
+}
+
+// IsValidCaptcha verifies the posted CAPTCHA token. When the provider cannot
+// be reached the proof-of-work solution computed by the page is checked instead.
+func IsValidCaptcha(c *gin.Context) bool {
+	verifier := GetCaptchaVerifier()
+	valid, err := verifier.Verify(c.PostForm(verifier.ResponseField()), c.ClientIP())
+	if err == nil {
+		return valid
+	}
+
+	logger.Warn(
+		"IsValidCaptcha - captcha provider unavailable, falling back to proof of work",
+		"error", err.Error(),
+		"Client IP", c.ClientIP(),
+	)
+	if !config.GetCaptchaConfig().ProofOfWorkFallback {
+		return false
+	}
+	return VerifyProofOfWork(c.Request.Context(), c.PostForm("pow_challenge"), c.PostForm("pow_nonce"))
+}
+
+func proofOfWorkSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte("captcha-pow:"+os.Getenv("SESSION_SECRET")))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateProofOfWorkChallenge returns a signed "<difficulty>.<issued>.<random>.<signature>"
+// challenge so it can be verified without server-side state.
+func GenerateProofOfWorkChallenge(difficulty int) string {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		logger.Error(
+			"GenerateProofOfWorkChallenge - failed to read random bytes",
+			"error", err.Error(),
+		)
+		return ""
+	}
+	payload := fmt.Sprintf("%d.%d.%s", difficulty, time.Now().Unix(), hex.EncodeToString(buf))
+	return payload + "." + proofOfWorkSignature(payload)
+}
+
+// VerifyProofOfWork checks that sha256(challenge + ":" + nonce) starts with the
+// challenge's number of zero bits. Each challenge is accepted only once.
+func VerifyProofOfWork(ctx context.Context, challenge, nonce string) bool {
+	challengeID, ok := checkProofOfWork(challenge, nonce)
+	if !ok {
+		return false
+	}
+	fresh, err := config.GetRedisClient().SetNX(ctx, proofOfWorkKeyPrefix+challengeID, 1, proofOfWorkTTL).Result()
+	if err != nil {
+		logger.Error(
+			"VerifyProofOfWork - failed to record challenge",
+			"error", err.Error(),
+		)
+		return false
+	}
+	return fresh
+}
+
+// checkProofOfWork does the checks of VerifyProofOfWork that need no state and
+// returns the random part identifying the challenge.
+func checkProofOfWork(challenge, nonce string) (string, bool) {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 || nonce == "" {
+		return "", false
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(proofOfWorkSignature(payload))) {
+		return "", false
+	}
+	difficulty, err := strconv.Atoi(parts[0])
+	if err != nil || difficulty < config.GetCaptchaConfig().ProofOfWorkBits {
+		return "", false
+	}
+	issued, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Since(time.Unix(issued, 0)) > proofOfWorkTTL {
+		return "", false
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return "", false
+	}
+	return parts[2], true
+}
+
+func leadingZeroBits(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+)
+
+func TestSiteVerifyCaptchaVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		response string
+		valid    bool
+		wantErr  bool
+	}{
+		{"accepted token", http.StatusOK, `{"success": true}`, "token", true, false},
+		{"rejected token", http.StatusOK, `{"success": false, "error-codes": ["invalid-input-response"]}`, "token", false, false},
+		{"missing success", http.StatusOK, `{"error-codes": ["internal-error"]}`, "token", false, true},
+		{"null success", http.StatusOK, `{"success": null}`, "token", false, true},
+		{"success as a string", http.StatusOK, `{"success": "true"}`, "token", false, true},
+		{"not JSON", http.StatusOK, `<html>maintenance</html>`, "token", false, true},
+		{"server error", http.StatusInternalServerError, `{"success": true}`, "token", false, true},
+		{"empty response field", http.StatusOK, `{"success": true}`, "", false, false},
+	}
+	for _, test := range tests {
+		var posted map[string][]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			posted = r.PostForm
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		verifier := &SiteVerifyCaptchaVerifier{VerifyURL: server.URL, SecretKey: "secret", Field: "g-recaptcha-response"}
+		valid, err := verifier.Verify(test.response, "203.0.113.7")
+		server.Close()
+
+		if valid != test.valid || (err != nil) != test.wantErr {
+			t.Errorf("%s: Verify = %v, %v; want %v with error %v", test.name, valid, err, test.valid, test.wantErr)
+		}
+		if test.response != "" && (len(posted["secret"]) != 1 || posted["secret"][0] != "secret" || posted["remoteip"][0] != "203.0.113.7") {
+			t.Errorf("%s: siteverify got form %v", test.name, posted)
+		}
+	}
+}
+
+func TestNewCaptchaVerifierFailsClosed(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.CaptchaConfig
+		wantErr bool
+	}{
+		{"recaptcha", config.CaptchaConfig{Provider: "recaptcha", SecretKey: "secret"}, false},
+		{"turnstile without secret", config.CaptchaConfig{Provider: "turnstile"}, true},
+		{"no provider", config.CaptchaConfig{}, true},
+		{"unknown provider", config.CaptchaConfig{Provider: "captcha.example", SecretKey: "secret"}, true},
+		{"explicit local", config.CaptchaConfig{Provider: "local"}, false},
+	}
+	for _, test := range tests {
+		verifier, err := NewCaptchaVerifier(test.config)
+		if (err != nil) != test.wantErr || (err == nil && verifier == nil) {
+			t.Errorf("%s: NewCaptchaVerifier = %v, %v; want error %v", test.name, verifier, err, test.wantErr)
+		}
+	}
+}
+
+func solveProofOfWork(t *testing.T, challenge string, difficulty int) string {
+	for nonce := 0; nonce < 1<<24; nonce++ {
+		sum := sha256.Sum256([]byte(challenge + ":" + strconv.Itoa(nonce)))
+		if leadingZeroBits(sum[:]) >= difficulty {
+			return strconv.Itoa(nonce)
+		}
+	}
+	t.Fatalf("no nonce solves %s", challenge)
+	return ""
+}
+
+// failProofOfWork returns a nonce whose hash misses the difficulty.
+func failProofOfWork(challenge string, difficulty int) string {
+	for nonce := 0; ; nonce++ {
+		sum := sha256.Sum256([]byte(challenge + ":" + strconv.Itoa(nonce)))
+		if leadingZeroBits(sum[:]) < difficulty {
+			return strconv.Itoa(nonce)
+		}
+	}
+}
+
+func signedProofOfWorkChallenge(difficulty int, issued time.Time, id string) string {
+	payload := fmt.Sprintf("%d.%d.%s", difficulty, issued.Unix(), id)
+	return payload + "." + proofOfWorkSignature(payload)
+}
+
+func TestCheckProofOfWork(t *testing.T) {
+	t.Setenv("SESSION_SECRET", "test-secret")
+	t.Setenv("CAPTCHA_POW_BITS", "8")
+
+	challenge := GenerateProofOfWorkChallenge(8)
+	solution := solveProofOfWork(t, challenge, 8)
+	parts := strings.Split(challenge, ".")
+
+	expired := signedProofOfWorkChallenge(8, time.Now().Add(-proofOfWorkTTL-time.Minute), "expired")
+	easy := signedProofOfWorkChallenge(4, time.Now(), "easy")
+	forged := strings.Join([]string{"8", parts[1], "forged", parts[3]}, ".")
+	lowered := strings.Join(append([]string{"4"}, parts[1:]...), ".")
+
+	tests := []struct {
+		name      string
+		challenge string
+		nonce     string
+		valid     bool
+	}{
+		{"solved challenge", challenge, solution, true},
+		{"unsolved challenge", challenge, failProofOfWork(challenge, 8), false},
+		{"empty nonce", challenge, "", false},
+		{"malformed challenge", "8.123.abc", solution, false},
+		{"forged random part", forged, solveProofOfWork(t, forged, 8), false},
+		{"lowered difficulty", lowered, solveProofOfWork(t, lowered, 4), false},
+		{"below the configured difficulty", easy, solveProofOfWork(t, easy, 4), false},
+		{"expired challenge", expired, solveProofOfWork(t, expired, 8), false},
+	}
+	for _, test := range tests {
+		challengeID, ok := checkProofOfWork(test.challenge, test.nonce)
+		if ok != test.valid {
+			t.Errorf("%s: checkProofOfWork = %v, want %v", test.name, ok, test.valid)
+			continue
+		}
+		if ok && challengeID != parts[2] {
+			t.Errorf("%s: challenge ID = %q, want %q", test.name, challengeID, parts[2])
+		}
+	}
+
+	t.Setenv("SESSION_SECRET", "rotated-secret")
+	if _, ok := checkProofOfWork(challenge, solution); ok {
+		t.Error("checkProofOfWork accepted a challenge signed with another secret")
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		sum  []byte
+		want int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x40}, 9},
+		{[]byte{0x00, 0x00, 0x0f}, 20},
+		{[]byte{0x00, 0x00}, 16},
+	}
+	for _, test := range tests {
+		if got := leadingZeroBits(test.sum); got != test.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", test.sum, got, test.want)
+		}
+	}
+}
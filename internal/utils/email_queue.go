@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"math"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	emailPollInterval   = 5 * time.Second
+	emailBatchSize      = 20
+	emailMaxAttempts    = 6
+	emailRetryBaseDelay = 30 * time.Second
+	emailRetryMaxDelay  = time.Hour
+	emailSendingTimeout = 10 * time.Minute
+)
+
+var emailQueueWakeup = make(chan struct{}, 1)
+
+// QueueTemplateEmail renders the named email template in the given locale and
+// queues it with both HTML and plain-text parts. The background workers send
+// it, so SMTP latency or outages never block a request.
+func QueueTemplateEmail(email, locale, templateName string, data map[string]interface{}) error {
+	rendered, err := RenderEmail(templateName, locale, data)
+	if err != nil {
+		logger.Error(
+			"QueueTemplateEmail - failed to render email",
+			"error", err,
+			"Template", templateName,
+		)
+		return err
+	}
+	return queueEmailMessage(EmailMessage{
+		To:       email,
+		Subject:  rendered.Subject,
+		Body:     rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+	})
+}
+
+func queueEmailMessage(message EmailMessage) error {
+	now := models.CustomTime{Time: time.Now()}
+	_, err := repositories.CreateOutboundEmail(factories.OutboundEmailFactory(message.To, message.Subject, message.Body, message.TextBody, now))
+	if err != nil {
+		logger.Error(
+			"queueEmailMessage - failed to queue email",
+			"error", err,
+		)
+		return err
+	}
+	select {
+	case emailQueueWakeup <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StartEmailWorkers launches the given number of goroutines that deliver
+// queued emails through the configured Mailer.
+func StartEmailWorkers(count int) {
+	for i := 0; i < count; i++ {
+		go runEmailWorker()
+	}
+}
+
+func runEmailWorker() {
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+	for {
+		processEmailQueue()
+		select {
+		case <-ticker.C:
+		case <-emailQueueWakeup:
+		}
+	}
+}
+
+func processEmailQueue() {
+	now := time.Now()
+	staleBefore := now.Add(-emailSendingTimeout)
+	ids, err := repositories.GetDueOutboundEmailIDs(now, staleBefore, emailBatchSize)
+	if err != nil {
+		logger.Error(
+			"processEmailQueue - failed to get due emails",
+			"error", err,
+		)
+		return
+	}
+	for _, id := range ids {
+		email, claimed, err := repositories.ClaimOutboundEmail(id, time.Now(), staleBefore)
+		if err != nil {
+			logger.Error(
+				"processEmailQueue - failed to claim email",
+				"error", err,
+				"Outbound Email ID", id,
+			)
+			continue
+		}
+		if claimed {
+			deliverOutboundEmail(email)
+		}
+	}
+}
+
+func deliverOutboundEmail(email models.OutboundEmail) {
+	attempts := email.Attempts + 1
+	err := SendEmailMessage(EmailMessage{
+		To:       email.Recipient,
+		Subject:  email.Subject,
+		Body:     email.Body,
+		TextBody: email.TextBody,
+	})
+	if err == nil {
+		err = repositories.MarkOutboundEmailSent(email.OutboundEmailID, attempts, time.Now())
+		if err != nil {
+			logger.Error(
+				"deliverOutboundEmail - failed to mark email as sent",
+				"error", err,
+				"Outbound Email ID", email.OutboundEmailID,
+			)
+		}
+		return
+	}
+
+	status := "pending"
+	if attempts >= emailMaxAttempts {
+		status = "dead"
+	}
+	nextAttemptTime := time.Now().Add(retryDelay(attempts, emailRetryBaseDelay, emailRetryMaxDelay))
+	logger.Warn(
+		"deliverOutboundEmail - delivery failed",
+		"error", err,
+		"Outbound Email ID", email.OutboundEmailID,
+		"Attempts", attempts,
+		"Status", status,
+	)
+	err = repositories.MarkOutboundEmailFailed(email.OutboundEmailID, attempts, err.Error(), status, nextAttemptTime)
+	if err != nil {
+		logger.Error(
+			"deliverOutboundEmail - failed to record delivery failure",
+			"error", err,
+			"Outbound Email ID", email.OutboundEmailID,
+		)
+	}
+}
+
+// retryDelay doubles the wait after each failed attempt, e.g. 30s, 1m, 2m, ...
+// capped at maxDelay. Used by the email and webhook queues.
+func retryDelay(attempts uint, baseDelay, maxDelay time.Duration) time.Duration {
+	delay := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempts-1)))
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	htmlTemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	emailTemplateDir = "internal/views/email"
+	DefaultLocale    = "en"
+)
+
+var SupportedLocales = []string{"en", "id"}
+
+var EmailTemplateNames = []string{
+	"otp",
+	"reset_password",
+	"email_changed",
+	"account_deletion",
+	"account_locked",
+	"vote_invitation",
+	"vote_reminder",
+	"vote_results",
+}
+
+type RenderedEmail struct {
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// NormalizeLocale maps a language tag such as "id-ID" to a supported locale,
+// falling back to English.
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	for _, supported := range SupportedLocales {
+		if locale == supported || strings.HasPrefix(locale, supported+"-") || strings.HasPrefix(locale, supported+"_") {
+			return supported
+		}
+	}
+	return DefaultLocale
+}
+
+func GetRequestLocale(c *gin.Context) string {
+	acceptLanguage := c.GetHeader("Accept-Language")
+	first := strings.SplitN(acceptLanguage, ",", 2)[0]
+	return NormalizeLocale(strings.SplitN(first, ";", 2)[0])
+}
+
+func emailTemplatePath(name, locale, extension string) string {
+	path := filepath.Join(emailTemplateDir, name+"."+locale+"."+extension)
+	if _, err := os.Stat(path); err != nil {
+		return filepath.Join(emailTemplateDir, name+"."+DefaultLocale+"."+extension)
+	}
+	return path
+}
+
+// RenderEmail renders the named template inside the shared layout, producing
+// the subject, HTML body and plain-text alternative for the given locale.
+func RenderEmail(name, locale string, data map[string]interface{}) (RenderedEmail, error) {
+	locale = NormalizeLocale(locale)
+	templateData := map[string]interface{}{
+		"appURL": config.GetBaseURL(),
+		"locale": locale,
+	}
+	for key, value := range data {
+		templateData[key] = value
+	}
+
+	htmlPath := emailTemplatePath(name, locale, "html")
+	htmlTemplates, err := htmlTemplate.ParseFiles(
+		filepath.Join(emailTemplateDir, "layout.html"),
+		htmlPath,
+	)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+	textTemplates, err := textTemplate.ParseFiles(
+		filepath.Join(emailTemplateDir, "layout.txt"),
+		emailTemplatePath(name, locale, "txt"),
+	)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+
+	// The subject is a header, not HTML, so it is rendered without escaping.
+	subjectTemplates, err := textTemplate.ParseFiles(htmlPath)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+
+	var subject, htmlBody, textBody bytes.Buffer
+	err = subjectTemplates.ExecuteTemplate(&subject, "subject", templateData)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+	err = htmlTemplates.ExecuteTemplate(&htmlBody, "layout", templateData)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+	err = textTemplates.ExecuteTemplate(&textBody, "layout", templateData)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+
+	return RenderedEmail{
+		Subject:  strings.TrimSpace(subject.String()),
+		HTMLBody: htmlBody.String(),
+		TextBody: textBody.String(),
+	}, nil
+}
@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const variantJPEGQuality = 85
+
+// imageVariant is a resized copy stored next to the original as
+// <name>_<suffix><ext>. Width variants are only made for images wider than
+// the variant, so a missing one means the original is small enough. The Go
+// standard library has no WebP encoder, so variants keep the format of the
+// original.
+type imageVariant struct {
+	suffix string
+	width  int
+	square bool
+}
+
+// imageVariantInfo is what was recorded about the variants of a stored image.
+type imageVariantInfo struct {
+	width     int
+	thumbnail bool
+	widths    []imageVariant
+}
+
+var (
+	avatarVariant = imageVariant{suffix: "thumb", width: 160, square: true}
+	widthVariants = []imageVariant{
+		{suffix: "w480", width: 480},
+		{suffix: "w1024", width: 1024},
+	}
+
+	// imageVariantIndex holds the recorded variants of every stored image, so
+	// rendering a page never touches the blob store or the database. It is
+	// loaded at startup and refreshed for images stored by other instances.
+	imageVariantIndex sync.Map
+)
+
+// TemplateFuncs are the image helpers available in the HTML templates.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"imageSrcset": ImageSrcset,
+		"avatarSrc":   AvatarSrc,
+	}
+}
+
+func imageVariantName(name, suffix string) string {
+	extension := filepath.Ext(name)
+	return strings.TrimSuffix(name, extension) + "_" + suffix + extension
+}
+
+func isImageVariantName(name string) bool {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if strings.HasSuffix(stem, "_"+avatarVariant.suffix) {
+		return true
+	}
+	for _, variant := range widthVariants {
+		if strings.HasSuffix(stem, "_"+variant.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageURL is the address of a stored image, see BlobStore.URL.
+func ImageURL(name string) string {
+	return GetBlobStore().URL(name)
+}
+
+// lookupImageVariants only reads the index; an image without recorded
+// variants is linked as is.
+func lookupImageVariants(name string) imageVariantInfo {
+	if info, ok := imageVariantIndex.Load(name); ok {
+		return info.(imageVariantInfo)
+	}
+	return imageVariantInfo{}
+}
+
+func imageVariantInfoFromRecord(record models.ImageVariants) imageVariantInfo {
+	info := imageVariantInfo{width: record.Width, thumbnail: record.Thumbnail}
+	for _, width := range strings.Split(record.Widths, ",") {
+		for _, variant := range widthVariants {
+			if width == strconv.Itoa(variant.width) {
+				info.widths = append(info.widths, variant)
+			}
+		}
+	}
+	return info
+}
+
+// recordImageVariants saves what writeImageVariants wrote, in the database for
+// the other instances and in the index for this one.
+func recordImageVariants(name string, width, height int, widths []imageVariant) error {
+	recordedWidths := []string{}
+	for _, variant := range widths {
+		recordedWidths = append(recordedWidths, strconv.Itoa(variant.width))
+	}
+	record := models.ImageVariants{
+		ImageName: name,
+		Width:     width,
+		Height:    height,
+		Thumbnail: true,
+		Widths:    strings.Join(recordedWidths, ","),
+	}
+	err := repositories.SaveImageVariants(record)
+	if err != nil {
+		return err
+	}
+	imageVariantIndex.Store(name, imageVariantInfoFromRecord(record))
+	return nil
+}
+
+// LoadImageVariants replaces the index with the variants recorded in the
+// database.
+func LoadImageVariants() error {
+	records, err := repositories.GetAllImageVariants()
+	if err != nil {
+		return err
+	}
+	recorded := map[string]bool{}
+	for _, record := range records {
+		recorded[record.ImageName] = true
+		imageVariantIndex.Store(record.ImageName, imageVariantInfoFromRecord(record))
+	}
+	imageVariantIndex.Range(func(name, _ interface{}) bool {
+		if !recorded[name.(string)] {
+			imageVariantIndex.Delete(name)
+		}
+		return true
+	})
+	return nil
+}
+
+// RefreshImageVariants reloads the index every interval, so images stored
+// or removed by another instance show up on this one.
+func RefreshImageVariants(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		<-ticker.C
+		err := LoadImageVariants()
+		if err != nil {
+			logger.Error(
+				"RefreshImageVariants - failed to load image variants",
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+// AvatarSrc is the URL of the square, center-cropped thumbnail of an image,
+// or of the original when it has no thumbnail.
+func AvatarSrc(name string) string {
+	name = filepath.Base(name)
+	if lookupImageVariants(name).thumbnail {
+		return ImageURL(imageVariantName(name, avatarVariant.suffix))
+	}
+	return ImageURL(name)
+}
+
+// ImageSrcset renders the src, srcset and sizes attributes of an <img> so the
+// browser can pick the smallest variant that fits the rendered size.
+func ImageSrcset(name, sizes string) template.HTMLAttr {
+	name = filepath.Base(name)
+	info := lookupImageVariants(name)
+	if len(info.widths) == 0 {
+		return template.HTMLAttr(fmt.Sprintf(`src="%s"`, html.EscapeString(ImageURL(name))))
+	}
+	candidates := []string{}
+	for _, variant := range info.widths {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", ImageURL(imageVariantName(name, variant.suffix)), variant.width))
+	}
+	candidates = append(candidates, fmt.Sprintf("%s %dw", ImageURL(name), info.width))
+	return template.HTMLAttr(fmt.Sprintf(
+		`src="%s" srcset="%s" sizes="%s"`,
+		html.EscapeString(ImageURL(imageVariantName(name, info.widths[0].suffix))),
+		html.EscapeString(strings.Join(candidates, ", ")),
+		html.EscapeString(sizes),
+	))
+}
+
+// GenerateImageVariants decodes a stored image, writes its variants and
+// records them.
+func GenerateImageVariants(name string) error {
+	name = filepath.Base(name)
+	store := GetBlobStore()
+	data, err := ReadBlob(store, name)
+	if err != nil {
+		return err
+	}
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+	return writeImageVariants(store, name, img)
+}
+
+func writeImageVariants(store BlobStore, name string, img image.Image) error {
+	bounds := img.Bounds()
+	source := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Src)
+
+	side := min(source.Bounds().Dx(), source.Bounds().Dy())
+	cropOrigin := image.Pt((source.Bounds().Dx()-side)/2, (source.Bounds().Dy()-side)/2)
+	square := source.SubImage(image.Rectangle{Min: cropOrigin, Max: cropOrigin.Add(image.Pt(side, side))}).(*image.RGBA)
+	thumbnailSide := min(side, avatarVariant.width)
+	err := writeImageVariant(store, imageVariantName(name, avatarVariant.suffix), resizeImage(square, thumbnailSide, thumbnailSide))
+	if err != nil {
+		return err
+	}
+
+	widths := []imageVariant{}
+	for _, variant := range widthVariants {
+		if source.Bounds().Dx() <= variant.width {
+			continue
+		}
+		height := max(1, source.Bounds().Dy()*variant.width/source.Bounds().Dx())
+		err = writeImageVariant(store, imageVariantName(name, variant.suffix), resizeImage(source, variant.width, height))
+		if err != nil {
+			return err
+		}
+		widths = append(widths, variant)
+	}
+	return recordImageVariants(name, source.Bounds().Dx(), source.Bounds().Dy(), widths)
+}
+
+func writeImageVariant(store BlobStore, name string, img image.Image) error {
+	var buffer bytes.Buffer
+	var err error
+	if strings.EqualFold(filepath.Ext(name), ".jpg") || strings.EqualFold(filepath.Ext(name), ".jpeg") {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: variantJPEGQuality})
+	} else {
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return err
+	}
+	return store.Put(name, buffer.Bytes(), imageContentType(name))
+}
+
+// resizeImage scales an image down by averaging the source pixels that fall
+// into each destination pixel.
+func resizeImage(source *image.RGBA, width, height int) *image.RGBA {
+	bounds := source.Bounds()
+	sourceWidth, sourceHeight := bounds.Dx(), bounds.Dy()
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sourceHeight / height
+		y1 := max((y+1)*sourceHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * sourceWidth / width
+			x1 := max((x+1)*sourceWidth/width, x0+1)
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := source.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(source.Pix[offset])
+					g += uint64(source.Pix[offset+1])
+					b += uint64(source.Pix[offset+2])
+					a += uint64(source.Pix[offset+3])
+					offset += 4
+					count++
+				}
+			}
+			offset := resized.PixOffset(x, y)
+			resized.Pix[offset] = uint8(r / count)
+			resized.Pix[offset+1] = uint8(g / count)
+			resized.Pix[offset+2] = uint8(b / count)
+			resized.Pix[offset+3] = uint8(a / count)
+		}
+	}
+	return resized
+}
+
+func removeImageVariants(name string) {
+	imageVariantIndex.Delete(name)
+	err := repositories.DeleteImageVariants(name)
+	if err != nil {
+		logger.Warn(
+			"removeImageVariants - failed to delete the variants record",
+			"error", err.Error(),
+			"Image", name,
+		)
+	}
+	store := GetBlobStore()
+	store.Delete(imageVariantName(name, avatarVariant.suffix))
+	for _, variant := range widthVariants {
+		store.Delete(imageVariantName(name, variant.suffix))
+	}
+}
+
+// BackfillImageVariants loads the index and creates the variants of images
+// that have none recorded, e.g. ones stored before variants existed or copied
+// in by cmd/MigrateMedia. It runs once in the background at startup.
+func BackfillImageVariants() {
+	err := LoadImageVariants()
+	if err != nil {
+		logger.Error(
+			"BackfillImageVariants - failed to load image variants",
+			"error", err.Error(),
+		)
+		return
+	}
+	keys, err := GetBlobStore().List("")
+	if err != nil {
+		logger.Error(
+			"BackfillImageVariants - failed to list images",
+			"error", err.Error(),
+		)
+		return
+	}
+	generated := 0
+	for _, name := range keys {
+		if !IsImageKey(name) || isImageVariantName(name) {
+			continue
+		}
+		if _, recorded := imageVariantIndex.Load(name); recorded {
+			continue
+		}
+		err = GenerateImageVariants(name)
+		if err != nil {
+			logger.Warn(
+				"BackfillImageVariants - skipping image",
+				"error", err.Error(),
+				"Image", name,
+			)
+			continue
+		}
+		generated++
+	}
+	logger.Info(
+		"BackfillImageVariants - done",
+		"Generated", generated,
+	)
+}
@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	DefaultImage       = "default.png"
+	MaxImageUploadSize = 5 << 20
+
+	maxImagePixels = 40_000_000
+	jpegQuality    = 90
+)
+
+var (
+	ErrInvalidImage = errors.New("invalid image")
+
+	errImageTooLarge    = fmt.Errorf("%w: images must be at most 5 MB", ErrInvalidImage)
+	errImageUnsupported = fmt.Errorf("%w: only JPEG, PNG and GIF images are supported", ErrInvalidImage)
+	errImageDimensions  = fmt.Errorf("%w: the image dimensions are too large", ErrInvalidImage)
+
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// SaveUploadedImage runs an uploaded picture through the image pipeline and
+// returns the name it is stored under. The client supplied file name is never
+// used.
+func SaveUploadedImage(fileHeader *multipart.FileHeader) (string, error) {
+	if fileHeader.Size > MaxImageUploadSize {
+		return "", errImageTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return StoreImage(file)
+}
+
+// StoreImage validates the image by its magic bytes, re-encodes it, which
+// drops EXIF and any other metadata, and stores it under the SHA-256 of the
+// result. Identical images share one blob. The resized variants are written
+// alongside.
+func StoreImage(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageUploadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxImageUploadSize {
+		return "", errImageTooLarge
+	}
+	img, extension, err := decodeImage(data)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := encodeImage(img, extension)
+	if err != nil {
+		return "", err
+	}
+	store := GetBlobStore()
+	name, err := putContentAddressedImage(store, "", encoded, extension)
+	if err != nil {
+		return "", err
+	}
+	hasVariants, err := repositories.HasImageVariants(name)
+	if err == nil && !hasVariants {
+		err = writeImageVariants(store, name, img)
+		if err != nil {
+			logger.Warn(
+				"StoreImage - failed to write image variants",
+				"error", err.Error(),
+				"Image", name,
+			)
+		}
+	}
+	return name, nil
+}
+
+// ProcessImage decodes a JPEG, PNG or GIF image and re-encodes it. JPEGs stay
+// JPEGs with their EXIF orientation applied; PNGs and the first frame of a
+// GIF become PNGs.
+func ProcessImage(data []byte) ([]byte, string, error) {
+	img, extension, err := decodeImage(data)
+	if err != nil {
+		return nil, "", err
+	}
+	encoded, err := encodeImage(img, extension)
+	if err != nil {
+		return nil, "", err
+	}
+	return encoded, extension, nil
+}
+
+func decodeImage(data []byte) (image.Image, string, error) {
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	extension := ".png"
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		decodeConfig, decode, extension = jpeg.DecodeConfig, jpeg.Decode, ".jpg"
+	case bytes.HasPrefix(data, pngSignature):
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	default:
+		return nil, "", errImageUnsupported
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errImageUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, "", errImageDimensions
+	}
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errImageUnsupported
+	}
+	if extension == ".jpg" {
+		img = orientImage(img, jpegOrientation(data))
+	}
+	return img, extension, nil
+}
+
+func encodeImage(img image.Image, extension string) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+	if extension == ".jpg" {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func contentAddressedImageName(encoded []byte, extension string) string {
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]) + extension
+}
+
+// putContentAddressedImage stores an encoded image under prefix followed by
+// its content addressed name, which it returns without the prefix.
+func putContentAddressedImage(store BlobStore, prefix string, encoded []byte, extension string) (string, error) {
+	name := contentAddressedImageName(encoded, extension)
+	exists, err := store.Exists(prefix + name)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		err = store.Put(prefix+name, encoded, imageContentType(name))
+		if err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+func imageContentType(name string) string {
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// RemoveUnusedImages deletes stored images that are no longer referenced by
+// any profile, candidate, organization, vote history or vote template. Since
+// names are content addressed, a blob may be shared by several records.
+func RemoveUnusedImages(names ...string) {
+	for _, name := range names {
+		name = filepath.Base(name)
+		if name == "" || name == "." || name == DefaultImage {
+			continue
+		}
+		referenced, err := repositories.IsImageReferenced(name)
+		if err != nil || referenced {
+			continue
+		}
+		removeImageVariants(name)
+		err = GetBlobStore().Delete(name)
+		if err != nil {
+			logger.Warn(
+				"RemoveUnusedImages - failed to remove image",
+				"error", err.Error(),
+				"Image", name,
+			)
+		}
+	}
+}
+
+// ImageUploadStatus is the HTTP status for an error from the image pipeline.
+func ImageUploadStatus(err error) int {
+	if errors.Is(err, ErrInvalidImage) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// jpegOrientation reads the EXIF orientation tag of a JPEG, defaulting to 1
+// (upright) when there is none.
+func jpegOrientation(data []byte) int {
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		offset = end
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orientImage turns an image the way its EXIF orientation says it should be
+// displayed, since the re-encoded file no longer carries the tag.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	source := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Src)
+	width, height := bounds.Dx(), bounds.Dy()
+
+	outWidth, outHeight := width, height
+	if orientation >= 5 {
+		outWidth, outHeight = height, width
+	}
+	oriented := image.NewNRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			}
+			oriented.SetNRGBA(dx, dy, source.NRGBAAt(x, y))
+		}
+	}
+	return oriented
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	voteResultsChannelPrefix = "vote_results:"
+	liveResultsBufferSize    = 4
+)
+
+// LiveResultCandidate keeps the field names of models.Candidate so the result
+// page can read the initial render and streamed updates the same way.
+type LiveResultCandidate struct {
+	CandidateID   uint
+	CandidateName string
+	TotalVotes    uint
+}
+
+type LiveResults struct {
+	VoteID     uint
+	TotalVotes uint
+	Candidates []LiveResultCandidate
+}
+
+// liveResultsHub fans updates received from Redis out to the result streams
+// open on this instance.
+type liveResultsHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan []byte]struct{}
+}
+
+var (
+	resultsHub          = &liveResultsHub{subscribers: map[uint]map[chan []byte]struct{}{}}
+	resultsListenerOnce sync.Once
+)
+
+func GetLiveResults(voteID uint) (LiveResults, error) {
+	candidates, err := repositories.GetCandidatesByVoteID(voteID)
+	if err != nil {
+		return LiveResults{}, err
+	}
+	results := LiveResults{
+		VoteID:     voteID,
+		Candidates: make([]LiveResultCandidate, 0, len(candidates)),
+	}
+	for _, candidate := range candidates {
+		results.TotalVotes += candidate.TotalVotes
+		results.Candidates = append(results.Candidates, LiveResultCandidate{
+			CandidateID:   candidate.CandidateID,
+			CandidateName: candidate.CandidateName,
+			TotalVotes:    candidate.TotalVotes,
+		})
+	}
+	return results, nil
+}
+
+// PublishVoteResults sends the current tally of the vote to every instance
+// through Redis. When Redis is unreachable the update still reaches streams
+// on this instance.
+func PublishVoteResults(voteID uint) {
+	results, err := GetLiveResults(voteID)
+	if err != nil {
+		logger.Error(
+			"PublishVoteResults - failed to get results",
+			"error", err,
+			"Vote ID", voteID,
+		)
+		return
+	}
+	payload, err := json.Marshal(results)
+	if err != nil {
+		logger.Error(
+			"PublishVoteResults - failed to marshal results",
+			"error", err,
+			"Vote ID", voteID,
+		)
+		return
+	}
+	channel := voteResultsChannelPrefix + strconv.FormatUint(uint64(voteID), 10)
+	err = config.GetRedisClient().Publish(context.Background(), channel, payload).Err()
+	if err != nil {
+		logger.Warn(
+			"PublishVoteResults - failed to publish results, delivering locally",
+			"error", err,
+			"Vote ID", voteID,
+		)
+		resultsHub.broadcast(voteID, payload)
+	}
+}
+
+// SubscribeVoteResults returns a channel of JSON encoded LiveResults for the
+// vote and a function that must be called once the stream is closed.
+func SubscribeVoteResults(voteID uint) (<-chan []byte, func()) {
+	resultsListenerOnce.Do(func() {
+		go listenVoteResults()
+	})
+	updates := make(chan []byte, liveResultsBufferSize)
+	resultsHub.mu.Lock()
+	if resultsHub.subscribers[voteID] == nil {
+		resultsHub.subscribers[voteID] = map[chan []byte]struct{}{}
+	}
+	resultsHub.subscribers[voteID][updates] = struct{}{}
+	resultsHub.mu.Unlock()
+
+	return updates, func() {
+		resultsHub.mu.Lock()
+		delete(resultsHub.subscribers[voteID], updates)
+		if len(resultsHub.subscribers[voteID]) == 0 {
+			delete(resultsHub.subscribers, voteID)
+		}
+		resultsHub.mu.Unlock()
+	}
+}
+
+// listenVoteResults holds one pattern subscription per instance; go-redis
+// reconnects it on its own if the connection drops.
+func listenVoteResults() {
+	pubsub := config.GetRedisClient().PSubscribe(context.Background(), voteResultsChannelPrefix+"*")
+	for message := range pubsub.Channel() {
+		voteID, err := strconv.ParseUint(strings.TrimPrefix(message.Channel, voteResultsChannelPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		resultsHub.broadcast(uint(voteID), []byte(message.Payload))
+	}
+}
+
+// broadcast never blocks on a slow stream: every payload is a full snapshot,
+// so an unread older one is dropped to make room for the newest.
+func (hub *liveResultsHub) broadcast(voteID uint, payload []byte) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for updates := range hub.subscribers[voteID] {
+		select {
+		case updates <- payload:
+			continue
+		default:
+		}
+		select {
+		case <-updates:
+		default:
+		}
+		select {
+		case updates <- payload:
+		default:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"gopkg.in/gomail.v2"
+)
+
+type EmailMessage struct {
+	To       string
+	Subject  string
+	Body     string
+	TextBody string
+}
+
+// Mailer delivers a single HTML email. The implementation is chosen by
+// MAIL_TRANSPORT, never by the recipient's domain.
+type Mailer interface {
+	Send(message EmailMessage) error
+}
+
+type SMTPMailer struct {
+	From        string
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	ImplicitTLS bool
+}
+
+// FileMailer writes every message as an .eml file, for local development.
+type FileMailer struct {
+	From string
+	Dir  string
+}
+
+// MemoryMailer keeps sent messages in memory, for tests. It is only used when
+// MAIL_TRANSPORT=memory is set explicitly, never as a fallback.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+// failingMailer stands in for a misconfigured transport, so that every send
+// reports the configuration error instead of the message being dropped.
+type failingMailer struct {
+	err error
+}
+
+var (
+	mailerOnce sync.Once
+	mailer     Mailer
+)
+
+func newGomailMessage(from string, message EmailMessage) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	if message.TextBody != "" {
+		m.SetBody("text/plain", message.TextBody)
+		m.AddAlternative("text/html", message.Body)
+		return m
+	}
+	m.SetBody("text/html", message.Body)
+	return m
+}
+
+func (s *SMTPMailer) Send(message EmailMessage) error {
+	if s.Host == "" {
+		return fmt.Errorf("smtp host is not configured")
+	}
+	// A dialer caches its auth mechanism, so each send gets its own.
+	d := gomail.NewDialer(s.Host, s.Port, s.Username, s.Password)
+	d.SSL = s.ImplicitTLS
+	return d.DialAndSend(newGomailMessage(s.From, message))
+}
+
+func (f *FileMailer) Send(message EmailMessage) error {
+	err := os.MkdirAll(f.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(message.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), recipient)
+	file, err := os.Create(filepath.Join(f.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = newGomailMessage(f.From, message).WriteTo(file)
+	return err
+}
+
+func (f *failingMailer) Send(message EmailMessage) error {
+	return fmt.Errorf("mailer is not configured: %w", f.err)
+}
+
+func (m *MemoryMailer) Send(message EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]EmailMessage(nil), m.messages...)
+}
+
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+func NewMailer(mailConfig config.MailConfig) (Mailer, error) {
+	switch mailConfig.Transport {
+	case "smtp":
+		if mailConfig.Host == "" {
+			return nil, fmt.Errorf("smtp host is not configured")
+		}
+		return &SMTPMailer{
+			From:        mailConfig.From,
+			Host:        mailConfig.Host,
+			Port:        mailConfig.Port,
+			Username:    mailConfig.Username,
+			Password:    mailConfig.Password,
+			ImplicitTLS: mailConfig.ImplicitTLS,
+		}, nil
+	case "file":
+		return &FileMailer{From: mailConfig.From, Dir: mailConfig.Dir}, nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	}
+	return nil, fmt.Errorf("unsupported mail transport: %s", mailConfig.Transport)
+}
+
+func GetMailer() Mailer {
+	mailerOnce.Do(func() {
+		var err error
+		mailer, err = NewMailer(config.GetMailConfig())
+		if err != nil {
+			logger.Error(
+				"GetMailer - invalid mail configuration, every email will fail to send",
+				"error", err,
+			)
+			mailer = &failingMailer{err: err}
+		}
+	})
+	return mailer
+}
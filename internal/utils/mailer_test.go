@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+)
+
+func TestNewMailer(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.MailConfig
+		want    Mailer
+		wantErr bool
+	}{
+		{"smtp", config.MailConfig{Transport: "smtp", Host: "smtp.example.com", Port: 587}, &SMTPMailer{}, false},
+		{"smtp without host", config.MailConfig{Transport: "smtp"}, nil, true},
+		{"file", config.MailConfig{Transport: "file", Dir: "mail"}, &FileMailer{}, false},
+		{"memory", config.MailConfig{Transport: "memory"}, &MemoryMailer{}, false},
+		{"unknown transport", config.MailConfig{Transport: "carrier-pigeon"}, nil, true},
+	}
+	for _, test := range tests {
+		mailer, err := NewMailer(test.config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: NewMailer error = %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if test.want != nil && fmt.Sprintf("%T", mailer) != fmt.Sprintf("%T", test.want) {
+			t.Errorf("%s: NewMailer = %T, want %T", test.name, mailer, test.want)
+		}
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer, err := NewMailer(config.MailConfig{Transport: "memory"})
+	if err != nil {
+		t.Fatalf("NewMailer returned error: %v", err)
+	}
+	memoryMailer := mailer.(*MemoryMailer)
+	messages := []EmailMessage{
+		{To: "first@example.com", Subject: "OTP", Body: "<p>123456</p>", TextBody: "123456"},
+		{To: "second@example.com", Subject: "Reset", Body: "<p>reset</p>"},
+	}
+	for _, message := range messages {
+		if err := memoryMailer.Send(message); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+	sent := memoryMailer.Messages()
+	if len(sent) != len(messages) || sent[0] != messages[0] || sent[1] != messages[1] {
+		t.Errorf("Messages = %+v, want %+v", sent, messages)
+	}
+	sent[0].To = "changed@example.com"
+	if memoryMailer.Messages()[0].To != "first@example.com" {
+		t.Error("Messages returned the mailer's own slice")
+	}
+	memoryMailer.Reset()
+	if len(memoryMailer.Messages()) != 0 {
+		t.Error("Reset kept the sent messages")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := &FileMailer{From: "noreply@example.com", Dir: dir}
+	err := mailer.Send(EmailMessage{To: "voter@example.com", Subject: "Your code", Body: "<p>123456</p>", TextBody: "123456"})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ReadDir = %v, %v; want one message", files, err)
+	}
+	if !strings.HasSuffix(files[0].Name(), "-voter_at_example.com.eml") {
+		t.Errorf("message file name = %s", files[0].Name())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	for _, want := range []string{"From: noreply@example.com", "To: voter@example.com", "Subject: Your code", "123456"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestFailingMailer(t *testing.T) {
+	_, configErr := NewMailer(config.MailConfig{Transport: "smtp"})
+	mailer := &failingMailer{err: configErr}
+	err := mailer.Send(EmailMessage{To: "voter@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "smtp host is not configured") {
+		t.Errorf("Send error = %v, want the configuration error", err)
+	}
+}
@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const oidcDiscoveryTTL = time.Hour
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+type OIDCDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type OIDCClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type oidcJWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type oidcCacheEntry struct {
+	discovery OIDCDiscovery
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time
+}
+
+var (
+	oidcCacheMu sync.Mutex
+	oidcCache   = map[string]*oidcCacheEntry{}
+)
+
+func GenerateOIDCState() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func oidcCodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func getOIDCJSON(endpoint string, target interface{}) error {
+	resp, err := oidcHTTPClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// getOIDCProviderCache fetches the discovery document and JWKS for a provider,
+// reusing them for an hour. refresh forces a reload, e.g. after key rotation.
+func getOIDCProviderCache(provider config.OIDCProvider, refresh bool) (*oidcCacheEntry, error) {
+	oidcCacheMu.Lock()
+	defer oidcCacheMu.Unlock()
+
+	entry, ok := oidcCache[provider.Issuer]
+	if ok && !refresh && time.Since(entry.fetched) < oidcDiscoveryTTL {
+		return entry, nil
+	}
+
+	discovery := OIDCDiscovery{}
+	err := getOIDCJSON(provider.Issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != provider.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", discovery.Issuer)
+	}
+
+	jwks := struct {
+		Keys []oidcJWK `json:"keys"`
+	}{}
+	err = getOIDCJSON(discovery.JWKSURI, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		publicKey, err := parseOIDCRSAKey(key)
+		if err != nil {
+			logger.Warn(
+				"getOIDCProviderCache - skipping invalid signing key",
+				"Provider", provider.Name,
+				"Kid", key.Kid,
+				"error", err.Error(),
+			)
+			continue
+		}
+		keys[key.Kid] = publicKey
+	}
+
+	entry = &oidcCacheEntry{
+		discovery: discovery,
+		keys:      keys,
+		fetched:   time.Now(),
+	}
+	oidcCache[provider.Issuer] = entry
+	return entry, nil
+}
+
+func parseOIDCRSAKey(key oidcJWK) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+// OIDCAuthURL builds the authorization request using PKCE (S256) with the given verifier.
+func OIDCAuthURL(provider config.OIDCProvider, state, nonce, verifier string) (string, error) {
+	entry, err := getOIDCProviderCache(provider, false)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", provider.ClientID)
+	params.Set("redirect_uri", provider.RedirectURL)
+	params.Set("scope", strings.Join(provider.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", oidcCodeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(entry.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return entry.discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// ExchangeOIDCCode trades the authorization code for tokens and returns the
+// verified claims of the ID token.
+func ExchangeOIDCCode(provider config.OIDCProvider, code, verifier, nonce string) (OIDCClaims, error) {
+	entry, err := getOIDCProviderCache(provider, false)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequest(http.MethodPost, entry.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	defer resp.Body.Close()
+
+	tokenResponse := struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	if resp.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		return OIDCClaims{}, fmt.Errorf("token endpoint error: %s %s", tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	if tokenResponse.IDToken == "" {
+		return OIDCClaims{}, errors.New("token response has no id_token")
+	}
+	return verifyOIDCIDToken(provider, tokenResponse.IDToken, nonce)
+}
+
+func verifyOIDCIDToken(provider config.OIDCProvider, rawIDToken, nonce string) (OIDCClaims, error) {
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg()}}
+	token, err := parser.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		entry, err := getOIDCProviderCache(provider, false)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := entry.keys[kid]; ok {
+			return key, nil
+		}
+		entry, err = getOIDCProviderCache(provider, true)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := entry.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
+	if err != nil || !token.Valid {
+		return OIDCClaims{}, fmt.Errorf("invalid id token: %v", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return OIDCClaims{}, errors.New("invalid id token claims")
+	}
+	issuer, _ := claims["iss"].(string)
+	if strings.TrimRight(issuer, "/") != provider.Issuer {
+		return OIDCClaims{}, errors.New("id token issuer mismatch")
+	}
+	if !oidcAudienceContains(claims["aud"], provider.ClientID) {
+		return OIDCClaims{}, errors.New("id token audience mismatch")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return OIDCClaims{}, errors.New("id token has no expiry")
+	}
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || tokenNonce != nonce {
+		return OIDCClaims{}, errors.New("id token nonce mismatch")
+	}
+
+	result := OIDCClaims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	if result.Subject == "" {
+		return OIDCClaims{}, errors.New("id token has no subject")
+	}
+	return result, nil
+}
+
+func oidcAudienceContains(aud interface{}, clientID string) bool {
+	switch audience := aud.(type) {
+	case string:
+		return audience == clientID
+	case []interface{}:
+		for _, value := range audience {
+			if value == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
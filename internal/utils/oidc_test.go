@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// newTestOIDCProvider serves a discovery document and a JWKS holding the
+// public half of key under the kid "signing-key".
+func newTestOIDCProvider(t *testing.T, key *rsa.PrivateKey) config.OIDCProvider {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(OIDCDiscovery{
+				Issuer:  server.URL,
+				JWKSURI: server.URL + "/jwks",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string][]oidcJWK{
+				"keys": {{
+					Kid: "signing-key",
+					Kty: "RSA",
+					N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return config.OIDCProvider{Name: "test", Issuer: server.URL, ClientID: "electivote"}
+}
+
+func signTestIDToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing the id token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyOIDCIDToken(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating the signing key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating the other key: %v", err)
+	}
+	provider := newTestOIDCProvider(t, signingKey)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            provider.Issuer,
+			"aud":            provider.ClientID,
+			"sub":            "user-1",
+			"email":          "voter@example.com",
+			"email_verified": true,
+			"nonce":          "nonce-1",
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	withClaim := func(name string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr string
+	}{
+		{
+			name:  "valid token",
+			token: signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", validClaims()),
+			nonce: "nonce-1",
+		},
+		{
+			name:  "audience list",
+			token: signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("aud", []string{"other", provider.ClientID})),
+			nonce: "nonce-1",
+		},
+		{
+			name:    "bad signature",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, otherKey, "signing-key", validClaims()),
+			nonce:   "nonce-1",
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "unknown kid",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "rotated-key", validClaims()),
+			nonce:   "nonce-1",
+			wantErr: "unknown signing key",
+		},
+		{
+			name:    "symmetric algorithm",
+			token:   signTestIDToken(t, jwt.SigningMethodHS256, []byte("client-secret"), "signing-key", validClaims()),
+			nonce:   "nonce-1",
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "wrong audience",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("aud", "someone-else")),
+			nonce:   "nonce-1",
+			wantErr: "audience mismatch",
+		},
+		{
+			name:    "wrong issuer",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("iss", "https://attacker.example.com")),
+			nonce:   "nonce-1",
+			wantErr: "issuer mismatch",
+		},
+		{
+			name:    "expired",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("exp", time.Now().Add(-time.Minute).Unix())),
+			nonce:   "nonce-1",
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "no expiry",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("exp", nil)),
+			nonce:   "nonce-1",
+			wantErr: "no expiry",
+		},
+		{
+			name:    "wrong nonce",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", validClaims()),
+			nonce:   "nonce-2",
+			wantErr: "nonce mismatch",
+		},
+		{
+			name:    "no subject",
+			token:   signTestIDToken(t, jwt.SigningMethodRS256, signingKey, "signing-key", withClaim("sub", nil)),
+			nonce:   "nonce-1",
+			wantErr: "no subject",
+		},
+	}
+	for _, test := range tests {
+		claims, err := verifyOIDCIDToken(provider, test.token, test.nonce)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: verifyOIDCIDToken returned error: %v", test.name, err)
+				continue
+			}
+			if claims.Subject != "user-1" || claims.Email != "voter@example.com" || !claims.EmailVerified {
+				t.Errorf("%s: verifyOIDCIDToken = %+v", test.name, claims)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: verifyOIDCIDToken error = %v, want one containing %q", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 56.0
+	pdfLineHeight = 1.4
+)
+
+// PDFWriter lays out plain text on A4 pages using the built-in Helvetica
+// fonts, which every PDF reader ships, so no font files or third-party
+// libraries are needed. It is meant for simple printable documents such as
+// result certificates.
+type PDFWriter struct {
+	Footer string
+	pages  []*bytes.Buffer
+	y      float64
+}
+
+func NewPDFWriter() *PDFWriter {
+	writer := &PDFWriter{}
+	writer.newPage()
+	return writer
+}
+
+func (p *PDFWriter) newPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pdfPageHeight - pdfMargin
+}
+
+func (p *PDFWriter) nextLine(size float64) {
+	height := size * pdfLineHeight
+	if p.y-height < pdfMargin {
+		p.newPage()
+	}
+	p.y -= height
+}
+
+// Text writes a left-aligned paragraph, wrapping it to the page width.
+func (p *PDFWriter) Text(text string, size float64, bold bool) {
+	for _, line := range wrapPDFText(text, size, pdfPageWidth-2*pdfMargin) {
+		p.nextLine(size)
+		p.writeText(pdfMargin, p.y, line, size, bold)
+	}
+}
+
+func (p *PDFWriter) CenteredText(text string, size float64, bold bool) {
+	for _, line := range wrapPDFText(text, size, pdfPageWidth-2*pdfMargin) {
+		p.nextLine(size)
+		p.writeText((pdfPageWidth-pdfTextWidth(line, size))/2, p.y, line, size, bold)
+	}
+}
+
+// Row writes one line of columns starting at the given offsets from the left
+// margin; each cell is cut to fit before the next column.
+func (p *PDFWriter) Row(cells []string, offsets []float64, size float64, bold bool) {
+	p.nextLine(size)
+	for i, cell := range cells {
+		width := pdfPageWidth - 2*pdfMargin - offsets[i]
+		if i+1 < len(offsets) {
+			width = offsets[i+1] - offsets[i] - 6
+		}
+		p.writeText(pdfMargin+offsets[i], p.y, truncatePDFText(cell, size, width), size, bold)
+	}
+}
+
+func (p *PDFWriter) Space(height float64) {
+	if p.y-height < pdfMargin {
+		p.newPage()
+		return
+	}
+	p.y -= height
+}
+
+func (p *PDFWriter) Rule() {
+	p.Space(6)
+	fmt.Fprintf(p.pages[len(p.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y, pdfPageWidth-pdfMargin, p.y)
+	p.Space(4)
+}
+
+func (p *PDFWriter) writeText(x, y float64, text string, size float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePDFText(text))
+}
+
+// Bytes serializes the document, adding the footer and page numbers.
+func (p *PDFWriter) Bytes() []byte {
+	for i, page := range p.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(p.pages))
+		if p.Footer != "" {
+			footer = p.Footer + "  -  " + footer
+		}
+		fmt.Fprintf(page, "BT /F1 8.0 Tf %.2f %.2f Td (%s) Tj ET\n", pdfMargin, pdfMargin/2, escapePDFText(footer))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := []string{}
+	for _, page := range p.pages {
+		pageObject := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageObject+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// escapePDFText escapes a string literal for WinAnsiEncoding; characters
+// outside Latin-1 are replaced with "?".
+func escapePDFText(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r < 32:
+			out.WriteByte(' ')
+		case r < 128:
+			out.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
+
+// pdfTextWidth approximates Helvetica glyph widths, which is close enough
+// for centering and wrapping.
+func pdfTextWidth(text string, size float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("iljtfI.,:;'|!", r):
+			width += 0.28
+		case r == ' ':
+			width += 0.28
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 0.85
+		case r >= 'A' && r <= 'Z':
+			width += 0.68
+		default:
+			width += 0.56
+		}
+	}
+	return width * size
+}
+
+func wrapPDFText(text string, size, maxWidth float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && pdfTextWidth(candidate, size) > maxWidth {
+				lines = append(lines, line)
+				candidate = word
+			}
+			for pdfTextWidth(candidate, size) > maxWidth {
+				cut := truncatePDFText(candidate, size, maxWidth)
+				lines = append(lines, cut)
+				candidate = candidate[len(cut):]
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func truncatePDFText(text string, size, maxWidth float64) string {
+	if pdfTextWidth(text, size) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 1 && pdfTextWidth(string(runes), size) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
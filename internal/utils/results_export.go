@@ -0,0 +1,389 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	ResultsStatusLive   = "live"
+	ResultsStatusClosed = "closed"
+
+	resultsTimeFormat = "02 Jan 2006 15:04 MST"
+)
+
+var resultsFilenameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+type ResultsExportCandidate struct {
+	ID         uint    `json:"id,omitempty"`
+	Name       string  `json:"name"`
+	TotalVotes uint    `json:"totalVotes"`
+	Percentage float64 `json:"percentage"`
+}
+
+type ResultsExportBallot struct {
+	Voter     string    `json:"voter"`
+	Candidate string    `json:"candidate"`
+	VotedTime time.Time `json:"votedAt"`
+}
+
+// ResultsExport is the document behind the CSV, JSON and PDF result exports.
+// A closed vote keeps the one taken when it was archived in
+// VoteHistory.ResultsSnapshot, so its exports and signature never change.
+type ResultsExport struct {
+	VoteID         uint                     `json:"voteId,omitempty"`
+	VoteHistoryID  uint                     `json:"voteHistoryId,omitempty"`
+	Title          string                   `json:"title"`
+	Description    string                   `json:"description"`
+	Moderator      string                   `json:"moderator"`
+	Status         string                   `json:"status"`
+	PublicBallot   bool                     `json:"publicBallot"`
+	Start          time.Time                `json:"start"`
+	End            *time.Time               `json:"end"`
+	GeneratedAt    time.Time                `json:"generatedAt"`
+	TotalVotes     uint                     `json:"totalVotes"`
+	EligibleVoters *int64                   `json:"eligibleVoters"`
+	Turnout        *float64                 `json:"turnout"`
+	Winners        []string                 `json:"winners"`
+	Candidates     []ResultsExportCandidate `json:"candidates"`
+	Ballots        []ResultsExportBallot    `json:"ballots,omitempty"`
+	Note           string                   `json:"note,omitempty"`
+	Signature      string                   `json:"signature"`
+}
+
+// BuildVoteResultsExport collects the current results of a running vote. The
+// ballot list is only included when the vote was created with a public ballot.
+func BuildVoteResultsExport(vote models.Vote) (ResultsExport, error) {
+	moderatorName, err := repositories.GetModeratorNameByModeratorID(vote.ModeratorID)
+	if err != nil {
+		return ResultsExport{}, err
+	}
+	candidates, err := repositories.GetCandidatesByVoteID(vote.VoteID)
+	if err != nil {
+		return ResultsExport{}, err
+	}
+
+	export := ResultsExport{
+		VoteID:       vote.VoteID,
+		Title:        vote.VoteTitle,
+		Description:  vote.VoteDescription,
+		Moderator:    moderatorName,
+		Status:       ResultsStatusLive,
+		PublicBallot: vote.PublicBallot,
+		Start:        vote.Start.Time,
+		GeneratedAt:  time.Now().Truncate(time.Second),
+		Winners:      []string{},
+		Candidates:   make([]ResultsExportCandidate, 0, len(candidates)),
+	}
+	for _, candidate := range candidates {
+		export.TotalVotes += candidate.TotalVotes
+		export.Candidates = append(export.Candidates, ResultsExportCandidate{
+			ID:         candidate.CandidateID,
+			Name:       candidate.CandidateName,
+			TotalVotes: candidate.TotalVotes,
+		})
+	}
+	completeResultsExport(&export)
+
+	if vote.VoterRollID != nil {
+		eligibleVoters, err := repositories.CountVoterRollEntries(*vote.VoterRollID)
+		if err != nil {
+			return ResultsExport{}, err
+		}
+		export.EligibleVoters = &eligibleVoters
+		if eligibleVoters > 0 {
+			turnout := roundPercentage(float64(export.TotalVotes) / float64(eligibleVoters))
+			export.Turnout = &turnout
+		}
+	}
+
+	if vote.PublicBallot {
+		voteRecords, err := repositories.GetVoteRecordsByVoteID(vote.VoteID)
+		if err != nil {
+			return ResultsExport{}, err
+		}
+		for _, voteRecord := range voteRecords {
+			export.Ballots = append(export.Ballots, ResultsExportBallot{
+				Voter:     voteRecord.User.Username,
+				Candidate: voteRecord.Candidate.CandidateName,
+				VotedTime: voteRecord.VotedTime.Time,
+			})
+		}
+	}
+	return export, nil
+}
+
+// CloseResultsExport turns a live export into the final snapshot stored with
+// the vote history.
+func CloseResultsExport(export ResultsExport, end time.Time) (string, error) {
+	end = end.Truncate(time.Second)
+	export.Status = ResultsStatusClosed
+	export.End = &end
+	export.GeneratedAt = end
+	export.Signature = ""
+	snapshot, err := json.Marshal(export)
+	if err != nil {
+		return "", err
+	}
+	return string(snapshot), nil
+}
+
+// GetVoteHistoryResultsExport loads the snapshot of an archived vote. Votes
+// archived before snapshots existed only know their winner.
+func GetVoteHistoryResultsExport(voteHistory models.VoteHistory) (ResultsExport, error) {
+	export := ResultsExport{}
+	if voteHistory.ResultsSnapshot != "" {
+		err := json.Unmarshal([]byte(voteHistory.ResultsSnapshot), &export)
+		if err != nil {
+			return export, err
+		}
+	} else {
+		end := voteHistory.End.Time
+		export = ResultsExport{
+			Title:       voteHistory.VoteTitle,
+			Description: voteHistory.VoteDescription,
+			Moderator:   voteHistory.ModeratorName,
+			Status:      ResultsStatusClosed,
+			Start:       voteHistory.Start.Time,
+			End:         &end,
+			GeneratedAt: end,
+			Winners:     []string{},
+			Candidates:  []ResultsExportCandidate{},
+			Note:        "This vote was archived before full results were recorded; only the winner is known.",
+		}
+		if voteHistory.CandidateWinnerName != "None" && voteHistory.CandidateWinnerName != "" {
+			export.Winners = []string{voteHistory.CandidateWinnerName}
+			export.Candidates = append(export.Candidates, ResultsExportCandidate{
+				Name:       voteHistory.CandidateWinnerName,
+				TotalVotes: voteHistory.TotalVotes,
+			})
+		}
+	}
+	export.VoteHistoryID = voteHistory.VoteHistoryID
+	SignResultsExport(&export)
+	return export, nil
+}
+
+func completeResultsExport(export *ResultsExport) {
+	var topVotes uint
+	for i, candidate := range export.Candidates {
+		if export.TotalVotes > 0 {
+			export.Candidates[i].Percentage = roundPercentage(float64(candidate.TotalVotes) / float64(export.TotalVotes))
+		}
+		if candidate.TotalVotes > topVotes {
+			topVotes = candidate.TotalVotes
+		}
+	}
+	for _, candidate := range export.Candidates {
+		if topVotes > 0 && candidate.TotalVotes == topVotes {
+			export.Winners = append(export.Winners, candidate.Name)
+		}
+	}
+}
+
+func roundPercentage(ratio float64) float64 {
+	return math.Round(ratio*10000) / 100
+}
+
+func resultsSigningKey() []byte {
+	return []byte("results:" + os.Getenv("SECRET_KEY"))
+}
+
+func resultsSignature(export ResultsExport) string {
+	export.Signature = ""
+	payload, _ := json.Marshal(export)
+	h := hmac.New(sha256.New, resultsSigningKey())
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SignResultsExport sets the HMAC-SHA256 signature over the export contents,
+// printed on the PDF certificate and included in the JSON export.
+func SignResultsExport(export *ResultsExport) {
+	export.Signature = resultsSignature(*export)
+}
+
+func IsValidResultsSignature(export ResultsExport, signature string) bool {
+	return hmac.Equal([]byte(resultsSignature(export)), []byte(signature))
+}
+
+// ResultsVerificationURL is printed on certificates of closed votes; the
+// page recomputes the signature from the stored snapshot.
+func ResultsVerificationURL(export ResultsExport) string {
+	if export.Status != ResultsStatusClosed || export.VoteHistoryID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/electivote/verify-results/%d/%s/", config.GetBaseURL(), export.VoteHistoryID, export.Signature)
+}
+
+func ResultsExportFilename(export ResultsExport, extension string) string {
+	name := strings.Trim(resultsFilenameCleaner.ReplaceAllString(strings.ToLower(export.Title), "-"), "-")
+	if name == "" {
+		name = "vote"
+	}
+	return fmt.Sprintf("results-%s-%s.%s", name, export.GeneratedAt.Format("20060102-1504"), extension)
+}
+
+// csvTextCell keeps spreadsheet programs from evaluating user supplied text as
+// a formula by prefixing cells that start like one with a quote.
+func csvTextCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func WriteResultsCSV(w io.Writer, export ResultsExport) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"Vote", csvTextCell(export.Title)},
+		{"Description", csvTextCell(export.Description)},
+		{"Moderator", csvTextCell(export.Moderator)},
+		{"Status", export.Status},
+		{"Start", export.Start.Format(time.RFC3339)},
+		{"End", formatResultsEnd(export.End, time.RFC3339)},
+		{"Generated At", export.GeneratedAt.Format(time.RFC3339)},
+		{"Total Votes", strconv.FormatUint(uint64(export.TotalVotes), 10)},
+		{"Eligible Voters", formatEligibleVoters(export.EligibleVoters)},
+		{"Turnout", formatTurnout(export.Turnout)},
+		{"Winner", csvTextCell(strings.Join(export.Winners, ", "))},
+		{"Signature", export.Signature},
+	}
+	if export.Note != "" {
+		rows = append(rows, []string{"Note", csvTextCell(export.Note)})
+	}
+	rows = append(rows, []string{}, []string{"Candidate", "Votes", "Percentage"})
+	for _, candidate := range export.Candidates {
+		rows = append(rows, []string{
+			csvTextCell(candidate.Name),
+			strconv.FormatUint(uint64(candidate.TotalVotes), 10),
+			strconv.FormatFloat(candidate.Percentage, 'f', 2, 64),
+		})
+	}
+	if export.PublicBallot {
+		rows = append(rows, []string{}, []string{"Voter", "Candidate", "Voted At"})
+		for _, ballot := range export.Ballots {
+			rows = append(rows, []string{csvTextCell(ballot.Voter), csvTextCell(ballot.Candidate), ballot.VotedTime.Format(time.RFC3339)})
+		}
+	}
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// RenderResultsPDF lays the export out as a printable certificate of results
+// for meeting minutes.
+func RenderResultsPDF(export ResultsExport) []byte {
+	pdf := NewPDFWriter()
+	pdf.Footer = "ElectiVote certificate of results - " + export.Title
+
+	pdf.CenteredText("Certificate of Results", 22, true)
+	pdf.Space(6)
+	pdf.CenteredText(export.Title, 15, true)
+	if export.Description != "" {
+		pdf.CenteredText(export.Description, 10, false)
+	}
+	pdf.Rule()
+
+	status := "Final - the vote closed on " + formatResultsEnd(export.End, resultsTimeFormat)
+	if export.Status == ResultsStatusLive {
+		status = "Provisional - the vote is still open; results as of " + export.GeneratedAt.Format(resultsTimeFormat)
+	}
+	details := [][]string{
+		{"Status", status},
+		{"Moderator", export.Moderator},
+		{"Voting opened", export.Start.Format(resultsTimeFormat)},
+		{"Voting closed", formatResultsEnd(export.End, resultsTimeFormat)},
+		{"Ballots cast", strconv.FormatUint(uint64(export.TotalVotes), 10)},
+		{"Eligible voters", formatEligibleVoters(export.EligibleVoters)},
+		{"Turnout", formatTurnout(export.Turnout)},
+		{"Winner", strings.Join(export.Winners, ", ")},
+	}
+	for _, detail := range details {
+		if detail[1] == "" {
+			detail[1] = "-"
+		}
+		pdf.Row(detail, []float64{0, 120}, 10, false)
+	}
+	if export.Note != "" {
+		pdf.Space(4)
+		pdf.Text(export.Note, 9, false)
+	}
+
+	pdf.Space(14)
+	pdf.Text("Results by candidate", 13, true)
+	pdf.Rule()
+	columns := []float64{0, 300, 390}
+	pdf.Row([]string{"Candidate", "Votes", "Share"}, columns, 10, true)
+	for _, candidate := range export.Candidates {
+		pdf.Row([]string{
+			candidate.Name,
+			strconv.FormatUint(uint64(candidate.TotalVotes), 10),
+			strconv.FormatFloat(candidate.Percentage, 'f', 2, 64) + "%",
+		}, columns, 10, false)
+	}
+
+	pdf.Space(14)
+	pdf.Text("Ballots", 13, true)
+	pdf.Rule()
+	if export.PublicBallot {
+		pdf.Row([]string{"Voter", "Candidate", "Voted at"}, []float64{0, 170, 340}, 9, true)
+		for _, ballot := range export.Ballots {
+			pdf.Row([]string{ballot.Voter, ballot.Candidate, ballot.VotedTime.Format(resultsTimeFormat)}, []float64{0, 170, 340}, 9, false)
+		}
+		if len(export.Ballots) == 0 {
+			pdf.Text("No ballots have been cast.", 9, false)
+		}
+	} else {
+		pdf.Text("This is a secret ballot; individual ballots are not disclosed.", 9, false)
+	}
+
+	pdf.Space(40)
+	pdf.Row([]string{"Moderator signature: ______________________", "Date: ______________"}, []float64{0, 300}, 10, false)
+	pdf.Space(20)
+	pdf.Rule()
+	pdf.Text("Generated "+export.GeneratedAt.Format(resultsTimeFormat)+". Signature (HMAC-SHA256):", 8, false)
+	pdf.Text(export.Signature, 8, true)
+	if verificationURL := ResultsVerificationURL(export); verificationURL != "" {
+		pdf.Text("Verify this certificate at "+verificationURL, 8, false)
+	}
+	return pdf.Bytes()
+}
+
+func formatResultsEnd(end *time.Time, layout string) string {
+	if end == nil {
+		return ""
+	}
+	return end.Format(layout)
+}
+
+func formatEligibleVoters(eligibleVoters *int64) string {
+	if eligibleVoters == nil {
+		return "Anyone with the vote code"
+	}
+	return strconv.FormatInt(*eligibleVoters, 10)
+}
+
+func formatTurnout(turnout *float64) string {
+	if turnout == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*turnout, 'f', 2, 64) + "%"
+}
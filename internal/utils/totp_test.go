@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238 appendix B, "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes; a 6 digit code is their last 6 digits.
+	tests := []struct {
+		unixTime int64
+		code     string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, test := range tests {
+		code, err := generateTOTPCode(rfc6238Secret, uint64(test.unixTime/totpPeriod))
+		if err != nil {
+			t.Fatalf("generateTOTPCode(%d) returned error: %v", test.unixTime, err)
+		}
+		if code != test.code {
+			t.Errorf("generateTOTPCode(%d) = %s, want %s", test.unixTime, code, test.code)
+		}
+	}
+}
+
+func TestGenerateTOTPCodeNormalizesSecret(t *testing.T) {
+	code, err := generateTOTPCode(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil {
+		t.Fatalf("generateTOTPCode returned error: %v", err)
+	}
+	if code != "287082" {
+		t.Errorf("generateTOTPCode = %s, want 287082", code)
+	}
+	if _, err := generateTOTPCode("not base32!", 1); err == nil {
+		t.Error("generateTOTPCode accepted an invalid secret")
+	}
+}
+
+func TestMatchTOTPStep(t *testing.T) {
+	counter := uint64(time.Now().Unix() / totpPeriod)
+	codeAt := func(counter uint64) string {
+		code, err := generateTOTPCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("generateTOTPCode returned error: %v", err)
+		}
+		return code
+	}
+	current := codeAt(counter)
+	tests := []struct {
+		name  string
+		code  string
+		step  uint64
+		valid bool
+	}{
+		{"current code", current, counter, true},
+		{"spaced code", current[:3] + " " + current[3:], counter, true},
+		{"previous period", codeAt(counter - 1), counter - 1, true},
+		{"next period", codeAt(counter + 1), counter + 1, true},
+		{"outside the skew", codeAt(counter - 3), 0, false},
+		{"too short", current[:5], 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, test := range tests {
+		step, valid := matchTOTPStep(rfc6238Secret, test.code)
+		if valid != test.valid || step != test.step {
+			t.Errorf("%s: matchTOTPStep(%q) = %d, %v, want %d, %v", test.name, test.code, step, valid, test.step, test.valid)
+		}
+	}
+	if _, valid := matchTOTPStep("not base32!", current); valid {
+		t.Error("matchTOTPStep accepted a code for an invalid secret")
+	}
+}
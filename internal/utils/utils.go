@@ -1,16 +1,12 @@
 package utils
 
 import (
-	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"math/big"
-	"net/http"
-	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -22,38 +18,39 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/gomail.v2"
 )
 
 var SecretKey []byte = []byte(os.Getenv("SECRET_KEY"))
+const RememberMeDuration = 7 * 24 * time.Hour
+// RememberTokenGracePeriod is how long the validator replaced by a rotation is
+// still accepted, for requests sent in parallel with the same cookie.
+const RememberTokenGracePeriod = 30 * time.Second
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 var logger *slog.Logger = config.SetUpLogger()
 
-func GenerateSecureToken(username string) (string, error) {
-	timestamp := time.Now().Unix()
-	data := fmt.Sprintf("%s:%d", username, timestamp)
-	h := hmac.New(sha256.New, []byte(SecretKey))
-	h.Write([]byte(data))
-	signature := hex.EncodeToString(h.Sum(nil))
-	return fmt.Sprintf("%s:%s", data, signature), nil
-}
-
-func ExtractUsername(token string) (string, error) {
-	parts := strings.Split(token, ":")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid token format")
+func GenerateRememberToken() (string, string, error) {
+	selector := make([]byte, 16)
+	if _, err := rand.Read(selector); err != nil {
+		return "", "", err
 	}
+	validator := make([]byte, 32)
+	if _, err := rand.Read(validator); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(selector), hex.EncodeToString(validator), nil
+}
 
-	data := fmt.Sprintf("%s:%s", parts[0], parts[1])
-	h := hmac.New(sha256.New, []byte(SecretKey))
-	h.Write([]byte(data))
-	expectedSignature := hex.EncodeToString(h.Sum(nil))
+func HashRememberToken(validator string) string {
+	hash := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(hash[:])
+}
 
-	if !hmac.Equal([]byte(expectedSignature), []byte(parts[2])) {
-		return "", fmt.Errorf("invalid token signature")
+func SplitRememberToken(token string) (string, string, error) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid token format")
 	}
-
-	return parts[0], nil
+	return parts[0], parts[1], nil
 }
 
 func IsValidEmail(email string) bool {
@@ -70,6 +67,16 @@ func HashPassword(password string) (string, error) {
     return string(hashedPassword), nil
 } 
 
+// GenerateRandomSecret returns 32 random bytes, hex encoded, for passwords
+// that no one should know, such as those of anonymized accounts.
+func GenerateRandomSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
 func ValidateLoginInput(username, password string, c *gin.Context) (string, string) {
 	usernameErr, passwordErr := "", ""
 
@@ -298,24 +305,6 @@ func GenerateResetToken(userEmail string) (string, error) {
     return tokenString, nil
 }
 
-func GetEmailDomain(email string) string {
-	index := strings.LastIndex(email, "@")
-	if index == -1 {
-		return ""
-	}
-	return email[index+1:]
-}
-
-func GetEmailProvider(emailDomain string) string {
-	providers := map[string]string{
-		"gmail.com": "smtp.gmail.com",
-		"yahoo.com": "smtp.yahoo.com",
-		"hotmail.com": "smtp-mail.outlook.com",
-		"outlook.com": "smtp-mail.outlook.com",
-	}
-	return providers[emailDomain]
-}
-
 func GenerateOTP() (string, error) {
 	const otpLength = 6
 	var otp string
@@ -335,72 +324,16 @@ func GenerateOTP() (string, error) {
 	return otp, nil
 }
 
-func SendEmail(email, emailProvider, body string, subject string) error {
-    services := map[string]struct {
-        from     string
-        password string
-        host     string
-        port     int
-    }{
-        "smtp.gmail.com": {
-            from:     os.Getenv("GMAIL_EMAIL"),
-            password: os.Getenv("GMAIL_PASSWORD"),
-            host:     "smtp.gmail.com",
-            port:     587,
-        },
-        "smtp-mail.outlook.com": {
-            from:     os.Getenv("OUTLOOK_EMAIL"),
-            password: os.Getenv("OUTLOOK_PASSWORD"),
-            host:     "smtp-mail.outlook.com",
-            port:     587,
-        },
-    }
-
-    service, exists := services[emailProvider]
-    if !exists {
-        return fmt.Errorf("unsupported email provider: %s", emailProvider)
-    }
-
-    m := gomail.NewMessage()
-    m.SetHeader("From", service.from)
-    m.SetHeader("To", email)
-    m.SetHeader("Subject", subject)
-    m.SetBody("text/html", body)
-
-    
-    d := gomail.NewDialer(service.host, service.port, service.from, service.password)
-
-    if err := d.DialAndSend(m); err != nil {
-		logger.Error(
-			"SendEmail - failed to send email",
-			"error", err,
-		)
-        return fmt.Errorf("failed to send email: %v", err)
-    }
-
-    return nil
-}
-
-func IsValidReCAPTCHA(c *gin.Context) bool {
-	recaptchaResponse := c.PostForm("g-recaptcha-response")
-	secretKey := os.Getenv("RECAPTCHA_SECRET_KEY")
-
-	resp, err := http.PostForm("https://www.google.com/recaptcha/api/siteverify",
-		url.Values{"secret": {secretKey}, "response": {recaptchaResponse}})
-
+func SendEmailMessage(message EmailMessage) error {
+	err := GetMailer().Send(message)
 	if err != nil {
 		logger.Error(
-			"IsValidReCAPTCHA - error verifying reCAPTCHA",
+			"SendEmailMessage - failed to send email",
 			"error", err,
 		)
-		return false
+		return fmt.Errorf("failed to send email: %v", err)
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	return result["success"].(bool)
+	return nil
 }
 
 func ValidateFeedbackInput(feedbackMessage string, feedbackRate uint, c *gin.Context) (string, string, string) {
@@ -429,12 +362,52 @@ func ValidateFeedbackInput(feedbackMessage string, feedbackRate uint, c *gin.Con
 		feedbackRateErr = "Feedback Rate must be filled"
 	}
 
-	if !IsValidReCAPTCHA(c) {
+	if !IsValidCaptcha(c) {
 		logger.Warn(
-			"ValidateFeedbackInput - Invalid ReCAPTCHA",
+			"ValidateFeedbackInput - Invalid CAPTCHA",
 			"Client IP", c.ClientIP(),
 		)
-		captchaErr = "Invalid ReCAPTCHA"
+		captchaErr = "Invalid CAPTCHA"
 	}
 	return feedbackMessageErr, feedbackRateErr, captchaErr
-}
\ No newline at end of file
+}
+
+func GroupVotesByOrganization(votes []models.Vote) []models.OrganizationVotes {
+	groups := []models.OrganizationVotes{}
+	groupIndex := map[uint]int{}
+	for _, vote := range votes {
+		var organizationID uint
+		if vote.OrganizationID != nil {
+			organizationID = *vote.OrganizationID
+		}
+		index, exists := groupIndex[organizationID]
+		if !exists {
+			organization := vote.Organization
+			if organizationID == 0 {
+				organization = models.Organization{OrganizationName: "Personal"}
+			}
+			groups = append(groups, models.OrganizationVotes{Organization: organization})
+			index = len(groups) - 1
+			groupIndex[organizationID] = index
+		}
+		groups[index].Votes = append(groups[index].Votes, vote)
+	}
+	return groups
+}
+
+func ValidateOrganizationInput(organizationName string, c *gin.Context) string {
+	organizationNameErr := ""
+	if len(organizationName) < 3 || len(organizationName) > 255 {
+		logger.Warn(
+			"ValidateOrganizationInput - organization name must be between 3 and 255 characters",
+			"Inputted Organization Name", organizationName,
+			"Client IP", c.ClientIP(),
+		)
+		organizationNameErr = "Organization name must be between 3 and 255 characters"
+	}
+	return organizationNameErr
+}
+
+func IsValidOrganizationRole(memberRole string) bool {
+	return memberRole == "admin" || memberRole == "member"
+}
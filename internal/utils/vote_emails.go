@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/config"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	// voteReminderDelay is how long after the start of a vote the voters who
+	// have not voted yet are reminded.
+	voteReminderDelay = 24 * time.Hour
+	// voteReminderWindow bounds how late a missed reminder is still sent, so a
+	// worker that was down does not remind voters of long running votes.
+	voteReminderWindow = 48 * time.Hour
+)
+
+func voteEmailURL(vote models.Vote) string {
+	return fmt.Sprintf("%s/electivote/vote-page/%s/", config.GetBaseURL(), vote.VoteCode)
+}
+
+// QueueVoteInvitations emails the vote code to everyone on the voter roll or
+// in the organization of a newly created vote.
+func QueueVoteInvitations(vote models.Vote) {
+	voters, err := repositories.GetInvitedVoters(vote)
+	if err != nil {
+		logger.Error(
+			"QueueVoteInvitations - failed to get invited voters",
+			"error", err,
+			"Vote ID", vote.VoteID,
+		)
+		return
+	}
+	if len(voters) == 0 {
+		return
+	}
+	moderatorName, err := repositories.GetModeratorNameByModeratorID(vote.ModeratorID)
+	if err != nil {
+		logger.Error(
+			"QueueVoteInvitations - failed to get moderator name",
+			"error", err,
+			"Vote ID", vote.VoteID,
+		)
+		return
+	}
+	for _, voter := range voters {
+		if voter.ID == vote.ModeratorID {
+			continue
+		}
+		err := QueueTemplateEmail(voter.Email, voter.Locale, "vote_invitation", map[string]interface{}{
+			"voteTitle":     vote.VoteTitle,
+			"username":      voter.Username,
+			"moderatorName": moderatorName,
+			"startTime":     vote.Start.Time.Format("2006-01-02 15:04"),
+			"voteCode":      vote.VoteCode,
+			"voteURL":       voteEmailURL(vote),
+		})
+		if err != nil {
+			logger.Error(
+				"QueueVoteInvitations - failed to queue invitation",
+				"error", err,
+				"Vote ID", vote.VoteID,
+				"User ID", voter.ID,
+			)
+		}
+	}
+}
+
+// RunVoteReminderWorker reminds the invited voters who have not voted a day
+// after a vote started. It is started once from main and checks every
+// interval.
+func RunVoteReminderWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		queueDueVoteReminders(time.Now())
+		<-ticker.C
+	}
+}
+
+func queueDueVoteReminders(now time.Time) {
+	dueBefore := now.Add(-voteReminderDelay)
+	votes, err := repositories.GetVotesDueForReminder(dueBefore.Add(-voteReminderWindow), dueBefore)
+	if err != nil {
+		logger.Error(
+			"queueDueVoteReminders - failed to get votes due for reminder",
+			"error", err,
+		)
+		return
+	}
+	for _, vote := range votes {
+		claimed, err := repositories.ClaimVoteReminder(vote.VoteID, models.CustomTime{Time: now})
+		if err != nil {
+			logger.Error(
+				"queueDueVoteReminders - failed to claim reminder",
+				"error", err,
+				"Vote ID", vote.VoteID,
+			)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		queueVoteReminders(vote)
+	}
+}
+
+func queueVoteReminders(vote models.Vote) {
+	voters, err := repositories.GetInvitedVoters(vote)
+	if err != nil {
+		logger.Error(
+			"queueVoteReminders - failed to get invited voters",
+			"error", err,
+			"Vote ID", vote.VoteID,
+		)
+		return
+	}
+	voteRecords, err := repositories.GetVoteRecordsByVoteID(vote.VoteID)
+	if err != nil {
+		logger.Error(
+			"queueVoteReminders - failed to get vote records",
+			"error", err,
+			"Vote ID", vote.VoteID,
+		)
+		return
+	}
+	voted := make(map[uint]bool, len(voteRecords))
+	for _, voteRecord := range voteRecords {
+		voted[voteRecord.UserId] = true
+	}
+	for _, voter := range voters {
+		if voted[voter.ID] || voter.ID == vote.ModeratorID {
+			continue
+		}
+		err := QueueTemplateEmail(voter.Email, voter.Locale, "vote_reminder", map[string]interface{}{
+			"voteTitle": vote.VoteTitle,
+			"username":  voter.Username,
+			"voteCode":  vote.VoteCode,
+			"voteURL":   voteEmailURL(vote),
+		})
+		if err != nil {
+			logger.Error(
+				"queueVoteReminders - failed to queue reminder",
+				"error", err,
+				"Vote ID", vote.VoteID,
+				"User ID", voter.ID,
+			)
+		}
+	}
+	logger.Info(
+		"queueVoteReminders - reminders queued",
+		"Vote ID", vote.VoteID,
+	)
+}
+
+// GetVoteResultRecipients returns the invited voters and everyone who cast a
+// ballot. It must be called before the vote and its records are deleted.
+func GetVoteResultRecipients(vote models.Vote) ([]models.User, error) {
+	voters, err := repositories.GetInvitedVoters(vote)
+	if err != nil {
+		return nil, err
+	}
+	voteRecords, err := repositories.GetVoteRecordsByVoteID(vote.VoteID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]bool, len(voters)+len(voteRecords))
+	recipients := make([]models.User, 0, len(voters)+len(voteRecords))
+	for _, voter := range voters {
+		seen[voter.ID] = true
+		recipients = append(recipients, voter)
+	}
+	for _, voteRecord := range voteRecords {
+		user := voteRecord.User
+		if seen[user.ID] || user.Suspended || !user.DeletedTime.IsZero() {
+			continue
+		}
+		seen[user.ID] = true
+		recipients = append(recipients, user)
+	}
+	return recipients, nil
+}
+
+// voteResultEmailCandidate is a row of the vote_results template.
+type voteResultEmailCandidate struct {
+	Name  string
+	Votes uint
+}
+
+// QueueVoteResultEmails sends the final results of an archived vote to the
+// given recipients, linking to the signed results verification page.
+func QueueVoteResultEmails(recipients []models.User, export ResultsExport) {
+	winnerName := "None"
+	if len(export.Winners) > 0 {
+		winnerName = strings.Join(export.Winners, ", ")
+	}
+	candidates := make([]voteResultEmailCandidate, 0, len(export.Candidates))
+	for _, candidate := range export.Candidates {
+		candidates = append(candidates, voteResultEmailCandidate{Name: candidate.Name, Votes: candidate.TotalVotes})
+	}
+	for _, recipient := range recipients {
+		err := QueueTemplateEmail(recipient.Email, recipient.Locale, "vote_results", map[string]interface{}{
+			"voteTitle":  export.Title,
+			"totalVotes": export.TotalVotes,
+			"winnerName": winnerName,
+			"candidates": candidates,
+			"resultURL":  ResultsVerificationURL(export),
+		})
+		if err != nil {
+			logger.Error(
+				"QueueVoteResultEmails - failed to queue results",
+				"error", err,
+				"Vote History ID", export.VoteHistoryID,
+				"User ID", recipient.ID,
+			)
+		}
+	}
+}
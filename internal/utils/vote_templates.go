@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VoteTemplatePlaceholders lists the placeholders that may be used in the
+// title and description of a vote template.
+var VoteTemplatePlaceholders = []string{"{month}", "{previous_month}", "{next_month}", "{year}", "{date}"}
+
+// ExpandVoteTemplate fills in the placeholders of a vote template for a vote
+// created at now.
+func ExpandVoteTemplate(text string, now time.Time) string {
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	replacer := strings.NewReplacer(
+		"{month}", now.Month().String(),
+		"{previous_month}", firstOfMonth.AddDate(0, -1, 0).Month().String(),
+		"{next_month}", firstOfMonth.AddDate(0, 1, 0).Month().String(),
+		"{year}", strconv.Itoa(now.Year()),
+		"{date}", now.Format("2006-01-02"),
+	)
+	return replacer.Replace(text)
+}
+
+// CopyCandidatePicture gives a duplicated vote or a template its own
+// reference to a candidate picture. Pictures stored by the image pipeline are
+// content addressed and only deleted once nothing references them, so they
+// are shared as is; older pictures saved under their upload name are run
+// through the pipeline. A missing picture falls back to the default picture.
+func CopyCandidatePicture(picture string) (string, error) {
+	picture = filepath.Base(picture)
+	if picture == "" || picture == "." || picture == DefaultImage {
+		return DefaultImage, nil
+	}
+	if isContentAddressedImage(picture) {
+		return picture, nil
+	}
+	source, err := GetBlobStore().Open(picture)
+	if errors.Is(err, ErrBlobNotFound) {
+		logger.Warn(
+			"CopyCandidatePicture - picture not found, using default picture",
+			"Picture", picture,
+		)
+		return DefaultImage, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	return StoreImage(source)
+}
+
+func isContentAddressedImage(name string) bool {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if len(stem) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(stem)
+	return err == nil
+}
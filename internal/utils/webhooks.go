@@ -0,0 +1,468 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AndreanDjabbar/ElectiVote/internal/factories"
+	"github.com/AndreanDjabbar/ElectiVote/internal/models"
+	"github.com/AndreanDjabbar/ElectiVote/internal/repositories"
+)
+
+const (
+	WebhookEventVoteCreated     = "vote.created"
+	WebhookEventVoteOpened      = "vote.opened"
+	WebhookEventBallotCast      = "ballot.cast"
+	WebhookEventVoteClosed      = "vote.closed"
+	WebhookEventResultPublished = "result.published"
+
+	WebhookEventHeader     = "X-ElectiVote-Event"
+	WebhookDeliveryHeader  = "X-ElectiVote-Delivery"
+	WebhookTimestampHeader = "X-ElectiVote-Timestamp"
+	WebhookSignatureHeader = "X-ElectiVote-Signature"
+
+	webhookPollInterval   = 5 * time.Second
+	webhookBatchSize      = 20
+	webhookMaxAttempts    = 8
+	webhookRetryBaseDelay = 30 * time.Second
+	webhookRetryMaxDelay  = time.Hour
+	webhookSendingTimeout = 10 * time.Minute
+	webhookRequestTimeout = 10 * time.Second
+	webhookErrorBodyLimit = 512
+	webhookMaxURLLength   = 2048
+)
+
+// WebhookEvents lists the events an endpoint can subscribe to, in the order
+// the webhooks page shows them.
+var WebhookEvents = []struct {
+	Name  string
+	Label string
+}{
+	{WebhookEventVoteCreated, "A vote is created"},
+	{WebhookEventVoteOpened, "A vote opens for ballots"},
+	{WebhookEventBallotCast, "A ballot is cast (vote counts only)"},
+	{WebhookEventVoteClosed, "A vote is closed"},
+	{WebhookEventResultPublished, "The final result is published"},
+}
+
+var webhookQueueWakeup = make(chan struct{}, 1)
+
+// webhookHTTPClient refuses to follow redirects or to dial private networks,
+// so a registered URL cannot be used to reach internal services.
+var webhookHTTPClient = &http.Client{
+	Timeout: webhookRequestTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: webhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+type WebhookVote struct {
+	ID             uint      `json:"id"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	Code           string    `json:"code"`
+	ModeratorID    uint      `json:"moderatorId"`
+	OrganizationID *uint     `json:"organizationId"`
+	Start          time.Time `json:"start"`
+}
+
+type WebhookCandidateTally struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	TotalVotes uint   `json:"totalVotes"`
+}
+
+type WebhookTally struct {
+	VoteID     uint                    `json:"voteId"`
+	TotalVotes uint                    `json:"totalVotes"`
+	Candidates []WebhookCandidateTally `json:"candidates"`
+}
+
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+func NewWebhookVote(vote models.Vote) WebhookVote {
+	return WebhookVote{
+		ID:             vote.VoteID,
+		Title:          vote.VoteTitle,
+		Description:    vote.VoteDescription,
+		Code:           vote.VoteCode,
+		ModeratorID:    vote.ModeratorID,
+		OrganizationID: vote.OrganizationID,
+		Start:          vote.Start.Time,
+	}
+}
+
+func NewWebhookTally(results LiveResults) WebhookTally {
+	tally := WebhookTally{
+		VoteID:     results.VoteID,
+		TotalVotes: results.TotalVotes,
+		Candidates: make([]WebhookCandidateTally, 0, len(results.Candidates)),
+	}
+	for _, candidate := range results.Candidates {
+		tally.Candidates = append(tally.Candidates, WebhookCandidateTally{
+			ID:         candidate.CandidateID,
+			Name:       candidate.CandidateName,
+			TotalVotes: candidate.TotalVotes,
+		})
+	}
+	return tally
+}
+
+func IsValidWebhookEvent(event string) bool {
+	for _, webhookEvent := range WebhookEvents {
+		if webhookEvent.Name == event {
+			return true
+		}
+	}
+	return false
+}
+
+func HasWebhookEvent(events, event string) bool {
+	for _, subscribed := range strings.Split(events, ",") {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateWebhookURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return "Webhook URL must be an absolute http or https URL"
+	}
+	if len(rawURL) > webhookMaxURLLength {
+		return "Webhook URL is too long"
+	}
+	if parsed.User != nil {
+		return "Webhook URL must not contain credentials"
+	}
+	return ""
+}
+
+func GenerateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<payload>",
+// sent in the X-ElectiVote-Signature header. Receivers recompute it with the
+// endpoint secret and compare in constant time, as validateSignature does for
+// incoming Saweria callbacks.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// QueueWebhookEvent stores one delivery of the event for every active
+// endpoint of the vote or its organization that subscribed to it.
+func QueueWebhookEvent(event string, vote models.Vote, data interface{}) {
+	endpoints, err := repositories.GetActiveWebhookEndpointsForVote(vote.VoteID, vote.OrganizationID)
+	if err != nil {
+		logger.Error(
+			"QueueWebhookEvent - failed to get webhook endpoints",
+			"error", err,
+			"Event", event,
+			"Vote ID", vote.VoteID,
+		)
+		return
+	}
+	subscribed := []models.WebhookEndpoint{}
+	for _, endpoint := range endpoints {
+		if HasWebhookEvent(endpoint.Events, event) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	rawEventID := make([]byte, 16)
+	_, err = rand.Read(rawEventID)
+	if err != nil {
+		logger.Error(
+			"QueueWebhookEvent - failed to generate event ID",
+			"error", err,
+			"Event", event,
+		)
+		return
+	}
+	now := time.Now()
+	payload, err := json.Marshal(webhookEnvelope{
+		ID:        hex.EncodeToString(rawEventID),
+		Event:     event,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		logger.Error(
+			"QueueWebhookEvent - failed to marshal payload",
+			"error", err,
+			"Event", event,
+		)
+		return
+	}
+
+	for _, endpoint := range subscribed {
+		_, err = repositories.CreateWebhookDelivery(factories.WebhookDeliveryFactory(endpoint.WebhookEndpointID, event, string(payload), models.CustomTime{Time: now}))
+		if err != nil {
+			logger.Error(
+				"QueueWebhookEvent - failed to queue delivery",
+				"error", err,
+				"Event", event,
+				"Webhook Endpoint ID", endpoint.WebhookEndpointID,
+			)
+		}
+	}
+	wakeWebhookWorkers()
+}
+
+// QueueVoteCreatedWebhooks announces a new vote. Votes accept ballots as soon
+// as they are created, so vote.opened follows immediately.
+func QueueVoteCreatedWebhooks(vote models.Vote) {
+	QueueWebhookEvent(WebhookEventVoteCreated, vote, map[string]interface{}{
+		"vote": NewWebhookVote(vote),
+	})
+	QueueWebhookEvent(WebhookEventVoteOpened, vote, map[string]interface{}{
+		"vote":     NewWebhookVote(vote),
+		"openedAt": vote.Start.Time,
+	})
+}
+
+// QueueBallotCastWebhooks sends the updated counts only; who voted and for
+// whom is never part of the payload.
+func QueueBallotCastWebhooks(voteID uint) {
+	vote, err := repositories.GetVoteDataByVoteID(voteID)
+	if err != nil {
+		logger.Error(
+			"QueueBallotCastWebhooks - failed to get vote",
+			"error", err,
+			"Vote ID", voteID,
+		)
+		return
+	}
+	results, err := GetLiveResults(voteID)
+	if err != nil {
+		logger.Error(
+			"QueueBallotCastWebhooks - failed to get results",
+			"error", err,
+			"Vote ID", voteID,
+		)
+		return
+	}
+	QueueWebhookEvent(WebhookEventBallotCast, vote, NewWebhookTally(results))
+}
+
+// QueueVoteClosedWebhooks announces the closed vote and its final tally, then
+// deactivates the endpoints registered for that vote alone.
+func QueueVoteClosedWebhooks(vote models.Vote, results LiveResults, closedAt time.Time) {
+	QueueWebhookEvent(WebhookEventVoteClosed, vote, map[string]interface{}{
+		"vote":     NewWebhookVote(vote),
+		"closedAt": closedAt,
+	})
+
+	tally := NewWebhookTally(results)
+	var winner *WebhookCandidateTally
+	for i, candidate := range tally.Candidates {
+		if winner == nil || candidate.TotalVotes > winner.TotalVotes {
+			winner = &tally.Candidates[i]
+		}
+	}
+	QueueWebhookEvent(WebhookEventResultPublished, vote, map[string]interface{}{
+		"vote":       NewWebhookVote(vote),
+		"totalVotes": tally.TotalVotes,
+		"winner":     winner,
+		"candidates": tally.Candidates,
+	})
+
+	err := repositories.DeactivateVoteWebhookEndpoints(vote.VoteID)
+	if err != nil {
+		logger.Error(
+			"QueueVoteClosedWebhooks - failed to deactivate vote endpoints",
+			"error", err,
+			"Vote ID", vote.VoteID,
+		)
+	}
+}
+
+// ReplayWebhookDelivery queues the stored payload again as a new delivery,
+// keeping the original attempt in the log.
+func ReplayWebhookDelivery(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	replay, err := repositories.CreateWebhookDelivery(factories.WebhookDeliveryFactory(delivery.WebhookEndpointID, delivery.Event, delivery.Payload, models.CustomTime{Time: time.Now()}))
+	if err != nil {
+		return replay, err
+	}
+	wakeWebhookWorkers()
+	return replay, nil
+}
+
+func wakeWebhookWorkers() {
+	select {
+	case webhookQueueWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookWorkers launches the given number of goroutines that deliver
+// queued webhooks.
+func StartWebhookWorkers(count int) {
+	for i := 0; i < count; i++ {
+		go runWebhookWorker()
+	}
+}
+
+func runWebhookWorker() {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		processWebhookQueue()
+		select {
+		case <-ticker.C:
+		case <-webhookQueueWakeup:
+		}
+	}
+}
+
+func processWebhookQueue() {
+	now := time.Now()
+	staleBefore := now.Add(-webhookSendingTimeout)
+	ids, err := repositories.GetDueWebhookDeliveryIDs(now, staleBefore, webhookBatchSize)
+	if err != nil {
+		logger.Error(
+			"processWebhookQueue - failed to get due deliveries",
+			"error", err,
+		)
+		return
+	}
+	for _, id := range ids {
+		delivery, claimed, err := repositories.ClaimWebhookDelivery(id, time.Now(), staleBefore)
+		if err != nil {
+			logger.Error(
+				"processWebhookQueue - failed to claim delivery",
+				"error", err,
+				"Webhook Delivery ID", id,
+			)
+			continue
+		}
+		if claimed {
+			deliverWebhook(delivery)
+		}
+	}
+}
+
+func deliverWebhook(delivery models.WebhookDelivery) {
+	attempts := delivery.Attempts + 1
+	responseStatus, err := sendWebhook(delivery)
+	if err == nil {
+		err = repositories.MarkWebhookDeliveryDelivered(delivery.WebhookDeliveryID, attempts, responseStatus, time.Now())
+		if err != nil {
+			logger.Error(
+				"deliverWebhook - failed to mark delivery as delivered",
+				"error", err,
+				"Webhook Delivery ID", delivery.WebhookDeliveryID,
+			)
+		}
+		return
+	}
+
+	status := "pending"
+	if attempts >= webhookMaxAttempts {
+		status = "dead"
+	}
+	nextAttemptTime := time.Now().Add(retryDelay(attempts, webhookRetryBaseDelay, webhookRetryMaxDelay))
+	logger.Warn(
+		"deliverWebhook - delivery failed",
+		"error", err,
+		"Webhook Delivery ID", delivery.WebhookDeliveryID,
+		"Attempts", attempts,
+		"Status", status,
+	)
+	err = repositories.MarkWebhookDeliveryFailed(delivery.WebhookDeliveryID, attempts, responseStatus, err.Error(), status, nextAttemptTime)
+	if err != nil {
+		logger.Error(
+			"deliverWebhook - failed to record delivery failure",
+			"error", err,
+			"Webhook Delivery ID", delivery.WebhookDeliveryID,
+		)
+	}
+}
+
+// sendWebhook posts the payload and treats any 2xx answer as delivered.
+func sendWebhook(delivery models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request, err := http.NewRequest(http.MethodPost, delivery.WebhookEndpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "ElectiVote-Webhooks/1.0")
+	request.Header.Set(WebhookEventHeader, delivery.Event)
+	request.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.WebhookDeliveryID), 10))
+	request.Header.Set(WebhookTimestampHeader, timestamp)
+	request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.WebhookEndpoint.Secret, timestamp, payload))
+
+	response, err := webhookHTTPClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(response.Body, webhookErrorBodyLimit))
+		return response.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, webhookErrorBodyLimit))
+	return response.StatusCode, fmt.Errorf("endpoint answered %s: %s", response.Status, strings.TrimSpace(string(body)))
+}
+
+// webhookDialControl blocks loopback, private and link-local addresses unless
+// WEBHOOK_ALLOW_PRIVATE_NETWORKS=true (useful in development).
+func webhookDialControl(network, address string, conn syscall.RawConn) error {
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errors.New("webhook address " + host + " is not allowed")
+	}
+	return nil
+}